@@ -47,7 +47,7 @@ func (s *SmartContract) NFRMint(ctx contractapi.TransactionContextInterface, acc
 	}
 
 	// 获取用户客户端信息ID
-	operator, err := ctx.GetClientIdentity().GetID()
+	operator, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("[MintNFR] failed to get client id: %v", err)
 	}
@@ -118,7 +118,7 @@ func (s *SmartContract) NFRMintBatch(ctx contractapi.TransactionContextInterface
 	}
 
 	// 获取用户客户端信息ID
-	operator, err := ctx.GetClientIdentity().GetID()
+	operator, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("[NFRMintBatch] failed to get client id: %v", err)
 	}
@@ -173,14 +173,29 @@ func (s *SmartContract) NFRMintBatch(ctx contractapi.TransactionContextInterface
 
 /*
 	NFRMintBatchWithFee 批量创建NFR(内置扣手续费操作)
+	Deprecated: 使用 NFRMintBatchWithFeeV2, feeCollector 必须是已登记的手续费账户, 手续费按登记的比例分配
 	account: 账户
 	feeCollector: 手续费收取账户
 	batchIDs: NFR的唯一KEY值列表
 	metas: NFR的信息列表 (base64数据)
 	amounts: 数量
-	fee: 手续费
 */
 func (s *SmartContract) NFRMintBatchWithFee(ctx contractapi.TransactionContextInterface, account, feeCollector string, batchIDs, metas, tokenIds []string, amounts []uint64) ([][]*proto.NftMetadata, error) {
+	if err := utils.CheckFeeCollectorHelper(ctx, feeCollector); err != nil {
+		return nil, fmt.Errorf("[NFRMintBatchWithFee] %v", err)
+	}
+
+	return s.NFRMintBatchWithFeeV2(ctx, account, batchIDs, metas, tokenIds, amounts)
+}
+
+/*
+	NFRMintBatchWithFeeV2 批量创建NFR(内置扣手续费操作), 手续费转给登记的手续费收取账户
+	account: 账户
+	batchIDs: NFR的唯一KEY值列表
+	metas: NFR的信息列表 (base64数据)
+	amounts: 数量
+*/
+func (s *SmartContract) NFRMintBatchWithFeeV2(ctx contractapi.TransactionContextInterface, account string, batchIDs, metas, tokenIds []string, amounts []uint64) ([][]*proto.NftMetadata, error) {
 	log.Printf("[NFRMintBatchWithFee] start")
 	// 参数校验
 	length := len(batchIDs)
@@ -199,13 +214,13 @@ func (s *SmartContract) NFRMintBatchWithFee(ctx contractapi.TransactionContextIn
 	}
 
 	// 获取用户客户端信息ID
-	operator, err := ctx.GetClientIdentity().GetID()
+	operator, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("[NFRMintBatchWithFee] failed to get client id: %v", err)
 	}
 
 	// 收取手续费
-	if err = utils.MintNFRPayCoinsHelper(ctx, feeCollector); err != nil {
+	if err = utils.MintNFRPayCoinsHelper(ctx, batchIDs, amounts); err != nil {
 		log.Printf("MintNFRPayCoinsHelper failed, err: %v", err)
 		return nil, err
 	}
@@ -282,7 +297,7 @@ func (s *SmartContract) NFRBurn(ctx contractapi.TransactionContextInterface, acc
 	}
 
 	// 获取用户客户端信息ID
-	operator, err := ctx.GetClientIdentity().GetID()
+	operator, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return fmt.Errorf("[BurnNFR] failed to get client id: %v", err)
 	}
@@ -327,7 +342,7 @@ func (s *SmartContract) NFRBurnBatch(ctx contractapi.TransactionContextInterface
 	}
 
 	// 获取用户客户端信息ID
-	operator, err := ctx.GetClientIdentity().GetID()
+	operator, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return fmt.Errorf("[BurnNFRBatch] failed to get client id: %v", err)
 	}
@@ -350,12 +365,29 @@ func (s *SmartContract) NFRBurnBatch(ctx contractapi.TransactionContextInterface
 
 /*
 	NFRTrade NFR交易
+	Deprecated: 使用 NFRTradeV2, feeCollector 必须是已登记的手续费账户, 手续费按登记的比例分配
 	NFRSender: NFR当前持有账户
 	batchId: NFR批次类型
 	amount: 数量
 	totalPrice: 总价值(相对于稳定币)
 */
 func (s *SmartContract) NFRTrade(ctx contractapi.TransactionContextInterface, NFRSender, feeCollector, batchId string, amount, totalPrice uint64) ([]*proto.NftMetadata, error) {
+	if err := utils.CheckFeeCollectorHelper(ctx, feeCollector); err != nil {
+		return nil, fmt.Errorf("[NFRTrade] %v", err)
+	}
+
+	return s.NFRTradeV2(ctx, NFRSender, batchId, amount, totalPrice)
+}
+
+/*
+	NFRTradeV2 NFR交易, 手续费转给登记的手续费收取账户
+	成交价超过转售限价时不成交, 触发 ResaleRejected 事件并返回空列表
+	NFRSender: NFR当前持有账户
+	batchId: NFR批次类型
+	amount: 数量
+	totalPrice: 总价值(相对于稳定币)
+*/
+func (s *SmartContract) NFRTradeV2(ctx contractapi.TransactionContextInterface, NFRSender, batchId string, amount, totalPrice uint64) ([]*proto.NftMetadata, error) {
 	var nftTradeList []*proto.NftMetadata
 	// 接收者不能为空账户
 	if NFRSender == proto.EmptyAccount {
@@ -363,18 +395,32 @@ func (s *SmartContract) NFRTrade(ctx contractapi.TransactionContextInterface, NF
 	}
 
 	// 获取用户客户端信息ID
-	recipient, err := ctx.GetClientIdentity().GetID()
+	recipient, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return nftTradeList, fmt.Errorf("[NFRTrade] failed to get client id: %v", err)
 	}
 
+	// 转售限价校验, 超过限价时不成交
+	if allowed, err := _checkResaleCap(ctx, NFRSender, recipient, []string{batchId}, []uint64{amount}, totalPrice); err != nil || !allowed {
+		return nftTradeList, err
+	}
+
 	// 支付稳定币费用和手续费
-	if err = utils.TradeNFRPayCoinsHelper(ctx, NFRSender, feeCollector, totalPrice); err != nil {
+	if err = utils.TradeNFRPayCoinsHelper(ctx, NFRSender, totalPrice, []string{batchId}, []uint64{amount}); err != nil {
 		return nftTradeList, err
 	}
 
+	return _nfrTrade(ctx, recipient, NFRSender, batchId, amount)
+}
+
+/*
+	_nfrTrade 支付完成后将NFR转给买方
+	recipient: 买方账户
+	NFRSender: NFR当前持有账户
+*/
+func _nfrTrade(ctx contractapi.TransactionContextInterface, recipient, NFRSender, batchId string, amount uint64) ([]*proto.NftMetadata, error) {
 	// NFR交易
-	nftTradeList, err = utils.TransferHelper(ctx, NFRSender, recipient, []string{batchId}, []uint64{amount})
+	nftTradeList, err := utils.TransferHelper(ctx, NFRSender, recipient, []string{batchId}, []uint64{amount})
 	if err != nil {
 		return nftTradeList, err
 	}
@@ -392,6 +438,7 @@ func (s *SmartContract) NFRTrade(ctx contractapi.TransactionContextInterface, NF
 
 /*
 	NFRTrade NFR批量交易
+	Deprecated: 使用 NFRTradeBatchV2, feeCollector 必须是已登记的手续费账户, 手续费按登记的比例分配
 	NFRSender: NFR当前持有账户
 	feeCollector: 收取手续费账户
 	batchIds: NFR批次类型列表
@@ -400,6 +447,24 @@ func (s *SmartContract) NFRTrade(ctx contractapi.TransactionContextInterface, NF
 */
 func (s *SmartContract) NFRTradeBatch(
 	ctx contractapi.TransactionContextInterface, NFRSender, feeCollector string, batchIds []string, amounts []uint64, totalPrice uint64,
+) ([]*proto.NftMetadata, error) {
+	if err := utils.CheckFeeCollectorHelper(ctx, feeCollector); err != nil {
+		return nil, fmt.Errorf("[NFRTrade] %v", err)
+	}
+
+	return s.NFRTradeBatchV2(ctx, NFRSender, batchIds, amounts, totalPrice)
+}
+
+/*
+	NFRTradeBatchV2 NFR批量交易, 手续费转给登记的手续费收取账户
+	成交总价超过转售限价时不成交, 触发 ResaleRejected 事件并返回空列表
+	NFRSender: NFR当前持有账户
+	batchIds: NFR批次类型列表
+	amounts: 数量列表
+	totalPrice: 总价值(相对于稳定币)
+*/
+func (s *SmartContract) NFRTradeBatchV2(
+	ctx contractapi.TransactionContextInterface, NFRSender string, batchIds []string, amounts []uint64, totalPrice uint64,
 ) ([]*proto.NftMetadata, error) {
 	var nftTradeList []*proto.NftMetadata
 	// 接收者不能为空账户
@@ -408,13 +473,18 @@ func (s *SmartContract) NFRTradeBatch(
 	}
 
 	// 获取用户客户端信息ID
-	recipient, err := ctx.GetClientIdentity().GetID()
+	recipient, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return nftTradeList, fmt.Errorf("[NFRTrade] failed to get client id: %v", err)
 	}
 
+	// 转售限价校验, 超过限价时不成交
+	if allowed, err := _checkResaleCap(ctx, NFRSender, recipient, batchIds, amounts, totalPrice); err != nil || !allowed {
+		return nftTradeList, err
+	}
+
 	// 支付稳定币费用和手续费
-	if err = utils.TradeNFRPayCoinsHelper(ctx, NFRSender, feeCollector, totalPrice); err != nil {
+	if err = utils.TradeNFRPayCoinsHelper(ctx, NFRSender, totalPrice, batchIds, amounts); err != nil {
 		return nftTradeList, err
 	}
 
@@ -454,11 +524,19 @@ func (s *SmartContract) TransferFrom(ctx contractapi.TransactionContextInterface
 	}
 
 	// 获取用户客户端信息ID
-	operator, err := ctx.GetClientIdentity().GetID()
+	operator, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return nftTradeList, fmt.Errorf("[TransferFrom] failed to get client id: %v", err)
 	}
 
+	return _transferFrom(ctx, operator, sender, recipient, batchId, amount)
+}
+
+/*
+	_transferFrom: operator 转移 sender 的资产
+*/
+func _transferFrom(ctx contractapi.TransactionContextInterface, operator, sender, recipient, batchId string, amount uint64) ([]*proto.NftMetadata, error) {
+	var nftTradeList []*proto.NftMetadata
 	// 如果发送账号不是操作者本人，则需要检查是否已授权（否则不能操作）
 	if operator != sender {
 		approved, err := _isApprovedForAll(ctx, sender, operator)
@@ -471,7 +549,7 @@ func (s *SmartContract) TransferFrom(ctx contractapi.TransactionContextInterface
 	}
 
 	// 减少发送者的资产
-	nftTradeList, err = utils.TransferHelper(ctx, sender, recipient, []string{batchId}, []uint64{amount})
+	nftTradeList, err := utils.TransferHelper(ctx, sender, recipient, []string{batchId}, []uint64{amount})
 	if err != nil {
 		return nftTradeList, err
 	}
@@ -511,7 +589,7 @@ func (s *SmartContract) BatchTransferFrom(ctx contractapi.TransactionContextInte
 	}
 
 	// 获取用户客户端信息ID
-	operator, err := ctx.GetClientIdentity().GetID()
+	operator, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return nftTradeList, fmt.Errorf("[BatchTransferFrom] failed to get client id: %v", err)
 	}
@@ -557,15 +635,21 @@ func (s *SmartContract) BatchTransferFromMultiRecipient(ctx contractapi.Transact
 	if len(recipients) != len(batchIds) || len(recipients) != len(amounts) {
 		return nftTradeList, fmt.Errorf("[BatchTransferFromMultiRecipient] ids and amounts must have the same length")
 	}
-	// 不能转移给自己
-	for _, recipient := range recipients {
+	// 不能转移给自己, 同一接收者和批次不能重复(否则逐笔校验的接收限制会被绕过)
+	seen := make(map[proto.ToID]bool)
+	for i, recipient := range recipients {
 		if sender == recipient {
 			return nftTradeList, fmt.Errorf("[BatchTransferFromMultiRecipient] transfer to self")
 		}
+		pair := proto.ToID{To: recipient, ID: batchIds[i]}
+		if seen[pair] {
+			return nftTradeList, fmt.Errorf("[BatchTransferFromMultiRecipient] duplicate recipient (%s) for batch (%s)", recipient, batchIds[i])
+		}
+		seen[pair] = true
 	}
 
 	// 获取用户客户端信息ID
-	operator, err := ctx.GetClientIdentity().GetID()
+	operator, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return nftTradeList, fmt.Errorf("[BatchTransferFromMultiRecipient] failed to get client id: %v", err)
 	}
@@ -608,7 +692,7 @@ func (s *SmartContract) BatchTransferFromMultiRecipient(ctx contractapi.Transact
 */
 func (s *SmartContract) SetApprovalForAll(ctx contractapi.TransactionContextInterface, account string, approved bool) error {
 	// 获取用户客户端信息ID
-	operator, err := ctx.GetClientIdentity().GetID()
+	operator, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return fmt.Errorf("[SetApprovalForAll] failed to get client id: %v", err)
 	}
@@ -684,7 +768,7 @@ func (s *SmartContract) BalanceOfBatch(ctx contractapi.TransactionContextInterfa
 func (s *SmartContract) ClientAccountBalance(ctx contractapi.TransactionContextInterface, batchId string) (uint64, error) {
 
 	// 获取用户客户端信息ID
-	clientID, err := ctx.GetClientIdentity().GetID()
+	clientID, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("[ClientAccountBalance] failed to get client id: %v", err)
 	}
@@ -698,7 +782,7 @@ func (s *SmartContract) ClientAccountBalance(ctx contractapi.TransactionContextI
 func (s *SmartContract) ClientAccountID(ctx contractapi.TransactionContextInterface) (string, error) {
 
 	// 获取用户客户端信息ID
-	clientAccountID, err := ctx.GetClientIdentity().GetID()
+	clientAccountID, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return "", fmt.Errorf("[ClientAccountID] failed to get client id: %v", err)
 	}
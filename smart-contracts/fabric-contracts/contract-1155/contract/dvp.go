@@ -0,0 +1,318 @@
+package contract
+
+import (
+	"contract-1155/proto"
+	"contract-1155/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+)
+
+/*
+	CreateDvp 创建券款对付订单, 创建者必须是买方或卖方
+	orderId: 订单ID
+	seller: 交付NFR的卖方
+	buyer: 支付稳定币的买方
+	batchId: NFR批次类型
+	amount: 数量
+	price: 总价值(相对于稳定币)
+	expiry: 过期时间(Unix秒)
+*/
+func (s *SmartContract) CreateDvp(ctx contractapi.TransactionContextInterface, orderId, seller, buyer, batchId string, amount, price uint64, expiry int64) (*proto.DvpOrder, error) {
+	// 参数校验
+	if orderId == "" || batchId == "" {
+		return nil, fmt.Errorf("[CreateDvp] orderId and batchId must not be empty")
+	}
+	if seller == buyer {
+		return nil, fmt.Errorf("[CreateDvp] seller and buyer must be different")
+	}
+	if seller == proto.EmptyAccount || buyer == proto.EmptyAccount {
+		return nil, fmt.Errorf("[CreateDvp] seller and buyer must not be the zero address")
+	}
+	if amount == 0 {
+		return nil, fmt.Errorf("[CreateDvp] amount must be a positive integer")
+	}
+
+	// 获取交易时间戳
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("[CreateDvp] failed to get transaction timestamp: %v", err)
+	}
+	if expiry <= txTimestamp.GetSeconds() {
+		return nil, fmt.Errorf("[CreateDvp] expiry (%d) must be later than transaction time (%d)", expiry, txTimestamp.GetSeconds())
+	}
+
+	// 获取用户客户端信息ID
+	creator, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[CreateDvp] failed to get client id: %v", err)
+	}
+	if creator != seller && creator != buyer {
+		return nil, fmt.Errorf("[CreateDvp] caller is neither the seller nor the buyer")
+	}
+
+	// 订单ID不能重复
+	existing, err := _readDvp(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("[CreateDvp] %v", err)
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("[CreateDvp] dvp order (%s) already exists", orderId)
+	}
+
+	order := &proto.DvpOrder{
+		ID:      orderId,
+		Creator: creator,
+		Seller:  seller,
+		Buyer:   buyer,
+		BatchID: batchId,
+		Amount:  amount,
+		Price:   price,
+		Expiry:  expiry,
+		Status:  proto.DvpStatusCreated,
+	}
+	if err = _putDvp(ctx, order, "DvpCreated"); err != nil {
+		return nil, fmt.Errorf("[CreateDvp] %v", err)
+	}
+
+	return order, nil
+}
+
+/*
+	AcceptDvpDelivery 卖方确认交付NFR, 之后买方确认支付时交割
+	orderId: 订单ID
+*/
+func (s *SmartContract) AcceptDvpDelivery(ctx contractapi.TransactionContextInterface, orderId string) (*proto.DvpOrder, error) {
+	order, err := _openDvp(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("[AcceptDvpDelivery] %v", err)
+	}
+
+	// 获取用户客户端信息ID
+	clientID, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[AcceptDvpDelivery] failed to get client id: %v", err)
+	}
+	if clientID != order.Seller {
+		return nil, fmt.Errorf("[AcceptDvpDelivery] caller is not the seller")
+	}
+	if order.SellerAccepted {
+		return nil, fmt.Errorf("[AcceptDvpDelivery] dvp order (%s) is already accepted by the seller", orderId)
+	}
+
+	// 卖方当前的NFR数量必须足够
+	balance, err := utils.BalanceOfHelper(ctx, order.Seller, order.BatchID)
+	if err != nil {
+		return nil, fmt.Errorf("[AcceptDvpDelivery] %v", err)
+	}
+	if balance < order.Amount {
+		return nil, fmt.Errorf("[AcceptDvpDelivery] seller has (%d) of batch (%s), need (%d)", balance, order.BatchID, order.Amount)
+	}
+
+	order.SellerAccepted = true
+	if err = _putDvp(ctx, order, "DvpAccepted"); err != nil {
+		return nil, fmt.Errorf("[AcceptDvpDelivery] %v", err)
+	}
+
+	return order, nil
+}
+
+/*
+	AcceptDvpPayment 买方确认支付稳定币并在同一笔交易中完成交割, 卖方必须已确认交付
+	orderId: 订单ID
+	coinPayload: 买方签名的稳定币合约 TransferBatch 代理调用数据
+	coinSignature: 对coinPayload的签名
+	签名数据只在本次调用中使用, 不会保存到订单中
+*/
+func (s *SmartContract) AcceptDvpPayment(ctx contractapi.TransactionContextInterface, orderId, coinPayload, coinSignature string) (*proto.DvpOrder, error) {
+	order, err := _openDvp(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("[AcceptDvpPayment] %v", err)
+	}
+
+	// 获取用户客户端信息ID
+	clientID, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[AcceptDvpPayment] failed to get client id: %v", err)
+	}
+	if clientID != order.Buyer {
+		return nil, fmt.Errorf("[AcceptDvpPayment] caller is not the buyer")
+	}
+	if !order.SellerAccepted {
+		return nil, fmt.Errorf("[AcceptDvpPayment] dvp order (%s) is not accepted by the seller yet", orderId)
+	}
+
+	return _settleDvp(ctx, order, coinPayload, coinSignature)
+}
+
+/*
+	CancelDvp 取消订单, 交割前买方或卖方可以取消, 过期后任何人都可以将订单标记为过期
+	orderId: 订单ID
+*/
+func (s *SmartContract) CancelDvp(ctx contractapi.TransactionContextInterface, orderId string) (*proto.DvpOrder, error) {
+	order, err := _readDvp(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("[CancelDvp] %v", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("[CancelDvp] dvp order (%s) does not exist", orderId)
+	}
+	if order.Status != proto.DvpStatusCreated {
+		return nil, fmt.Errorf("[CancelDvp] dvp order (%s) is already %s", orderId, order.Status)
+	}
+
+	// 获取用户客户端信息ID
+	clientID, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[CancelDvp] failed to get client id: %v", err)
+	}
+
+	// 获取交易时间戳
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("[CancelDvp] failed to get transaction timestamp: %v", err)
+	}
+
+	eventName := "DvpCancelled"
+	switch {
+	case txTimestamp.GetSeconds() >= order.Expiry:
+		order.Status, eventName = proto.DvpStatusExpired, "DvpExpired"
+	case clientID == order.Seller || clientID == order.Buyer:
+		order.Status = proto.DvpStatusCancelled
+	default:
+		return nil, fmt.Errorf("[CancelDvp] caller is neither the seller nor the buyer")
+	}
+
+	if err = _putDvp(ctx, order, eventName); err != nil {
+		return nil, fmt.Errorf("[CancelDvp] %v", err)
+	}
+
+	return order, nil
+}
+
+/*
+	GetDvp 查询券款对付订单
+	orderId: 订单ID
+*/
+func (s *SmartContract) GetDvp(ctx contractapi.TransactionContextInterface, orderId string) (*proto.DvpOrder, error) {
+	order, err := _readDvp(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("[GetDvp] %v", err)
+	}
+	if order == nil {
+		return nil, fmt.Errorf("[GetDvp] dvp order (%s) does not exist", orderId)
+	}
+
+	return order, nil
+}
+
+/*
+	_settleDvp 在同一笔交易中完成两边的交割, 任何一边失败整笔交易失败, 两边都不会交割
+	coinPayload: 买方签名的稳定币转账数据, 由稳定币合约验证签名并消耗序号
+	超过转售限价时不交割, 订单保持不变
+*/
+func _settleDvp(ctx contractapi.TransactionContextInterface, order *proto.DvpOrder, coinPayload, coinSignature string) (*proto.DvpOrder, error) {
+	// 转售限价校验, 超过限价时不成交
+	allowed, err := _checkResaleCap(ctx, order.Seller, order.Buyer, []string{order.BatchID}, []uint64{order.Amount}, order.Price)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return order, nil
+	}
+	order.BuyerAccepted = true
+
+	// 买方签名的稳定币转账支付费用和手续费
+	err = utils.TradeNFRPaySignedCoinsHelper(ctx, order.Buyer, order.Seller, order.Price, []string{order.BatchID}, []uint64{order.Amount}, coinPayload, coinSignature)
+	if err != nil {
+		return nil, err
+	}
+
+	// 卖方的NFR转给买方
+	if _, err = utils.TransferHelper(ctx, order.Seller, order.Buyer, []string{order.BatchID}, []uint64{order.Amount}); err != nil {
+		return nil, err
+	}
+
+	order.Status = proto.DvpStatusSettled
+	if err = _putDvp(ctx, order, "DvpSettled"); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[DvP] order (%s) settled: %d of batch (%s) from (%s) to (%s) for %d", order.ID, order.Amount, order.BatchID, order.Seller, order.Buyer, order.Price)
+
+	return order, nil
+}
+
+/*
+	_openDvp 查询可确认的订单, 订单必须存在、未结束且未过期
+*/
+func _openDvp(ctx contractapi.TransactionContextInterface, orderId string) (*proto.DvpOrder, error) {
+	order, err := _readDvp(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil {
+		return nil, fmt.Errorf("dvp order (%s) does not exist", orderId)
+	}
+	if order.Status != proto.DvpStatusCreated {
+		return nil, fmt.Errorf("dvp order (%s) is already %s", orderId, order.Status)
+	}
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	if txTimestamp.GetSeconds() >= order.Expiry {
+		return nil, fmt.Errorf("dvp order (%s) expired at (%d)", orderId, order.Expiry)
+	}
+
+	return order, nil
+}
+
+/*
+	_readDvp 查询订单, 不存在则返回nil
+*/
+func _readDvp(ctx contractapi.TransactionContextInterface, orderId string) (*proto.DvpOrder, error) {
+	dvpKey, err := ctx.GetStub().CreateCompositeKey(proto.DvpPrefix, []string{orderId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the composite key for prefix %s: %v", proto.DvpPrefix, err)
+	}
+	dvpBytes, err := ctx.GetStub().GetState(dvpKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dvp order %s: %v", orderId, err)
+	}
+	if dvpBytes == nil {
+		return nil, nil
+	}
+
+	order := new(proto.DvpOrder)
+	if err = json.Unmarshal(dvpBytes, order); err != nil {
+		return nil, fmt.Errorf("json unmarshal dvp order failed, err: %v", err)
+	}
+
+	return order, nil
+}
+
+/*
+	_putDvp 保存订单并触发对应的状态事件
+*/
+func _putDvp(ctx contractapi.TransactionContextInterface, order *proto.DvpOrder, eventName string) error {
+	dvpKey, err := ctx.GetStub().CreateCompositeKey(proto.DvpPrefix, []string{order.ID})
+	if err != nil {
+		return fmt.Errorf("failed to create the composite key for prefix %s: %v", proto.DvpPrefix, err)
+	}
+	dvpBytes, err := json.Marshal(order)
+	if err != nil {
+		return fmt.Errorf("json marshal dvp order failed, err: %v", err)
+	}
+	if err = ctx.GetStub().PutState(dvpKey, dvpBytes); err != nil {
+		return fmt.Errorf("failed to put dvp order %s: %v", order.ID, err)
+	}
+
+	if err = ctx.GetStub().SetEvent(eventName, dvpBytes); err != nil {
+		return fmt.Errorf("failed to set event %s: %v", eventName, err)
+	}
+
+	return nil
+}
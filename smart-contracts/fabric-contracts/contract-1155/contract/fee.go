@@ -0,0 +1,85 @@
+package contract
+
+import (
+	"contract-1155/proto"
+	"contract-1155/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+)
+
+/*
+	SetFeeCollectors 设置手续费收取账户和分配比例, 替换原有的设置
+	accounts: 手续费收取账户列表
+	shares: 分配比例列表(万分比, 总和必须为10000)
+*/
+func (s *SmartContract) SetFeeCollectors(ctx contractapi.TransactionContextInterface, accounts []string, shares []uint64) error {
+	// 参数校验
+	if len(accounts) == 0 || len(accounts) != len(shares) {
+		return fmt.Errorf("[SetFeeCollectors] accounts and shares must be non-empty and have the same length")
+	}
+
+	// 只有管理员可以设置手续费收取账户
+	operator, err := utils.AuthorizeAdminHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[SetFeeCollectors] %v", err)
+	}
+
+	collectors := make([]proto.FeeCollector, 0, len(accounts))
+	seen := make(map[string]bool)
+	var total uint64
+	for i, account := range accounts {
+		if account == "" || account == proto.EmptyAccount {
+			return fmt.Errorf("[SetFeeCollectors] fee collector must not be empty or the zero address")
+		}
+		if seen[account] {
+			return fmt.Errorf("[SetFeeCollectors] duplicate fee collector (%s)", account)
+		}
+		if shares[i] == 0 {
+			return fmt.Errorf("[SetFeeCollectors] share of fee collector (%s) must be positive", account)
+		}
+		seen[account] = true
+		total += shares[i]
+		collectors = append(collectors, proto.FeeCollector{Account: account, Share: shares[i]})
+	}
+	if total != proto.FeeShareTotal {
+		return fmt.Errorf("[SetFeeCollectors] shares must add up to %d, got %d", proto.FeeShareTotal, total)
+	}
+
+	collectorsBytes, err := json.Marshal(collectors)
+	if err != nil {
+		return fmt.Errorf("[SetFeeCollectors] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().PutState(proto.FeeCollectorsKey, collectorsBytes); err != nil {
+		return fmt.Errorf("[SetFeeCollectors] failed to put state: %v", err)
+	}
+
+	// 事件触发
+	updatedEventJSON, err := json.Marshal(proto.FeeCollectorsUpdated{
+		Operator:   operator,
+		Collectors: collectors,
+	})
+	if err != nil {
+		return fmt.Errorf("[SetFeeCollectors] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().SetEvent("FeeCollectorsUpdated", updatedEventJSON); err != nil {
+		return fmt.Errorf("[SetFeeCollectors] failed to set event: %v", err)
+	}
+
+	log.Printf("[SetFeeCollectors] operator (%s) set %d fee collectors", operator, len(collectors))
+
+	return nil
+}
+
+/*
+	GetFeeCollectors 查询登记的手续费收取账户和分配比例
+*/
+func (s *SmartContract) GetFeeCollectors(ctx contractapi.TransactionContextInterface) ([]proto.FeeCollector, error) {
+	collectors, err := utils.FeeCollectorsHelper(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[GetFeeCollectors] %v", err)
+	}
+
+	return collectors, nil
+}
@@ -0,0 +1,208 @@
+package contract
+
+import (
+	"contract-1155/proto"
+	"contract-1155/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sort"
+)
+
+/*
+	FeeReport 查询单个批次在日期范围内的手续费统计
+	batchId: NFR批次类型
+	fromDay: 开始日期 yyyyMMdd(包含), 为空表示不限
+	toDay: 结束日期 yyyyMMdd(包含), 为空表示不限
+*/
+func (s *SmartContract) FeeReport(ctx contractapi.TransactionContextInterface, batchId, fromDay, toDay string) (*proto.FeeReport, error) {
+	if err := utils.CheckDayRangeHelper(fromDay, toDay); err != nil {
+		return nil, fmt.Errorf("[FeeReport] %v", err)
+	}
+
+	days, err := _feeDays(ctx, proto.FeeBatchDayPrefix, []string{batchId}, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("[FeeReport] %v", err)
+	}
+	deltas, err := utils.PendingFeeDeltasHelper(ctx, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("[FeeReport] %v", err)
+	}
+	for _, delta := range deltas {
+		if amount, ok := delta.Batches[batchId]; ok {
+			days = _addFeeDay(days, delta.Day, amount)
+		}
+	}
+
+	report := &proto.FeeReport{
+		BatchID: batchId,
+		FromDay: fromDay,
+		ToDay:   toDay,
+		Days:    days,
+	}
+	for _, day := range days {
+		report.Amount += day.Amount
+		report.Count += day.Count
+	}
+
+	return report, nil
+}
+
+/*
+	PlatformRevenue 查询平台在日期范围内的手续费收入, 按日期和收取账户汇总
+	fromDay: 开始日期 yyyyMMdd(包含), 为空表示不限
+	toDay: 结束日期 yyyyMMdd(包含), 为空表示不限
+*/
+func (s *SmartContract) PlatformRevenue(ctx contractapi.TransactionContextInterface, fromDay, toDay string) (*proto.RevenueReport, error) {
+	if err := utils.CheckDayRangeHelper(fromDay, toDay); err != nil {
+		return nil, fmt.Errorf("[PlatformRevenue] %v", err)
+	}
+
+	days, err := _feeDays(ctx, proto.FeeDayPrefix, []string{}, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("[PlatformRevenue] %v", err)
+	}
+	deltas, err := utils.PendingFeeDeltasHelper(ctx, fromDay, toDay)
+	if err != nil {
+		return nil, fmt.Errorf("[PlatformRevenue] %v", err)
+	}
+	for _, delta := range deltas {
+		days = _addFeeDay(days, delta.Day, delta.Amount)
+	}
+
+	report := &proto.RevenueReport{
+		FromDay:    fromDay,
+		ToDay:      toDay,
+		Days:       days,
+		Collectors: make([]*proto.CollectorRevenue, 0),
+	}
+	for _, day := range days {
+		report.Amount += day.Amount
+		report.Count += day.Count
+	}
+
+	// 按收取账户汇总, 复合键按账户排序, 每个账户每天一条汇总
+	collectors := make(map[string]*proto.CollectorRevenue)
+	collectorIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(proto.FeeCollectorDayPrefix, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("[PlatformRevenue] failed to get state for prefix %s: %v", proto.FeeCollectorDayPrefix, err)
+	}
+	defer collectorIterator.Close()
+
+	for collectorIterator.HasNext() {
+		queryResponse, err := collectorIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("[PlatformRevenue] failed to get the next state for prefix %s: %v", proto.FeeCollectorDayPrefix, err)
+		}
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil || len(attributes) < 2 {
+			return nil, fmt.Errorf("[PlatformRevenue] invalid fee counter key %s", queryResponse.Key)
+		}
+		account, day := attributes[0], attributes[1]
+		if !utils.InDayRangeHelper(day, fromDay, toDay) {
+			continue
+		}
+
+		counter := new(proto.FeeCounter)
+		if err = json.Unmarshal(queryResponse.Value, counter); err != nil {
+			return nil, fmt.Errorf("[PlatformRevenue] json unmarshal fee counter failed, err: %v", err)
+		}
+		_addCollectorRevenue(collectors, account, counter.Amount, counter.Count)
+	}
+	for _, delta := range deltas {
+		for account, amount := range delta.Collectors {
+			_addCollectorRevenue(collectors, account, amount, 1)
+		}
+	}
+
+	accounts := make([]string, 0, len(collectors))
+	for account := range collectors {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	for _, account := range accounts {
+		report.Collectors = append(report.Collectors, collectors[account])
+	}
+
+	return report, nil
+}
+
+/*
+	RollupFeeCounters 把某一天的手续费增量汇总到按日统计, 汇总后删除增量, 任何人都可以调用
+	交易只写入增量避免MVCC冲突, 定期汇总已结束的日期, 查询时只需读取按日统计和尚未汇总的增量
+	day: 要汇总的日期 yyyyMMdd, 必须早于交易日期
+*/
+func (s *SmartContract) RollupFeeCounters(ctx contractapi.TransactionContextInterface, day string) (*proto.FeeDayReport, error) {
+	rolled, err := utils.RollupFeeHelper(ctx, day)
+	if err != nil {
+		return nil, fmt.Errorf("[RollupFeeCounters] %v", err)
+	}
+
+	return rolled, nil
+}
+
+/*
+	_feeDays 查询前缀下日期范围内的按日手续费统计
+	日期是 attributes 之后的属性, 每个日期一条汇总
+*/
+func _feeDays(ctx contractapi.TransactionContextInterface, prefix string, attributes []string, fromDay, toDay string) ([]*proto.FeeDayReport, error) {
+	counterIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(prefix, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state for prefix %s: %v", prefix, err)
+	}
+	defer counterIterator.Close()
+
+	days := make([]*proto.FeeDayReport, 0)
+	var current *proto.FeeDayReport
+	for counterIterator.HasNext() {
+		queryResponse, err := counterIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the next state for prefix %s: %v", prefix, err)
+		}
+		_, keyAttributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil || len(keyAttributes) <= len(attributes) {
+			return nil, fmt.Errorf("invalid fee counter key %s", queryResponse.Key)
+		}
+		day := keyAttributes[len(attributes)]
+		if !utils.InDayRangeHelper(day, fromDay, toDay) {
+			continue
+		}
+
+		counter := new(proto.FeeCounter)
+		if err = json.Unmarshal(queryResponse.Value, counter); err != nil {
+			return nil, fmt.Errorf("json unmarshal fee counter failed, err: %v", err)
+		}
+		if current == nil || current.Day != day {
+			current = &proto.FeeDayReport{Day: day}
+			days = append(days, current)
+		}
+		current.Amount += counter.Amount
+		current.Count += counter.Count
+	}
+
+	return days, nil
+}
+
+/*
+	_addFeeDay 把一笔尚未汇总的增量加到对应日期, 保持按日期排序
+*/
+func _addFeeDay(days []*proto.FeeDayReport, day string, amount uint64) []*proto.FeeDayReport {
+	i := sort.Search(len(days), func(i int) bool { return days[i].Day >= day })
+	if i == len(days) || days[i].Day != day {
+		days = append(days, nil)
+		copy(days[i+1:], days[i:])
+		days[i] = &proto.FeeDayReport{Day: day}
+	}
+	days[i].Amount += amount
+	days[i].Count++
+
+	return days
+}
+
+func _addCollectorRevenue(collectors map[string]*proto.CollectorRevenue, account string, amount, count uint64) {
+	if collectors[account] == nil {
+		collectors[account] = &proto.CollectorRevenue{Account: account}
+	}
+	collectors[account].Amount += amount
+	collectors[account].Count += count
+}
@@ -0,0 +1,81 @@
+package contract
+
+import (
+	"contract-1155/proto"
+	"contract-1155/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	SetBatchLimit 设置批次的持有和接收限制, 全部为0且没有豁免账户时删除限制
+	batchId: NFR批次类型
+	maxHolding: 每个账户最多持有的数量, 为0表示不限
+	maxPerTx: 每个账户单笔交易最多接收的数量, 为0表示不限
+	maxPerDay: 每个账户每天最多接收的数量, 为0表示不限
+	exempt: 不受限制的账户列表(如发行方)
+*/
+func (s *SmartContract) SetBatchLimit(ctx contractapi.TransactionContextInterface, batchId string, maxHolding, maxPerTx, maxPerDay uint64, exempt []string) error {
+	// 参数校验
+	if batchId == "" {
+		return fmt.Errorf("[SetBatchLimit] batchId must not be empty")
+	}
+
+	// 只有管理员可以设置批次限制
+	if _, err := utils.AuthorizeAdminHelper(ctx); err != nil {
+		return fmt.Errorf("[SetBatchLimit] %v", err)
+	}
+
+	limitKey, err := ctx.GetStub().CreateCompositeKey(proto.BatchLimitPrefix, []string{batchId})
+	if err != nil {
+		return fmt.Errorf("[SetBatchLimit] failed to create the composite key for prefix %s: %v", proto.BatchLimitPrefix, err)
+	}
+
+	if exempt == nil {
+		exempt = make([]string, 0)
+	}
+	limit := proto.BatchLimit{
+		BatchID:    batchId,
+		MaxHolding: maxHolding,
+		MaxPerTx:   maxPerTx,
+		MaxPerDay:  maxPerDay,
+		Exempt:     exempt,
+	}
+	limitJSON, err := json.Marshal(limit)
+	if err != nil {
+		return fmt.Errorf("[SetBatchLimit] failed to obtain JSON encoding: %v", err)
+	}
+
+	if maxHolding == 0 && maxPerTx == 0 && maxPerDay == 0 && len(exempt) == 0 {
+		err = ctx.GetStub().DelState(limitKey)
+	} else {
+		err = ctx.GetStub().PutState(limitKey, limitJSON)
+	}
+	if err != nil {
+		return fmt.Errorf("[SetBatchLimit] failed to update limit of batch %s: %v", batchId, err)
+	}
+
+	// 事件触发
+	if err = ctx.GetStub().SetEvent("BatchLimitUpdated", limitJSON); err != nil {
+		return fmt.Errorf("[SetBatchLimit] failed to set event: %v", err)
+	}
+
+	return nil
+}
+
+/*
+	GetBatchLimit 查询批次的持有和接收限制, 没有配置时各项为0
+	batchId: NFR批次类型
+*/
+func (s *SmartContract) GetBatchLimit(ctx contractapi.TransactionContextInterface, batchId string) (*proto.BatchLimit, error) {
+	limit, err := utils.ReadBatchLimitHelper(ctx, batchId)
+	if err != nil {
+		return nil, fmt.Errorf("[GetBatchLimit] %v", err)
+	}
+	if limit == nil {
+		limit = &proto.BatchLimit{BatchID: batchId, Exempt: make([]string, 0)}
+	}
+
+	return limit, nil
+}
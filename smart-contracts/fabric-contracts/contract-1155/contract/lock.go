@@ -0,0 +1,190 @@
+package contract
+
+import (
+	"contract-1155/proto"
+	"contract-1155/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+)
+
+/*
+	SetLocker 登记或取消可以锁定NFR的链码(如金融产品链码)
+	lockerChaincode: 链码名称
+	enabled: true 登记, false 取消; 取消后已锁定的NFR仍只能由该链码解锁
+*/
+func (s *SmartContract) SetLocker(ctx contractapi.TransactionContextInterface, lockerChaincode string, enabled bool) error {
+	// 参数校验
+	if lockerChaincode == "" {
+		return fmt.Errorf("[SetLocker] lockerChaincode must not be empty")
+	}
+
+	// 只有管理员可以登记锁定方
+	operator, err := utils.AuthorizeAdminHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[SetLocker] %v", err)
+	}
+
+	lockerKey, err := ctx.GetStub().CreateCompositeKey(proto.LockerPrefix, []string{lockerChaincode})
+	if err != nil {
+		return fmt.Errorf("[SetLocker] failed to create the composite key for prefix %s: %v", proto.LockerPrefix, err)
+	}
+	if enabled {
+		err = ctx.GetStub().PutState(lockerKey, []byte("1"))
+	} else {
+		err = ctx.GetStub().DelState(lockerKey)
+	}
+	if err != nil {
+		return fmt.Errorf("[SetLocker] failed to update locker %s: %v", lockerChaincode, err)
+	}
+
+	log.Printf("[SetLocker] operator (%s) set locker (%s) enabled: %v", operator, lockerChaincode, enabled)
+
+	return nil
+}
+
+/*
+	IsLocker 查询链码是否为登记的锁定方
+*/
+func (s *SmartContract) IsLocker(ctx contractapi.TransactionContextInterface, lockerChaincode string) (bool, error) {
+
+	return utils.IsLockerHelper(ctx, lockerChaincode)
+}
+
+/*
+	LockToken 锁定NFR用作抵押或质押, 锁定期间不能转移或销毁
+	必须由登记的锁定方链码通过 InvokeChaincode 调用, 提交交易的账户必须是NFR的持有者或其授权账户
+	tokenId: NFR的tokenId
+	lockerChaincode: 锁定方链码名称, 只有它可以解锁
+	reason: 锁定原因
+*/
+func (s *SmartContract) LockToken(ctx contractapi.TransactionContextInterface, tokenId, lockerChaincode, reason string) error {
+	// 校验调用的锁定方链码
+	if err := utils.CheckLockerHelper(ctx, lockerChaincode); err != nil {
+		return fmt.Errorf("[LockToken] %v", err)
+	}
+
+	nft, err := utils.ReadNFRHelper(ctx, tokenId)
+	if err != nil || nft.Owner == "" {
+		return fmt.Errorf("[LockToken] token (%s) does not exist", tokenId)
+	}
+
+	// 获取用户客户端信息ID
+	operator, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[LockToken] failed to get client id: %v", err)
+	}
+	if operator != nft.Owner {
+		approved, err := _isApprovedForAll(ctx, nft.Owner, operator)
+		if err != nil {
+			return fmt.Errorf("[LockToken] %v", err)
+		}
+		if !approved {
+			return fmt.Errorf("[LockToken] caller is not owner nor is approved")
+		}
+	}
+
+	lock, err := utils.ReadTokenLockHelper(ctx, tokenId)
+	if err != nil {
+		return fmt.Errorf("[LockToken] %v", err)
+	}
+	if lock != nil {
+		return fmt.Errorf("[LockToken] token (%s) is already locked by (%s)", tokenId, lock.Locker)
+	}
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[LockToken] failed to get transaction timestamp: %v", err)
+	}
+
+	lock = &proto.TokenLock{
+		TokenID:  tokenId,
+		Locked:   true,
+		Owner:    nft.Owner,
+		Locker:   lockerChaincode,
+		Reason:   reason,
+		LockedAt: txTimestamp.GetSeconds(),
+	}
+	lockJSON, err := json.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("[LockToken] failed to obtain JSON encoding: %v", err)
+	}
+	lockKey, err := ctx.GetStub().CreateCompositeKey(proto.TokenLockPrefix, []string{tokenId})
+	if err != nil {
+		return fmt.Errorf("[LockToken] failed to create the composite key for prefix %s: %v", proto.TokenLockPrefix, err)
+	}
+	if err = ctx.GetStub().PutState(lockKey, lockJSON); err != nil {
+		return fmt.Errorf("[LockToken] failed to put lock of token %s: %v", tokenId, err)
+	}
+
+	// 事件触发
+	if err = ctx.GetStub().SetEvent("TokenLocked", lockJSON); err != nil {
+		return fmt.Errorf("[LockToken] failed to set event: %v", err)
+	}
+
+	return nil
+}
+
+/*
+	UnlockToken 解除NFR的锁定, 必须由锁定它的链码通过 InvokeChaincode 调用
+	tokenId: NFR的tokenId
+	lockerChaincode: 锁定方链码名称
+*/
+func (s *SmartContract) UnlockToken(ctx contractapi.TransactionContextInterface, tokenId, lockerChaincode string) error {
+	lock, err := utils.ReadTokenLockHelper(ctx, tokenId)
+	if err != nil {
+		return fmt.Errorf("[UnlockToken] %v", err)
+	}
+	if lock == nil {
+		return fmt.Errorf("[UnlockToken] token (%s) is not locked", tokenId)
+	}
+	if lock.Locker != lockerChaincode {
+		return fmt.Errorf("[UnlockToken] token (%s) is locked by (%s), not (%s)", tokenId, lock.Locker, lockerChaincode)
+	}
+
+	// 校验调用的链码, 锁定方被取消登记后仍可以解锁
+	caller, err := utils.CallerChaincodeHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[UnlockToken] %v", err)
+	}
+	if caller != lock.Locker {
+		return fmt.Errorf("[UnlockToken] transaction was proposed to chaincode (%s), not locker (%s)", caller, lock.Locker)
+	}
+
+	lockKey, err := ctx.GetStub().CreateCompositeKey(proto.TokenLockPrefix, []string{tokenId})
+	if err != nil {
+		return fmt.Errorf("[UnlockToken] failed to create the composite key for prefix %s: %v", proto.TokenLockPrefix, err)
+	}
+	if err = ctx.GetStub().DelState(lockKey); err != nil {
+		return fmt.Errorf("[UnlockToken] failed to delete lock of token %s: %v", tokenId, err)
+	}
+
+	// 事件触发
+	lock.Locked = false
+	lockJSON, err := json.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("[UnlockToken] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().SetEvent("TokenUnlocked", lockJSON); err != nil {
+		return fmt.Errorf("[UnlockToken] failed to set event: %v", err)
+	}
+
+	return nil
+}
+
+/*
+	GetLock 查询NFR的锁定状态
+	tokenId: NFR的tokenId
+*/
+func (s *SmartContract) GetLock(ctx contractapi.TransactionContextInterface, tokenId string) (*proto.TokenLock, error) {
+	lock, err := utils.ReadTokenLockHelper(ctx, tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[GetLock] %v", err)
+	}
+	if lock == nil {
+		lock = &proto.TokenLock{TokenID: tokenId}
+	}
+
+	return lock, nil
+}
@@ -0,0 +1,108 @@
+package contract
+
+import (
+	"contract-1155/proto"
+	"contract-1155/utils"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+	"strconv"
+)
+
+/*
+	Nonces 查询账户下一个代理调用需要使用的序号
+*/
+func (s *SmartContract) Nonces(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+
+	return utils.NonceHelper(ctx, account)
+}
+
+/*
+	ExecuteSigned 以签名者的身份执行 TransferFrom、NFRTradeV2 或 NFRTrade, 由平台代为提交交易
+	payload: JSON编码的proto.SignedPayload, args与对应方法的参数一致(数字为十进制字符串)
+	signature: 对payload原始字节的签名
+	NFRTradeV2 的 args 末尾追加买方签名的稳定币合约 TransferBatch 代理调用数据和签名:
+	[NFRSender, batchId, amount, totalPrice, coinPayload, coinSignature]
+	NFRTrade 已弃用, args 为 [NFRSender, feeCollector, batchId, amount, totalPrice, coinPayload, coinSignature]
+*/
+func (s *SmartContract) ExecuteSigned(ctx contractapi.TransactionContextInterface, payload, signature string) ([]*proto.NftMetadata, error) {
+	var nftTradeList []*proto.NftMetadata
+
+	// 校验签名数据
+	signed, err := utils.VerifySignedPayloadHelper(ctx, payload, signature)
+	if err != nil {
+		return nftTradeList, fmt.Errorf("[ExecuteSigned] %v", err)
+	}
+
+	switch signed.Function {
+	case "TransferFrom":
+		if len(signed.Args) != 4 {
+			return nftTradeList, fmt.Errorf("[ExecuteSigned] TransferFrom expects 4 args (sender, recipient, batchId, amount), got %d", len(signed.Args))
+		}
+		sender, recipient, batchId := signed.Args[0], signed.Args[1], signed.Args[2]
+		amount, err := strconv.ParseUint(signed.Args[3], 10, 64)
+		if err != nil {
+			return nftTradeList, fmt.Errorf("[ExecuteSigned] invalid amount (%s): %v", signed.Args[3], err)
+		}
+		// 参数校验
+		if sender == recipient {
+			return nftTradeList, fmt.Errorf("[ExecuteSigned] transfer to self")
+		}
+		if recipient == proto.EmptyAccount {
+			return nftTradeList, fmt.Errorf("[ExecuteSigned] transfer to the zero address")
+		}
+
+		nftTradeList, err = _transferFrom(ctx, signed.Signer, sender, recipient, batchId, amount)
+		if err != nil {
+			return nftTradeList, err
+		}
+	case "NFRTradeV2", "NFRTrade":
+		args := signed.Args
+		if signed.Function == "NFRTrade" {
+			// 兼容旧格式, feeCollector 必须是已登记的手续费账户
+			if len(args) != 7 {
+				return nftTradeList, fmt.Errorf("[ExecuteSigned] NFRTrade expects 7 args (NFRSender, feeCollector, batchId, amount, totalPrice, coinPayload, coinSignature), got %d", len(args))
+			}
+			if err = utils.CheckFeeCollectorHelper(ctx, args[1]); err != nil {
+				return nftTradeList, fmt.Errorf("[ExecuteSigned] %v", err)
+			}
+			args = append([]string{args[0]}, args[2:]...)
+		}
+		if len(args) != 6 {
+			return nftTradeList, fmt.Errorf("[ExecuteSigned] NFRTradeV2 expects 6 args (NFRSender, batchId, amount, totalPrice, coinPayload, coinSignature), got %d", len(args))
+		}
+		NFRSender, batchId := args[0], args[1]
+		amount, err := strconv.ParseUint(args[2], 10, 64)
+		if err != nil {
+			return nftTradeList, fmt.Errorf("[ExecuteSigned] invalid amount (%s): %v", args[2], err)
+		}
+		totalPrice, err := strconv.ParseUint(args[3], 10, 64)
+		if err != nil {
+			return nftTradeList, fmt.Errorf("[ExecuteSigned] invalid totalPrice (%s): %v", args[3], err)
+		}
+		if NFRSender == proto.EmptyAccount {
+			return nftTradeList, fmt.Errorf("[ExecuteSigned] transfer from the zero address")
+		}
+
+		// 转售限价校验, 超过限价时不成交
+		if allowed, err := _checkResaleCap(ctx, NFRSender, signed.Signer, []string{batchId}, []uint64{amount}, totalPrice); err != nil || !allowed {
+			return nftTradeList, err
+		}
+
+		// 由买方签名的稳定币转账支付费用和手续费
+		if err = utils.TradeNFRPaySignedCoinsHelper(ctx, signed.Signer, NFRSender, totalPrice, []string{batchId}, []uint64{amount}, args[4], args[5]); err != nil {
+			return nftTradeList, err
+		}
+
+		nftTradeList, err = _nfrTrade(ctx, signed.Signer, NFRSender, batchId, amount)
+		if err != nil {
+			return nftTradeList, err
+		}
+	default:
+		return nftTradeList, fmt.Errorf("[ExecuteSigned] function (%s) cannot be relayed", signed.Function)
+	}
+
+	log.Printf("[ExecuteSigned] executed (%s) for signer (%s) with nonce (%d)", signed.Function, signed.Signer, signed.Nonce)
+
+	return nftTradeList, nil
+}
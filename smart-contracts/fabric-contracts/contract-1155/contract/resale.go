@@ -0,0 +1,143 @@
+package contract
+
+import (
+	"contract-1155/proto"
+	"contract-1155/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+)
+
+/*
+	NFRMintWithFaceValue 增加某一种nfr并登记批次的票面价, 用于二级市场转售限价
+	account: 账户, 登记为批次的发行账户, 转售不受限价约束
+	batchID: 一类NFR的唯一KEY值
+	meta: NFR的信息 (base64数据)
+	amount: 数量
+	faceValue: 单张票面价(相对于稳定币), 批次已登记时必须一致
+*/
+func (s *SmartContract) NFRMintWithFaceValue(ctx contractapi.TransactionContextInterface, account, batchID, meta string, amount, faceValue uint64) ([]*proto.NftMetadata, error) {
+	// 参数校验
+	if faceValue == 0 {
+		return nil, fmt.Errorf("[NFRMintWithFaceValue] face value must be a positive integer")
+	}
+
+	registered, err := utils.ReadFaceValueHelper(ctx, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("[NFRMintWithFaceValue] %v", err)
+	}
+	if registered != nil && registered.FaceValue != faceValue {
+		return nil, fmt.Errorf("[NFRMintWithFaceValue] batch %s is registered with face value %d, got %d", batchID, registered.FaceValue, faceValue)
+	}
+
+	// 权限验证和发NFR
+	nftSlice, err := s.NFRMint(ctx, account, batchID, meta, amount)
+	if err != nil {
+		return nftSlice, err
+	}
+
+	if registered == nil {
+		if err = utils.PutFaceValueHelper(ctx, &proto.FaceValue{BatchID: batchID, Issuer: account, FaceValue: faceValue}); err != nil {
+			return nftSlice, fmt.Errorf("[NFRMintWithFaceValue] %v", err)
+		}
+	}
+
+	return nftSlice, nil
+}
+
+/*
+	GetFaceValue 查询批次登记的票面价, 没有登记时票面价为0
+	batchId: NFR批次类型
+*/
+func (s *SmartContract) GetFaceValue(ctx contractapi.TransactionContextInterface, batchId string) (*proto.FaceValue, error) {
+	faceValue, err := utils.ReadFaceValueHelper(ctx, batchId)
+	if err != nil {
+		return nil, fmt.Errorf("[GetFaceValue] %v", err)
+	}
+	if faceValue == nil {
+		faceValue = &proto.FaceValue{BatchID: batchId}
+	}
+
+	return faceValue, nil
+}
+
+/*
+	SetResaleCap 设置二级市场转售限价, 替换原有的设置
+	capBps: 单张成交价不能超过票面价的比例(万分比), 如11000表示不超过票面价的110%, 为0表示不限
+	exempt: 不受限价约束的卖方账户列表, 批次的发行账户本身不受约束
+*/
+func (s *SmartContract) SetResaleCap(ctx contractapi.TransactionContextInterface, capBps uint64, exempt []string) error {
+	// 参数校验
+	if capBps > 0 && capBps < proto.FeeShareTotal {
+		return fmt.Errorf("[SetResaleCap] cap must be at least %d (the face value), got %d", proto.FeeShareTotal, capBps)
+	}
+
+	// 只有管理员可以设置转售限价
+	if _, err := utils.AuthorizeAdminHelper(ctx); err != nil {
+		return fmt.Errorf("[SetResaleCap] %v", err)
+	}
+
+	if exempt == nil {
+		exempt = make([]string, 0)
+	}
+	resaleCapJSON, err := json.Marshal(proto.ResaleCap{CapBps: capBps, Exempt: exempt})
+	if err != nil {
+		return fmt.Errorf("[SetResaleCap] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().PutState(proto.ResaleCapKey, resaleCapJSON); err != nil {
+		return fmt.Errorf("[SetResaleCap] failed to put state: %v", err)
+	}
+
+	// 事件触发
+	if err = ctx.GetStub().SetEvent("ResaleCapUpdated", resaleCapJSON); err != nil {
+		return fmt.Errorf("[SetResaleCap] failed to set event: %v", err)
+	}
+
+	return nil
+}
+
+/*
+	GetResaleCap 查询二级市场转售限价
+*/
+func (s *SmartContract) GetResaleCap(ctx contractapi.TransactionContextInterface) (*proto.ResaleCap, error) {
+	resaleCap, err := utils.ReadResaleCapHelper(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[GetResaleCap] %v", err)
+	}
+
+	return resaleCap, nil
+}
+
+/*
+	_checkResaleCap 校验转售总价是否超过限价, 超过时触发 ResaleRejected 事件并返回false
+	调用方应直接返回且不报错, 否则交易无效、事件不会上链
+*/
+func _checkResaleCap(ctx contractapi.TransactionContextInterface, seller, buyer string, batchIds []string, amounts []uint64, totalPrice uint64) (bool, error) {
+	maxPrice, capped, err := utils.ResaleMaxPriceHelper(ctx, seller, batchIds, amounts)
+	if err != nil {
+		return false, err
+	}
+	if !capped || totalPrice <= maxPrice {
+		return true, nil
+	}
+
+	rejectedEventJSON, err := json.Marshal(proto.ResaleRejected{
+		Seller:     seller,
+		Buyer:      buyer,
+		BatchIDs:   batchIds,
+		Amounts:    amounts,
+		TotalPrice: totalPrice,
+		MaxPrice:   maxPrice,
+	})
+	if err != nil {
+		return false, fmt.Errorf("[_checkResaleCap] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().SetEvent("ResaleRejected", rejectedEventJSON); err != nil {
+		return false, fmt.Errorf("[_checkResaleCap] failed to set event: %v", err)
+	}
+
+	log.Printf("[_checkResaleCap] rejected resale from (%s) to (%s): total price %d exceeds cap %d", seller, buyer, totalPrice, maxPrice)
+
+	return false, nil
+}
@@ -0,0 +1,231 @@
+package contract
+
+import (
+	"contract-1155/proto"
+	"contract-1155/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+)
+
+/*
+	ConfigureSale 发行方配置批次的一级市场发售, 再次配置时保留已售出数量
+	batchId: NFR批次类型
+	tierLimits: 各档阶梯的累计售出数量上限(递增), 最后一档即为发售总量
+	tierPrices: 各档阶梯的单价(相对于稳定币)
+	start: 开始时间(Unix秒)
+	end: 结束时间(Unix秒), 为0表示不限
+	walletLimit: 每个账户的限购数量, 为0表示不限
+	earlyBirdPrice: 早鸟单价, 为0表示没有早鸟价
+	earlyBirdEnd: 早鸟价截止时间(Unix秒)
+*/
+func (s *SmartContract) ConfigureSale(
+	ctx contractapi.TransactionContextInterface, batchId string, tierLimits, tierPrices []uint64, start, end int64, walletLimit, earlyBirdPrice uint64, earlyBirdEnd int64,
+) error {
+	// 参数校验
+	if batchId == "" {
+		return fmt.Errorf("[ConfigureSale] batchId must not be empty")
+	}
+	if len(tierLimits) == 0 || len(tierLimits) != len(tierPrices) {
+		return fmt.Errorf("[ConfigureSale] tierLimits and tierPrices must be non-empty and have the same length")
+	}
+	tiers := make([]proto.PriceTier, 0, len(tierLimits))
+	for i := range tierLimits {
+		if tierLimits[i] == 0 || (i > 0 && tierLimits[i] <= tierLimits[i-1]) {
+			return fmt.Errorf("[ConfigureSale] tierLimits must be positive and increasing")
+		}
+		tiers = append(tiers, proto.PriceTier{UpTo: tierLimits[i], Price: tierPrices[i]})
+	}
+	if end != 0 && end <= start {
+		return fmt.Errorf("[ConfigureSale] end (%d) must be later than start (%d)", end, start)
+	}
+	if earlyBirdPrice > 0 && earlyBirdEnd <= start {
+		return fmt.Errorf("[ConfigureSale] earlyBirdEnd (%d) must be later than start (%d)", earlyBirdEnd, start)
+	}
+
+	// 只有批次的发行账户可以配置发售
+	issuer, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[ConfigureSale] failed to get client id: %v", err)
+	}
+	batchIssuer, err := utils.ReadBatchIssuerHelper(ctx, batchId)
+	if err != nil {
+		return fmt.Errorf("[ConfigureSale] %v", err)
+	}
+	if batchIssuer == "" || batchIssuer != issuer {
+		return fmt.Errorf("[ConfigureSale] caller (%s) is not the issuer of batch (%s)", issuer, batchId)
+	}
+
+	// 只有发行方可以修改已有的配置
+	var sold uint64
+	existing, err := utils.ReadSaleHelper(ctx, batchId)
+	if err != nil {
+		return fmt.Errorf("[ConfigureSale] %v", err)
+	}
+	if existing != nil {
+		if existing.Issuer != issuer {
+			return fmt.Errorf("[ConfigureSale] sale of batch (%s) belongs to issuer (%s)", batchId, existing.Issuer)
+		}
+		sold = existing.Sold
+	}
+	if tierLimits[len(tierLimits)-1] < sold {
+		return fmt.Errorf("[ConfigureSale] sale capacity (%d) is less than sold (%d)", tierLimits[len(tierLimits)-1], sold)
+	}
+
+	sale := &proto.SaleConfig{
+		BatchID:        batchId,
+		Issuer:         issuer,
+		Tiers:          tiers,
+		Start:          start,
+		End:            end,
+		WalletLimit:    walletLimit,
+		EarlyBirdPrice: earlyBirdPrice,
+		EarlyBirdEnd:   earlyBirdEnd,
+		Sold:           sold,
+	}
+	if err = utils.PutSaleHelper(ctx, sale); err != nil {
+		return fmt.Errorf("[ConfigureSale] %v", err)
+	}
+
+	// 事件触发
+	saleJSON, err := json.Marshal(sale)
+	if err != nil {
+		return fmt.Errorf("[ConfigureSale] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().SetEvent("SaleConfigured", saleJSON); err != nil {
+		return fmt.Errorf("[ConfigureSale] failed to set event: %v", err)
+	}
+
+	return nil
+}
+
+/*
+	GetSale 查询批次的发售配置
+	batchId: NFR批次类型
+*/
+func (s *SmartContract) GetSale(ctx contractapi.TransactionContextInterface, batchId string) (*proto.SaleConfig, error) {
+	sale, err := utils.ReadSaleHelper(ctx, batchId)
+	if err != nil {
+		return nil, fmt.Errorf("[GetSale] %v", err)
+	}
+	if sale == nil {
+		return nil, fmt.Errorf("[GetSale] batch (%s) is not on sale", batchId)
+	}
+
+	return sale, nil
+}
+
+/*
+	SaleQuote 按当前时间和已售出数量查询购买 amount 张的总价(不含手续费)
+	batchId: NFR批次类型
+	amount: 数量
+*/
+func (s *SmartContract) SaleQuote(ctx contractapi.TransactionContextInterface, batchId string, amount uint64) (uint64, error) {
+	sale, err := s.GetSale(ctx, batchId)
+	if err != nil {
+		return 0, fmt.Errorf("[SaleQuote] %v", err)
+	}
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("[SaleQuote] failed to get transaction timestamp: %v", err)
+	}
+
+	return utils.SalePriceHelper(sale, txTimestamp.GetSeconds(), amount)
+}
+
+/*
+	Purchase 一级市场购买, 买方支付稳定币和手续费, NFR从发行方库存转给买方
+	batchId: NFR批次类型
+	amount: 数量
+*/
+func (s *SmartContract) Purchase(ctx contractapi.TransactionContextInterface, batchId string, amount uint64) ([]*proto.NftMetadata, error) {
+	var nftTradeList []*proto.NftMetadata
+	// 参数校验
+	if amount == 0 {
+		return nftTradeList, fmt.Errorf("[Purchase] amount must be a positive integer")
+	}
+
+	sale, err := utils.ReadSaleHelper(ctx, batchId)
+	if err != nil {
+		return nftTradeList, fmt.Errorf("[Purchase] %v", err)
+	}
+	if sale == nil {
+		return nftTradeList, fmt.Errorf("[Purchase] batch (%s) is not on sale", batchId)
+	}
+
+	// 发售时间窗口
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nftTradeList, fmt.Errorf("[Purchase] failed to get transaction timestamp: %v", err)
+	}
+	now := txTimestamp.GetSeconds()
+	if now < sale.Start {
+		return nftTradeList, fmt.Errorf("[Purchase] sale of batch (%s) starts at (%d)", batchId, sale.Start)
+	}
+	if sale.End != 0 && now >= sale.End {
+		return nftTradeList, fmt.Errorf("[Purchase] sale of batch (%s) ended at (%d)", batchId, sale.End)
+	}
+
+	// 获取用户客户端信息ID
+	buyer, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return nftTradeList, fmt.Errorf("[Purchase] failed to get client id: %v", err)
+	}
+	if buyer == sale.Issuer {
+		return nftTradeList, fmt.Errorf("[Purchase] issuer cannot purchase from own sale")
+	}
+
+	// 限购校验
+	purchased, err := utils.SalePurchasedHelper(ctx, batchId, buyer)
+	if err != nil {
+		return nftTradeList, fmt.Errorf("[Purchase] %v", err)
+	}
+	if sale.WalletLimit > 0 && purchased+amount > sale.WalletLimit {
+		return nftTradeList, fmt.Errorf("[Purchase] wallet limit is (%d), already purchased (%d)", sale.WalletLimit, purchased)
+	}
+
+	totalPrice, err := utils.SalePriceHelper(sale, now, amount)
+	if err != nil {
+		return nftTradeList, fmt.Errorf("[Purchase] %v", err)
+	}
+
+	// 支付稳定币费用和手续费
+	if err = utils.TradeNFRPayCoinsHelper(ctx, sale.Issuer, totalPrice, []string{batchId}, []uint64{amount}); err != nil {
+		return nftTradeList, err
+	}
+
+	// 从发行方库存转给买方
+	nftTradeList, err = utils.TransferHelper(ctx, sale.Issuer, buyer, []string{batchId}, []uint64{amount})
+	if err != nil {
+		return nftTradeList, err
+	}
+
+	sale.Sold += amount
+	if err = utils.PutSaleHelper(ctx, sale); err != nil {
+		return nftTradeList, fmt.Errorf("[Purchase] %v", err)
+	}
+	if err = utils.PutSalePurchasedHelper(ctx, batchId, buyer, purchased+amount); err != nil {
+		return nftTradeList, fmt.Errorf("[Purchase] %v", err)
+	}
+
+	// 事件触发
+	purchasedEventJSON, err := json.Marshal(proto.SalePurchased{
+		Buyer:      buyer,
+		Issuer:     sale.Issuer,
+		BatchID:    batchId,
+		Amount:     amount,
+		TotalPrice: totalPrice,
+	})
+	if err != nil {
+		return nftTradeList, fmt.Errorf("[Purchase] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().SetEvent("SalePurchased", purchasedEventJSON); err != nil {
+		return nftTradeList, fmt.Errorf("[Purchase] failed to set event: %v", err)
+	}
+
+	log.Printf("[Purchase] buyer (%s) purchased %d of batch (%s) for %d", buyer, amount, batchId, totalPrice)
+
+	return nftTradeList, nil
+}
@@ -0,0 +1,120 @@
+package contract
+
+import (
+	"contract-1155/proto"
+	"contract-1155/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	SetTransferRule 管理员或批次的发行账户设置批次的转让限制, 替换原有的设置
+	batchId: NFR批次类型
+	issuer: 发行账户, 从发行账户转出(一级销售)不受限制, 可以为空
+	mode: 转让方式 free(自由转让)、soulbound(不可转让)、timelock(指定时间之后才能转让)、allowlist(只能转给白名单账户)
+	until: timelock 方式下可以转让的时间(Unix秒)
+	allowlist: allowlist 方式下可以接收的账户列表
+*/
+func (s *SmartContract) SetTransferRule(ctx contractapi.TransactionContextInterface, batchId, issuer, mode string, until int64, allowlist []string) error {
+	// 参数校验
+	if batchId == "" {
+		return fmt.Errorf("[SetTransferRule] batchId must not be empty")
+	}
+	if allowlist == nil {
+		allowlist = make([]string, 0)
+	}
+	switch mode {
+	case proto.TransferModeFree, proto.TransferModeSoulbound:
+	case proto.TransferModeTimelock:
+		if until <= 0 {
+			return fmt.Errorf("[SetTransferRule] timelock mode needs a positive until")
+		}
+	case proto.TransferModeAllowlist:
+		if len(allowlist) == 0 {
+			return fmt.Errorf("[SetTransferRule] allowlist mode needs a non-empty allowlist")
+		}
+	default:
+		return fmt.Errorf("[SetTransferRule] unknown transfer mode (%s)", mode)
+	}
+
+	// 管理员或批次的发行账户可以设置, 发行账户只能把自己登记为不受限制的发行账户
+	operator, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[SetTransferRule] failed to get client id: %v", err)
+	}
+	isAdmin, err := utils.IsAdminHelper(ctx, operator)
+	if err != nil {
+		return fmt.Errorf("[SetTransferRule] %v", err)
+	}
+	if !isAdmin {
+		batchIssuer, err := utils.ReadBatchIssuerHelper(ctx, batchId)
+		if err != nil {
+			return fmt.Errorf("[SetTransferRule] %v", err)
+		}
+		if batchIssuer == "" || batchIssuer != operator {
+			return fmt.Errorf("[SetTransferRule] caller (%s) is neither an admin nor the issuer of batch (%s)", operator, batchId)
+		}
+		if issuer != "" && issuer != operator {
+			return fmt.Errorf("[SetTransferRule] the batch issuer can only exempt itself, got issuer (%s)", issuer)
+		}
+	}
+
+	ruleKey, err := ctx.GetStub().CreateCompositeKey(proto.TransferRulePrefix, []string{batchId})
+	if err != nil {
+		return fmt.Errorf("[SetTransferRule] failed to create the composite key for prefix %s: %v", proto.TransferRulePrefix, err)
+	}
+
+	rule := proto.TransferRule{
+		BatchID:   batchId,
+		Issuer:    issuer,
+		Mode:      mode,
+		Until:     until,
+		Allowlist: allowlist,
+	}
+	ruleJSON, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("[SetTransferRule] failed to obtain JSON encoding: %v", err)
+	}
+
+	if mode == proto.TransferModeFree {
+		err = ctx.GetStub().DelState(ruleKey)
+	} else {
+		err = ctx.GetStub().PutState(ruleKey, ruleJSON)
+	}
+	if err != nil {
+		return fmt.Errorf("[SetTransferRule] failed to update transfer rule of batch %s: %v", batchId, err)
+	}
+
+	// 事件触发
+	if err = ctx.GetStub().SetEvent("TransferRuleUpdated", ruleJSON); err != nil {
+		return fmt.Errorf("[SetTransferRule] failed to set event: %v", err)
+	}
+
+	return nil
+}
+
+/*
+	IsTransferable 查询批次的转让限制以及当前是否可以转让
+	tokenId: NFR批次类型(即 TransferSingle 事件中的 id)
+*/
+func (s *SmartContract) IsTransferable(ctx contractapi.TransactionContextInterface, tokenId string) (*proto.Transferability, error) {
+	rule, err := utils.ReadTransferRuleHelper(ctx, tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[IsTransferable] %v", err)
+	}
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("[IsTransferable] failed to get transaction timestamp: %v", err)
+	}
+
+	return &proto.Transferability{
+		BatchID:      rule.BatchID,
+		Issuer:       rule.Issuer,
+		Mode:         rule.Mode,
+		Until:        rule.Until,
+		Allowlist:    rule.Allowlist,
+		Transferable: utils.TransferableHelper(rule, txTimestamp.GetSeconds()),
+	}, nil
+}
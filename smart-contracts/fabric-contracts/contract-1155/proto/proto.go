@@ -6,6 +6,14 @@ const (
 
 	FcnCoinsTransfer      = "Transfer"
 	FcnCoinsTransferBatch = "TransferBatch"
+	FcnCoinsExecuteSigned = "ExecuteSigned"
+
+	// 证书身份与账户地址的映射、签名公钥、签名序号和管理员统一登记在稳定币合约
+	FcnCoinsResolveAccount  = "ResolveAccount"
+	FcnCoinsIsAdmin         = "IsAdmin"
+	FcnCoinsVerifySignature = "VerifySignature"
+	FcnCoinsUseNonce        = "UseNonce"
+	FcnCoinsNoncesOf        = "NoncesOf"
 )
 
 const (
@@ -15,6 +23,33 @@ const (
 	PrefixBalance  = "account-batchId-tokenId"
 	ApprovalPrefix = "account~operator"
 
+	SignedDomain = "contract-1155"
+
+	DvpPrefix = "dvp"
+
+	FeeCollectorsKey = "feeCollectors"
+
+	FeeBatchDayPrefix     = "feeBatchDay"
+	FeeDayPrefix          = "feeDay"
+	FeeCollectorDayPrefix = "feeCollectorDay"
+	FeeDeltaPrefix        = "feeDelta"
+
+	BatchIssuerPrefix = "batchIssuer"
+
+	SalePrefix          = "sale"
+	SalePurchasedPrefix = "salePurchased"
+
+	BatchLimitPrefix = "batchLimit"
+	BatchDailyPrefix = "batchDaily"
+
+	FaceValuePrefix = "faceValue"
+	ResaleCapKey    = "resaleCap"
+
+	TransferRulePrefix = "transferRule"
+
+	LockerPrefix    = "locker"
+	TokenLockPrefix = "tokenLock"
+
 	OperateAuthLevelName = "level"
 )
 
@@ -23,6 +58,31 @@ const (
 	OperateAuthNeedLevelBurn = 999
 )
 
+// FeeShareTotal 手续费分配比例的总和(万分比)
+const FeeShareTotal = 10000
+
+// 手续费统计和每日限购按北京时间(UTC+8)的日期 yyyyMMdd 汇总
+const (
+	FeeDayLayout     = "20060102"
+	FeeDayZoneOffset = 8 * 60 * 60
+)
+
+// DvP订单状态
+const (
+	DvpStatusCreated   = "created"
+	DvpStatusSettled   = "settled"
+	DvpStatusCancelled = "cancelled"
+	DvpStatusExpired   = "expired"
+)
+
+// 批次的转让方式
+const (
+	TransferModeFree      = "free"      // 自由转让
+	TransferModeSoulbound = "soulbound" // 不可转让(实名票)
+	TransferModeTimelock  = "timelock"  // 指定时间之后才能转让
+	TransferModeAllowlist = "allowlist" // 只能转给白名单账户
+)
+
 // TokenIdPre 用毫秒级时间当tokenId的前缀
 //var TokenIdPre = strconv.Itoa(int(time.Now().Unix())) + strconv.Itoa(13)
 
@@ -76,3 +136,212 @@ type ApprovalForAll struct {
 	Operator string `json:"operator"`
 	Approved bool   `json:"approved"`
 }
+
+// SignedPayload 链下签名的代理调用数据(与稳定币合约的格式一致)
+type SignedPayload struct {
+	Signer   string   `json:"signer"`
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+	Nonce    int      `json:"nonce"`
+	Deadline int64    `json:"deadline"`
+	Domain   string   `json:"domain"`
+	Channel  string   `json:"channel"`
+}
+
+/*
+	DvpOrder 券款对付订单, 卖方交付NFR, 买方支付稳定币
+	卖方先确认交付, 买方确认支付时提交签名的稳定币转账并在同一笔交易中完成两边的交割
+	Expiry 为过期时间(Unix秒)
+*/
+type DvpOrder struct {
+	ID             string `json:"id"`
+	Creator        string `json:"creator"`
+	Seller         string `json:"seller"`
+	Buyer          string `json:"buyer"`
+	BatchID        string `json:"batch_id"`
+	Amount         uint64 `json:"amount"`
+	Price          uint64 `json:"price"`
+	Expiry         int64  `json:"expiry"`
+	SellerAccepted bool   `json:"seller_accepted"`
+	BuyerAccepted  bool   `json:"buyer_accepted"`
+	Status         string `json:"status"`
+}
+
+// FeeCollector 登记的手续费收取账户, Share 为分配比例(万分比)
+type FeeCollector struct {
+	Account string `json:"account"`
+	Share   uint64 `json:"share"`
+}
+
+// FeeCollectorsUpdated 更新手续费收取账户时触发的事件
+type FeeCollectorsUpdated struct {
+	Operator   string         `json:"operator"`
+	Collectors []FeeCollector `json:"collectors"`
+}
+
+// FeeCounter 手续费累计金额和笔数
+type FeeCounter struct {
+	Amount uint64 `json:"amount"`
+	Count  uint64 `json:"count"`
+}
+
+// FeeDelta 单笔交易的手续费增量, 汇总到按日统计后删除
+type FeeDelta struct {
+	Day        string            `json:"day"`
+	Amount     uint64            `json:"amount"`
+	Batches    map[string]uint64 `json:"batches"`
+	Collectors map[string]uint64 `json:"collectors"`
+}
+
+// FeeDayReport 单日的手续费统计
+type FeeDayReport struct {
+	Day    string `json:"day"`
+	Amount uint64 `json:"amount"`
+	Count  uint64 `json:"count"`
+}
+
+// FeeReport 单个批次在日期范围内的手续费统计
+type FeeReport struct {
+	BatchID string          `json:"batch_id"`
+	FromDay string          `json:"from_day"`
+	ToDay   string          `json:"to_day"`
+	Amount  uint64          `json:"amount"`
+	Count   uint64          `json:"count"`
+	Days    []*FeeDayReport `json:"days"`
+}
+
+// CollectorRevenue 单个手续费收取账户的收入
+type CollectorRevenue struct {
+	Account string `json:"account"`
+	Amount  uint64 `json:"amount"`
+	Count   uint64 `json:"count"`
+}
+
+// RevenueReport 平台在日期范围内的手续费收入
+type RevenueReport struct {
+	FromDay    string              `json:"from_day"`
+	ToDay      string              `json:"to_day"`
+	Amount     uint64              `json:"amount"`
+	Count      uint64              `json:"count"`
+	Days       []*FeeDayReport     `json:"days"`
+	Collectors []*CollectorRevenue `json:"collectors"`
+}
+
+// PriceTier 阶梯价格, 累计售出数量不超过 UpTo 时单价为 Price
+type PriceTier struct {
+	UpTo  uint64 `json:"up_to"`
+	Price uint64 `json:"price"`
+}
+
+/*
+	SaleConfig 批次的一级市场发售配置, NFR从发行方库存转给买方
+	Start/End 为发售时间窗口(Unix秒), End 为0表示不限
+	WalletLimit 为每个账户的限购数量, 为0表示不限
+	EarlyBirdEnd 之前以 EarlyBirdPrice 的单价发售(为0表示没有早鸟价)
+	最后一档阶梯的 UpTo 即为发售总量
+*/
+type SaleConfig struct {
+	BatchID        string      `json:"batch_id"`
+	Issuer         string      `json:"issuer"`
+	Tiers          []PriceTier `json:"tiers"`
+	Start          int64       `json:"start"`
+	End            int64       `json:"end"`
+	WalletLimit    uint64      `json:"wallet_limit"`
+	EarlyBirdPrice uint64      `json:"early_bird_price"`
+	EarlyBirdEnd   int64       `json:"early_bird_end"`
+	Sold           uint64      `json:"sold"`
+}
+
+// SalePurchased 一级市场购买时触发的事件
+type SalePurchased struct {
+	Buyer      string `json:"buyer"`
+	Issuer     string `json:"issuer"`
+	BatchID    string `json:"batch_id"`
+	Amount     uint64 `json:"amount"`
+	TotalPrice uint64 `json:"total_price"`
+}
+
+/*
+	BatchLimit 批次的持有和接收限制, 为0表示不限
+	MaxHolding 每个账户最多持有的数量
+	MaxPerTx 每个账户单笔交易最多接收的数量
+	MaxPerDay 每个账户每天最多接收的数量
+	Exempt 不受限制的账户(如发行方)
+*/
+type BatchLimit struct {
+	BatchID    string   `json:"batch_id"`
+	MaxHolding uint64   `json:"max_holding"`
+	MaxPerTx   uint64   `json:"max_per_tx"`
+	MaxPerDay  uint64   `json:"max_per_day"`
+	Exempt     []string `json:"exempt"`
+}
+
+/*
+	FaceValue 批次的票面价, 在发行时登记
+	Issuer 发行时的接收账户, 转售不受限价约束
+	FaceValue 单张票面价(相对于稳定币)
+*/
+type FaceValue struct {
+	BatchID   string `json:"batch_id"`
+	Issuer    string `json:"issuer"`
+	FaceValue uint64 `json:"face_value"`
+}
+
+/*
+	ResaleCap 二级市场转售限价
+	CapBps 单张成交价不能超过票面价的比例(万分比), 为0表示不限
+	Exempt 不受限价约束的卖方账户
+*/
+type ResaleCap struct {
+	CapBps uint64   `json:"cap_bps"`
+	Exempt []string `json:"exempt"`
+}
+
+// ResaleRejected 转售价格超过限价被拒绝时触发的事件
+type ResaleRejected struct {
+	Seller     string   `json:"seller"`
+	Buyer      string   `json:"buyer"`
+	BatchIDs   []string `json:"batch_ids"`
+	Amounts    []uint64 `json:"amounts"`
+	TotalPrice uint64   `json:"total_price"`
+	MaxPrice   uint64   `json:"max_price"`
+}
+
+/*
+	TransferRule 批次的转让限制, 从发行账户转出(一级销售)不受限制
+	Mode 转让方式, 见 TransferMode 常量
+	Until timelock 方式下可以转让的时间(Unix秒)
+	Allowlist allowlist 方式下可以接收的账户
+*/
+type TransferRule struct {
+	BatchID   string   `json:"batch_id"`
+	Issuer    string   `json:"issuer"`
+	Mode      string   `json:"mode"`
+	Until     int64    `json:"until"`
+	Allowlist []string `json:"allowlist"`
+}
+
+// Transferability 批次当前是否可以转让
+type Transferability struct {
+	BatchID      string   `json:"batch_id"`
+	Issuer       string   `json:"issuer"`
+	Mode         string   `json:"mode"`
+	Until        int64    `json:"until"`
+	Allowlist    []string `json:"allowlist"`
+	Transferable bool     `json:"transferable"`
+}
+
+/*
+	TokenLock NFR的锁定状态, 锁定期间不能转移或销毁
+	Locked 是否锁定, 未锁定时其余字段为空
+	Locker 锁定该NFR的链码名称, 只有它可以解锁
+	LockedAt 锁定的交易时间(Unix秒)
+*/
+type TokenLock struct {
+	TokenID  string `json:"token_id"`
+	Locked   bool   `json:"locked"`
+	Owner    string `json:"owner"`
+	Locker   string `json:"locker"`
+	Reason   string `json:"reason"`
+	LockedAt int64  `json:"locked_at"`
+}
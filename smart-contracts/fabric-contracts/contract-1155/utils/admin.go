@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+	"strconv"
+)
+
+/*
+	IsAdminHelper: 查询账户是否为管理员
+	管理员只登记在稳定币合约, 这里调用稳定币合约的 IsAdmin 查询
+*/
+func IsAdminHelper(ctx contractapi.TransactionContextInterface, account string) (bool, error) {
+	args := [][]byte{[]byte(proto.FcnCoinsIsAdmin), []byte(account)}
+	response := ctx.GetStub().InvokeChaincode(proto.ChaincodeNameCoins, args, proto.ChannelID)
+	if response.Status != shim.OK {
+		log.Printf("[ERROR]-[IsAdminHelper] query admin failed, err: %v", response.Message)
+		return false, fmt.Errorf("[IsAdminHelper] query admin failed, err: %v", response.Message)
+	}
+
+	isAdmin, err := strconv.ParseBool(string(response.Payload))
+	if err != nil {
+		return false, fmt.Errorf("[IsAdminHelper] invalid admin flag (%s): %v", response.Payload, err)
+	}
+
+	return isAdmin, nil
+}
+
+/*
+	AuthorizeAdminHelper: 校验调用者是否为管理员, 返回调用者的账户地址
+*/
+func AuthorizeAdminHelper(ctx contractapi.TransactionContextInterface) (string, error) {
+	operator, err := ClientAccountHelper(ctx)
+	if err != nil {
+		return "", fmt.Errorf("[AuthorizeAdminHelper] failed to get client id: %v", err)
+	}
+
+	isAdmin, err := IsAdminHelper(ctx, operator)
+	if err != nil {
+		return "", err
+	}
+	if !isAdmin {
+		return "", fmt.Errorf("[AuthorizeAdminHelper] client account (%s) is not an admin", operator)
+	}
+
+	return operator, nil
+}
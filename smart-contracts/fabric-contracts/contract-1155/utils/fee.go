@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	FeeCollectorsHelper 查询登记的手续费收取账户, 没有登记时返回错误
+*/
+func FeeCollectorsHelper(ctx contractapi.TransactionContextInterface) ([]proto.FeeCollector, error) {
+	collectorsBytes, err := ctx.GetStub().GetState(proto.FeeCollectorsKey)
+	if err != nil {
+		return nil, fmt.Errorf("[FeeCollectorsHelper] failed to read fee collectors: %v", err)
+	}
+	if collectorsBytes == nil {
+		return nil, fmt.Errorf("[FeeCollectorsHelper] fee collectors are not configured")
+	}
+
+	var collectors []proto.FeeCollector
+	if err = json.Unmarshal(collectorsBytes, &collectors); err != nil {
+		return nil, fmt.Errorf("[FeeCollectorsHelper] json unmarshal fee collectors failed, err: %v", err)
+	}
+
+	return collectors, nil
+}
+
+/*
+	CheckFeeCollectorHelper 校验调用方传入的手续费账户是已登记的账户
+	仅用于兼容旧接口, 手续费仍按登记的比例分配
+*/
+func CheckFeeCollectorHelper(ctx contractapi.TransactionContextInterface, feeCollector string) error {
+	collectors, err := FeeCollectorsHelper(ctx)
+	if err != nil {
+		return err
+	}
+	for _, collector := range collectors {
+		if collector.Account == feeCollector {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("[CheckFeeCollectorHelper] fee collector (%s) is not registered", feeCollector)
+}
+
+/*
+	SplitFeeHelper 按比例分配手续费, 除不尽的部分归第一个账户
+	返回收款账户和对应的金额
+*/
+func SplitFeeHelper(collectors []proto.FeeCollector, fee uint64) ([]string, []int) {
+	accounts := make([]string, 0, len(collectors))
+	amounts := make([]int, 0, len(collectors))
+
+	var allocated uint64
+	for _, collector := range collectors {
+		share := fee * collector.Share / proto.FeeShareTotal
+		allocated += share
+		accounts = append(accounts, collector.Account)
+		amounts = append(amounts, int(share))
+	}
+	if len(amounts) > 0 {
+		amounts[0] += int(fee - allocated)
+	}
+
+	return accounts, amounts
+}
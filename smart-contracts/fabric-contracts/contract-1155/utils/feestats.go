@@ -0,0 +1,266 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"sort"
+	"time"
+)
+
+/*
+	TxDayHelper 交易时间戳对应的日期 yyyyMMdd(北京时间), 用于按日统计和限制
+*/
+func TxDayHelper(ctx contractapi.TransactionContextInterface) (string, error) {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return "", fmt.Errorf("[TxDayHelper] failed to get transaction timestamp: %v", err)
+	}
+	zone := time.FixedZone("CST", proto.FeeDayZoneOffset)
+
+	return time.Unix(txTimestamp.GetSeconds(), 0).In(zone).Format(proto.FeeDayLayout), nil
+}
+
+/*
+	CheckDayRangeHelper 校验统计日期范围, 日期格式为 yyyyMMdd, 为空表示不限
+*/
+func CheckDayRangeHelper(fromDay, toDay string) error {
+	for _, day := range []string{fromDay, toDay} {
+		if day == "" {
+			continue
+		}
+		if _, err := time.Parse(proto.FeeDayLayout, day); err != nil {
+			return fmt.Errorf("[CheckDayRangeHelper] invalid day (%s), want yyyyMMdd", day)
+		}
+	}
+	if fromDay != "" && toDay != "" && fromDay > toDay {
+		return fmt.Errorf("[CheckDayRangeHelper] fromDay (%s) is after toDay (%s)", fromDay, toDay)
+	}
+
+	return nil
+}
+
+/*
+	InDayRangeHelper 日期是否在统计范围内(包含两端)
+*/
+func InDayRangeHelper(day, fromDay, toDay string) bool {
+	return (fromDay == "" || day >= fromDay) && (toDay == "" || day <= toDay)
+}
+
+/*
+	RecordFeeHelper 记录一笔手续费到按批次、按日期和按收取账户的统计
+	batchIds: 本次交易涉及的批次, 手续费按 weights 的比例分摊, 除不尽的部分归第一个批次
+	accounts: 手续费收取账户
+	fees: 各收取账户收到的手续费
+	一笔交易只能调用一次, 账本不支持读取本交易内的写入
+*/
+func RecordFeeHelper(ctx contractapi.TransactionContextInterface, batchIds []string, weights []uint64, accounts []string, fees []int) error {
+	day, err := TxDayHelper(ctx)
+	if err != nil {
+		return err
+	}
+
+	delta := &proto.FeeDelta{
+		Day:        day,
+		Batches:    make(map[string]uint64),
+		Collectors: make(map[string]uint64),
+	}
+	for i, account := range accounts {
+		delta.Amount += uint64(fees[i])
+		delta.Collectors[account] += uint64(fees[i])
+	}
+
+	// 同一批次可能出现多次, 先汇总
+	var totalWeight uint64
+	batchWeights := make(map[string]uint64)
+	for i, batchId := range batchIds {
+		batchWeights[batchId] += weights[i]
+		totalWeight += weights[i]
+	}
+	var allocated uint64
+	for _, batchId := range SortedKeys(batchWeights) {
+		if totalWeight == 0 {
+			break
+		}
+		delta.Batches[batchId] = delta.Amount * batchWeights[batchId] / totalWeight
+		allocated += delta.Batches[batchId]
+	}
+	if len(batchIds) > 0 {
+		delta.Batches[batchIds[0]] += delta.Amount - allocated
+	}
+
+	return PutFeeDeltaHelper(ctx, delta)
+}
+
+/*
+	PutFeeDeltaHelper 写入本笔交易的手续费增量
+	增量以 [日期, 交易ID] 作为复合键单独保存, 不读取也不覆盖按日的汇总, 避免并发交易在同一个key上产生MVCC冲突
+	当日结束后由 RollupFeeHelper 汇总到按日统计
+*/
+func PutFeeDeltaHelper(ctx contractapi.TransactionContextInterface, delta *proto.FeeDelta) error {
+	deltaKey, err := ctx.GetStub().CreateCompositeKey(proto.FeeDeltaPrefix, []string{delta.Day, ctx.GetStub().GetTxID()})
+	if err != nil {
+		return fmt.Errorf("[PutFeeDeltaHelper] failed to create the composite key for prefix %s: %v", proto.FeeDeltaPrefix, err)
+	}
+
+	deltaBytes, err := json.Marshal(delta)
+	if err != nil {
+		return fmt.Errorf("[PutFeeDeltaHelper] json marshal fee delta failed, err: %v", err)
+	}
+	if err = ctx.GetStub().PutState(deltaKey, deltaBytes); err != nil {
+		return fmt.Errorf("[PutFeeDeltaHelper] failed to put fee delta %s: %v", deltaKey, err)
+	}
+
+	return nil
+}
+
+/*
+	PendingFeeDeltasHelper 查询尚未汇总的手续费增量, 只返回日期范围内的
+	汇总后的增量会被删除, 这里只会遍历当日和尚未汇总的日期
+*/
+func PendingFeeDeltasHelper(ctx contractapi.TransactionContextInterface, fromDay, toDay string) ([]*proto.FeeDelta, error) {
+	return _feeDeltas(ctx, []string{}, fromDay, toDay, false)
+}
+
+/*
+	RollupFeeHelper 把某一天的手续费增量累加到按日统计, 并删除已汇总的增量
+	只能汇总交易日期之前的日期, 当日仍在写入增量, 汇总会和并发交易冲突
+	返回本次汇总的金额和笔数, 重复汇总同一天时没有增量可汇总, 返回零
+*/
+func RollupFeeHelper(ctx contractapi.TransactionContextInterface, day string) (*proto.FeeDayReport, error) {
+	if err := CheckDayRangeHelper(day, day); err != nil || day == "" {
+		return nil, fmt.Errorf("[RollupFeeHelper] invalid day (%s), want yyyyMMdd", day)
+	}
+	txDay, err := TxDayHelper(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if day >= txDay {
+		return nil, fmt.Errorf("[RollupFeeHelper] day (%s) has not ended yet, only days before %s can be rolled up", day, txDay)
+	}
+
+	deltas, err := _feeDeltas(ctx, []string{day}, day, day, true)
+	if err != nil {
+		return nil, err
+	}
+
+	rolled := &proto.FeeDayReport{Day: day}
+	batches := make(map[string]*proto.FeeCounter)
+	collectors := make(map[string]*proto.FeeCounter)
+	for _, delta := range deltas {
+		rolled.Amount += delta.Amount
+		rolled.Count++
+		for batchId, amount := range delta.Batches {
+			if batches[batchId] == nil {
+				batches[batchId] = new(proto.FeeCounter)
+			}
+			batches[batchId].Amount += amount
+			batches[batchId].Count++
+		}
+		for account, amount := range delta.Collectors {
+			if collectors[account] == nil {
+				collectors[account] = new(proto.FeeCounter)
+			}
+			collectors[account].Amount += amount
+			collectors[account].Count++
+		}
+	}
+	if rolled.Count == 0 {
+		return rolled, nil
+	}
+
+	if err = AddFeeCounterHelper(ctx, proto.FeeDayPrefix, []string{day}, &proto.FeeCounter{Amount: rolled.Amount, Count: rolled.Count}); err != nil {
+		return nil, err
+	}
+	for _, batchId := range _sortedCounterKeys(batches) {
+		if err = AddFeeCounterHelper(ctx, proto.FeeBatchDayPrefix, []string{batchId, day}, batches[batchId]); err != nil {
+			return nil, err
+		}
+	}
+	for _, account := range _sortedCounterKeys(collectors) {
+		if err = AddFeeCounterHelper(ctx, proto.FeeCollectorDayPrefix, []string{account, day}, collectors[account]); err != nil {
+			return nil, err
+		}
+	}
+
+	return rolled, nil
+}
+
+/*
+	AddFeeCounterHelper 把汇总的金额和笔数累加到按日统计
+	只在 RollupFeeHelper 中调用, 交易中不直接写入统计
+*/
+func AddFeeCounterHelper(ctx contractapi.TransactionContextInterface, prefix string, attributes []string, delta *proto.FeeCounter) error {
+	counterKey, err := ctx.GetStub().CreateCompositeKey(prefix, attributes)
+	if err != nil {
+		return fmt.Errorf("[AddFeeCounterHelper] failed to create the composite key for prefix %s: %v", prefix, err)
+	}
+
+	counter := new(proto.FeeCounter)
+	counterBytes, err := ctx.GetStub().GetState(counterKey)
+	if err != nil {
+		return fmt.Errorf("[AddFeeCounterHelper] failed to read fee counter %s: %v", counterKey, err)
+	}
+	if counterBytes != nil {
+		if err = json.Unmarshal(counterBytes, counter); err != nil {
+			return fmt.Errorf("[AddFeeCounterHelper] json unmarshal fee counter failed, err: %v", err)
+		}
+	}
+	counter.Amount += delta.Amount
+	counter.Count += delta.Count
+
+	if counterBytes, err = json.Marshal(counter); err != nil {
+		return fmt.Errorf("[AddFeeCounterHelper] json marshal fee counter failed, err: %v", err)
+	}
+	if err = ctx.GetStub().PutState(counterKey, counterBytes); err != nil {
+		return fmt.Errorf("[AddFeeCounterHelper] failed to put fee counter %s: %v", counterKey, err)
+	}
+
+	return nil
+}
+
+/*
+	_feeDeltas 按 attributes 查询手续费增量, 只返回日期范围内的, remove 为 true 时同时删除
+*/
+func _feeDeltas(ctx contractapi.TransactionContextInterface, attributes []string, fromDay, toDay string, remove bool) ([]*proto.FeeDelta, error) {
+	deltaIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(proto.FeeDeltaPrefix, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("[_feeDeltas] failed to get state for prefix %s: %v", proto.FeeDeltaPrefix, err)
+	}
+	defer deltaIterator.Close()
+
+	deltas := make([]*proto.FeeDelta, 0)
+	for deltaIterator.HasNext() {
+		queryResponse, err := deltaIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("[_feeDeltas] failed to get the next state for prefix %s: %v", proto.FeeDeltaPrefix, err)
+		}
+
+		delta := new(proto.FeeDelta)
+		if err = json.Unmarshal(queryResponse.Value, delta); err != nil {
+			return nil, fmt.Errorf("[_feeDeltas] json unmarshal fee delta failed, err: %v", err)
+		}
+		if !InDayRangeHelper(delta.Day, fromDay, toDay) {
+			continue
+		}
+		deltas = append(deltas, delta)
+
+		if remove {
+			if err = ctx.GetStub().DelState(queryResponse.Key); err != nil {
+				return nil, fmt.Errorf("[_feeDeltas] failed to delete fee delta %s: %v", queryResponse.Key, err)
+			}
+		}
+	}
+
+	return deltas, nil
+}
+
+func _sortedCounterKeys(m map[string]*proto.FeeCounter) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
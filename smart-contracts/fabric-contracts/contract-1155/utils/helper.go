@@ -9,7 +9,6 @@ import (
 	"log"
 	"math"
 	"sort"
-	"strconv"
 )
 
 /*
@@ -91,25 +90,45 @@ func MintHelper(ctx contractapi.TransactionContextInterface, account, batchID, t
 		return fmt.Errorf("[ERROR]-[MintHelper] put state balance failed, err: %v", err)
 	}
 
+	// 首次发行时登记批次的发行账户
+	if err = RegisterBatchIssuerHelper(ctx, batchID, account); err != nil {
+		return fmt.Errorf("[ERROR]-[MintHelper] %v", err)
+	}
+
 	return nil
 }
 
 /*
-	MintNFRPayCoinsHelper 创建NFR手续费收取
-	feeCollector: 手续费收取账户
+	MintNFRPayCoinsHelper 创建NFR手续费收取, 按登记的比例转给手续费收取账户
+	batchIDs: 创建的NFR批次(用于手续费统计)
+	amounts: 各批次创建的数量
 */
-func MintNFRPayCoinsHelper(ctx contractapi.TransactionContextInterface, feeCollector string) error {
+func MintNFRPayCoinsHelper(ctx contractapi.TransactionContextInterface, batchIDs []string, amounts []uint64) error {
 	// fixme 目前扣除手续费是写死(10000个稳定币), 后期需要修改
 	fee := 10000
 
-	args := [][]byte{[]byte(proto.FcnCoinsTransfer), []byte(feeCollector), []byte(strconv.Itoa(fee))}
+	collectors, err := FeeCollectorsHelper(ctx)
+	if err != nil {
+		return err
+	}
+	accounts, fees := SplitFeeHelper(collectors, uint64(fee))
+	accountsBytes, err := json.Marshal(accounts)
+	if err != nil {
+		return fmt.Errorf("json marshal accounts failed, err: %v", err)
+	}
+	feesBytes, err := json.Marshal(fees)
+	if err != nil {
+		return fmt.Errorf("json marshal fees failed, err: %v", err)
+	}
+
+	args := [][]byte{[]byte(proto.FcnCoinsTransferBatch), accountsBytes, feesBytes}
 	response := ctx.GetStub().InvokeChaincode(proto.ChaincodeNameCoins, args, proto.ChannelID)
 	if response.Status != shim.OK {
 		log.Printf("[ERROR]-[MintNFRPayCoinsHelper] transfer coins failed, err: %v", response.Message)
 		return fmt.Errorf("transfer coins failed, err: %v", response.Message)
 	}
 
-	return nil
+	return RecordFeeHelper(ctx, batchIDs, amounts, accounts, fees)
 }
 
 /*
@@ -146,20 +165,28 @@ func BurnNFRHelper(ctx contractapi.TransactionContextInterface, account string,
 
 		// 迭代所有的key
 		for balanceIterator.HasNext() && partialBalance < neededAmount {
-			// 存在即代表余额加1
-			partialBalance++
-
 			queryResponse, err := balanceIterator.Next()
 			if err != nil {
 				return fmt.Errorf("[RemoveBalance] failed to get the next state for prefix %v: %v", proto.PrefixBalance, err)
 			}
 
+			// 锁定的NFR不能转移或销毁
+			locked, err := BalanceKeyLockedHelper(ctx, queryResponse.Key)
+			if err != nil {
+				return err
+			}
+			if locked {
+				continue
+			}
+
+			// 存在即代表余额加1
+			partialBalance++
 			balanceKeys = append(balanceKeys, queryResponse.Key)
 		}
 
 		if partialBalance < neededAmount {
 			// 余额不够
-			return fmt.Errorf("[RemoveBalance] sender has insufficient funds for token (%v), needed funds: (%v), available fund: (%v)", batchId, neededAmount, partialBalance)
+			return fmt.Errorf("[RemoveBalance] sender has insufficient unlocked funds for token (%v), needed funds: (%v), available fund: (%v)", batchId, neededAmount, partialBalance)
 		} else {
 			// 余额大于或等于需要的数量
 			for i := 0; i < int(neededAmount); i++ {
@@ -179,15 +206,17 @@ func BurnNFRHelper(ctx contractapi.TransactionContextInterface, account string,
 	TradeNFRPayCoinsHelper
 	NFRSender: 收取稳定币账户
 	value: 价值
+	batchIds: 交易的NFR批次(用于手续费统计)
+	tradeAmounts: 各批次交易的数量
 */
-func TradeNFRPayCoinsHelper(ctx contractapi.TransactionContextInterface, NFRSender, feeCollector string, value uint64) error {
-	// 计算手续费
-	fee := Round(float64(value) * 0.03)
-	log.Printf("[INFO]-[TradeNFRPayCoinsHelper] this trade fee handing is (%v) coins", fee)
+func TradeNFRPayCoinsHelper(ctx contractapi.TransactionContextInterface, NFRSender string, value uint64, batchIds []string, tradeAmounts []uint64) error {
+	collectors, err := FeeCollectorsHelper(ctx)
+	if err != nil {
+		return err
+	}
 
 	// 将手续费和NFR对应价值的稳定币转给手续费账户和NFR发送方
-	accounts := []string{feeCollector, NFRSender}
-	amounts := []int{int(fee), int(value)}
+	accounts, amounts := TradeNFRPaymentHelper(collectors, NFRSender, value)
 	accountsBytes, err := json.Marshal(accounts)
 	if err != nil {
 		log.Printf("json marshal accounts failed, err: %v", err)
@@ -205,6 +234,11 @@ func TradeNFRPayCoinsHelper(ctx contractapi.TransactionContextInterface, NFRSend
 		return fmt.Errorf("transfer batch coins failed, err: %v", response.Message)
 	}
 
+	// 记录手续费统计, 最后一个收款账户是NFR发送方
+	if err = RecordFeeHelper(ctx, batchIds, tradeAmounts, accounts[:len(accounts)-1], amounts[:len(amounts)-1]); err != nil {
+		return err
+	}
+
 	//// 将手续费转给手续费账户
 	//feeArgs := [][]byte{[]byte("Transfer"), []byte(feeCollector), []byte(strconv.Itoa(int(fee)))}
 	//feeResponse := ctx.GetStub().InvokeChaincode("contract-20-v1", feeArgs, "chan2021")
@@ -224,6 +258,92 @@ func TradeNFRPayCoinsHelper(ctx contractapi.TransactionContextInterface, NFRSend
 	return nil
 }
 
+/*
+	TradeNFRPaymentHelper 计算交易需要支付的稳定币
+	返回收款账户[手续费账户..., NFR发送方]和对应的金额, 手续费按登记的比例分配
+*/
+func TradeNFRPaymentHelper(collectors []proto.FeeCollector, NFRSender string, value uint64) ([]string, []int) {
+	// 计算手续费
+	fee := Round(float64(value) * 0.03)
+	log.Printf("[INFO]-[TradeNFRPaymentHelper] this trade fee handing is (%v) coins", fee)
+
+	accounts, amounts := SplitFeeHelper(collectors, fee)
+
+	return append(accounts, NFRSender), append(amounts, int(value))
+}
+
+/*
+	TradeNFRPaySignedCoinsHelper 使用买方签名的稳定币转账支付交易费用
+	buyer: 买方账户(签名者)
+	NFRSender: 收取稳定币账户
+	value: 价值
+	batchIds: 交易的NFR批次(用于手续费统计)
+	tradeAmounts: 各批次交易的数量
+	coinPayload: 买方签名的稳定币合约 TransferBatch 代理调用数据
+	coinSignature: 对coinPayload的签名
+*/
+func TradeNFRPaySignedCoinsHelper(ctx contractapi.TransactionContextInterface, buyer, NFRSender string, value uint64, batchIds []string, tradeAmounts []uint64, coinPayload, coinSignature string) error {
+	collectors, err := FeeCollectorsHelper(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 校验签名数据中的转账与本次交易一致, 签名本身由稳定币合约验证
+	if _, err = CheckSignedCoinsHelper(collectors, buyer, NFRSender, value, coinPayload); err != nil {
+		return err
+	}
+
+	args := [][]byte{[]byte(proto.FcnCoinsExecuteSigned), []byte(coinPayload), []byte(coinSignature)}
+	response := ctx.GetStub().InvokeChaincode(proto.ChaincodeNameCoins, args, proto.ChannelID)
+	if response.Status != shim.OK {
+		log.Printf("[ERROR]-[TradeNFRPaySignedCoinsHelper] execute signed coins failed, err: %v", response.Message)
+		return fmt.Errorf("execute signed coins failed, err: %v", response.Message)
+	}
+
+	// 记录手续费统计, 最后一个收款账户是NFR发送方
+	accounts, amounts := TradeNFRPaymentHelper(collectors, NFRSender, value)
+
+	return RecordFeeHelper(ctx, batchIds, tradeAmounts, accounts[:len(accounts)-1], amounts[:len(amounts)-1])
+}
+
+/*
+	CheckSignedCoinsHelper 校验买方签名的稳定币转账与交易一致, 不校验签名本身
+	collectors: 登记的手续费收取账户
+	返回解析后的签名数据
+*/
+func CheckSignedCoinsHelper(collectors []proto.FeeCollector, buyer, NFRSender string, value uint64, coinPayload string) (*proto.SignedPayload, error) {
+	coinSigned := new(proto.SignedPayload)
+	if err := json.Unmarshal([]byte(coinPayload), coinSigned); err != nil {
+		return nil, fmt.Errorf("[CheckSignedCoinsHelper] json unmarshal coin payload failed, err: %v", err)
+	}
+	if coinSigned.Signer != buyer {
+		return nil, fmt.Errorf("[CheckSignedCoinsHelper] coin payload signer (%s) is not the buyer (%s)", coinSigned.Signer, buyer)
+	}
+	if coinSigned.Function != proto.FcnCoinsTransferBatch || len(coinSigned.Args) != 2 {
+		return nil, fmt.Errorf("[CheckSignedCoinsHelper] coin payload must be a %s call with 2 args", proto.FcnCoinsTransferBatch)
+	}
+
+	accounts, amounts := TradeNFRPaymentHelper(collectors, NFRSender, value)
+	var signedAccounts []string
+	var signedAmounts []int
+	if err := json.Unmarshal([]byte(coinSigned.Args[0]), &signedAccounts); err != nil {
+		return nil, fmt.Errorf("[CheckSignedCoinsHelper] json unmarshal coin accounts failed, err: %v", err)
+	}
+	if err := json.Unmarshal([]byte(coinSigned.Args[1]), &signedAmounts); err != nil {
+		return nil, fmt.Errorf("[CheckSignedCoinsHelper] json unmarshal coin amounts failed, err: %v", err)
+	}
+	if len(signedAccounts) != len(accounts) || len(signedAmounts) != len(amounts) {
+		return nil, fmt.Errorf("[CheckSignedCoinsHelper] coin payload does not match the trade, want %v %v", accounts, amounts)
+	}
+	for i := range accounts {
+		if signedAccounts[i] != accounts[i] || signedAmounts[i] != amounts[i] {
+			return nil, fmt.Errorf("[CheckSignedCoinsHelper] coin payload does not match the trade, want %v %v", accounts, amounts)
+		}
+	}
+
+	return coinSigned, nil
+}
+
 /*
 	TransferHelper
 	sender: 发送者账户
@@ -246,6 +366,16 @@ func TransferHelper(ctx contractapi.TransactionContextInterface, sender, recipie
 		// 此类票需要转账的数量
 		neededAmount := necessaryFunds[batchId]
 
+		// 批次的转让限制
+		if err := CheckTransferRuleHelper(ctx, sender, recipient, batchId); err != nil {
+			return updateNftList, err
+		}
+
+		// 接收者的持有和接收限制
+		if err := CheckBatchLimitHelper(ctx, recipient, batchId, neededAmount); err != nil {
+			return updateNftList, err
+		}
+
 		var senderBalanceKeys []string
 		var partialBalance uint64
 
@@ -258,20 +388,28 @@ func TransferHelper(ctx contractapi.TransactionContextInterface, sender, recipie
 
 		// 迭代所有的key
 		for balanceIterator.HasNext() && partialBalance < neededAmount {
-			// 存在即代表余额加1
-			partialBalance++
-
 			queryResponse, err := balanceIterator.Next()
 			if err != nil {
 				return updateNftList, fmt.Errorf("[TransferHelper] failed to get the next state for prefix %v: %v", proto.PrefixBalance, err)
 			}
 
+			// 锁定的NFR不能转移或销毁
+			locked, err := BalanceKeyLockedHelper(ctx, queryResponse.Key)
+			if err != nil {
+				return updateNftList, err
+			}
+			if locked {
+				continue
+			}
+
+			// 存在即代表余额加1
+			partialBalance++
 			senderBalanceKeys = append(senderBalanceKeys, queryResponse.Key)
 		}
 
 		if partialBalance < neededAmount {
 			// 余额不够
-			return updateNftList, fmt.Errorf("[TransferHelper] sender has insufficient funds for token (%v), needed funds: (%v), available fund: (%v)", batchId, neededAmount, partialBalance)
+			return updateNftList, fmt.Errorf("[TransferHelper] sender has insufficient unlocked funds for token (%v), needed funds: (%v), available fund: (%v)", batchId, neededAmount, partialBalance)
 		} else {
 			// 余额大于或等于需要的数量
 			for i := 0; i < int(neededAmount); i++ {
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+)
+
+/*
+	ClientAccountHelper: 获取客户端证书身份对应的稳定账户地址
+*/
+func ClientAccountHelper(ctx contractapi.TransactionContextInterface) (string, error) {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", err
+	}
+
+	return ResolveAccountHelper(ctx, clientID)
+}
+
+/*
+	ResolveAccountHelper: 根据证书身份ID查询稳定账户地址
+	身份映射只登记在稳定币合约, 这里调用稳定币合约的 ResolveAccount 查询, 不再维护单独的映射
+*/
+func ResolveAccountHelper(ctx contractapi.TransactionContextInterface, clientID string) (string, error) {
+	args := [][]byte{[]byte(proto.FcnCoinsResolveAccount), []byte(clientID)}
+	response := ctx.GetStub().InvokeChaincode(proto.ChaincodeNameCoins, args, proto.ChannelID)
+	if response.Status != shim.OK {
+		log.Printf("[ERROR]-[ResolveAccountHelper] resolve account failed, err: %v", response.Message)
+		return "", fmt.Errorf("[ResolveAccountHelper] resolve account failed, err: %v", response.Message)
+	}
+
+	return string(response.Payload), nil
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	ReadBatchIssuerHelper 查询批次的发行账户, 没有登记则返回空字符串
+	发行账户是批次首次发行时的接收账户, 登记之前发行的批次以票面价登记的发行账户为准
+*/
+func ReadBatchIssuerHelper(ctx contractapi.TransactionContextInterface, batchId string) (string, error) {
+	issuerKey, err := ctx.GetStub().CreateCompositeKey(proto.BatchIssuerPrefix, []string{batchId})
+	if err != nil {
+		return "", fmt.Errorf("[ReadBatchIssuerHelper] failed to create the composite key for prefix %s: %v", proto.BatchIssuerPrefix, err)
+	}
+	issuerBytes, err := ctx.GetStub().GetState(issuerKey)
+	if err != nil {
+		return "", fmt.Errorf("[ReadBatchIssuerHelper] failed to read issuer of batch %s: %v", batchId, err)
+	}
+	if issuerBytes != nil {
+		return string(issuerBytes), nil
+	}
+
+	faceValue, err := ReadFaceValueHelper(ctx, batchId)
+	if err != nil || faceValue == nil {
+		return "", err
+	}
+
+	return faceValue.Issuer, nil
+}
+
+/*
+	RegisterBatchIssuerHelper 批次首次发行时登记发行账户, 已登记时不做修改
+	同一交易内多次调用读不到本交易的写入, 写入的是同一个账户
+*/
+func RegisterBatchIssuerHelper(ctx contractapi.TransactionContextInterface, batchId, account string) error {
+	issuerKey, err := ctx.GetStub().CreateCompositeKey(proto.BatchIssuerPrefix, []string{batchId})
+	if err != nil {
+		return fmt.Errorf("[RegisterBatchIssuerHelper] failed to create the composite key for prefix %s: %v", proto.BatchIssuerPrefix, err)
+	}
+	issuerBytes, err := ctx.GetStub().GetState(issuerKey)
+	if err != nil {
+		return fmt.Errorf("[RegisterBatchIssuerHelper] failed to read issuer of batch %s: %v", batchId, err)
+	}
+	if issuerBytes != nil {
+		return nil
+	}
+
+	if err = ctx.GetStub().PutState(issuerKey, []byte(account)); err != nil {
+		return fmt.Errorf("[RegisterBatchIssuerHelper] failed to put issuer of batch %s: %v", batchId, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"strconv"
+)
+
+/*
+	ReadBatchLimitHelper 查询批次的持有和接收限制, 没有配置则返回nil
+*/
+func ReadBatchLimitHelper(ctx contractapi.TransactionContextInterface, batchId string) (*proto.BatchLimit, error) {
+	limitKey, err := ctx.GetStub().CreateCompositeKey(proto.BatchLimitPrefix, []string{batchId})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadBatchLimitHelper] failed to create the composite key for prefix %s: %v", proto.BatchLimitPrefix, err)
+	}
+	limitBytes, err := ctx.GetStub().GetState(limitKey)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadBatchLimitHelper] failed to read limit of batch %s: %v", batchId, err)
+	}
+	if limitBytes == nil {
+		return nil, nil
+	}
+
+	limit := new(proto.BatchLimit)
+	if err = json.Unmarshal(limitBytes, limit); err != nil {
+		return nil, fmt.Errorf("[ReadBatchLimitHelper] json unmarshal batch limit failed, err: %v", err)
+	}
+
+	return limit, nil
+}
+
+/*
+	CheckBatchLimitHelper 校验接收者接收 amount 张后不超过批次的限制, 并累加当天的接收数量
+	recipient: 接收者账户
+	batchId: NFR批次类型
+	amount: 本次交易接收的数量
+	同一笔交易对同一接收者和批次只能调用一次, 账本不支持读取本交易内的写入
+*/
+func CheckBatchLimitHelper(ctx contractapi.TransactionContextInterface, recipient, batchId string, amount uint64) error {
+	limit, err := ReadBatchLimitHelper(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	if limit == nil {
+		return nil
+	}
+	for _, account := range limit.Exempt {
+		if account == recipient {
+			return nil
+		}
+	}
+
+	if limit.MaxPerTx > 0 && amount > limit.MaxPerTx {
+		return fmt.Errorf("[CheckBatchLimitHelper] per-transaction limit of batch (%s) is %d, account (%s) receives %d", batchId, limit.MaxPerTx, recipient, amount)
+	}
+
+	if limit.MaxHolding > 0 {
+		balance, err := BalanceOfHelper(ctx, recipient, batchId)
+		if err != nil {
+			return err
+		}
+		if balance+amount > limit.MaxHolding {
+			return fmt.Errorf("[CheckBatchLimitHelper] holding limit of batch (%s) is %d, account (%s) holds %d and receives %d", batchId, limit.MaxHolding, recipient, balance, amount)
+		}
+	}
+
+	if limit.MaxPerDay > 0 {
+		day, err := TxDayHelper(ctx)
+		if err != nil {
+			return err
+		}
+		dailyKey, err := ctx.GetStub().CreateCompositeKey(proto.BatchDailyPrefix, []string{batchId, recipient, day})
+		if err != nil {
+			return fmt.Errorf("[CheckBatchLimitHelper] failed to create the composite key for prefix %s: %v", proto.BatchDailyPrefix, err)
+		}
+		dailyBytes, err := ctx.GetStub().GetState(dailyKey)
+		if err != nil {
+			return fmt.Errorf("[CheckBatchLimitHelper] failed to read daily amount: %v", err)
+		}
+		var received uint64
+		if dailyBytes != nil {
+			received, _ = strconv.ParseUint(string(dailyBytes), 10, 64)
+		}
+		if received+amount > limit.MaxPerDay {
+			return fmt.Errorf("[CheckBatchLimitHelper] daily limit of batch (%s) is %d, account (%s) received %d on %s and receives %d", batchId, limit.MaxPerDay, recipient, received, day, amount)
+		}
+		if err = ctx.GetStub().PutState(dailyKey, []byte(strconv.FormatUint(received+amount, 10))); err != nil {
+			return fmt.Errorf("[CheckBatchLimitHelper] failed to put daily amount: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"encoding/json"
+	"fmt"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/*
+	CallerChaincodeHelper 获取交易提案调用的链码名称
+	其他链码通过 InvokeChaincode 调用本合约时, 返回的是该链码的名称
+*/
+func CallerChaincodeHelper(ctx contractapi.TransactionContextInterface) (string, error) {
+	signedProposal, err := ctx.GetStub().GetSignedProposal()
+	if err != nil {
+		return "", fmt.Errorf("[CallerChaincodeHelper] failed to get signed proposal: %v", err)
+	}
+	if signedProposal == nil {
+		return "", fmt.Errorf("[CallerChaincodeHelper] signed proposal is empty")
+	}
+
+	proposal := new(peer.Proposal)
+	if err = protobuf.Unmarshal(signedProposal.ProposalBytes, proposal); err != nil {
+		return "", fmt.Errorf("[CallerChaincodeHelper] failed to unmarshal proposal: %v", err)
+	}
+	payload := new(peer.ChaincodeProposalPayload)
+	if err = protobuf.Unmarshal(proposal.Payload, payload); err != nil {
+		return "", fmt.Errorf("[CallerChaincodeHelper] failed to unmarshal proposal payload: %v", err)
+	}
+	invocationSpec := new(peer.ChaincodeInvocationSpec)
+	if err = protobuf.Unmarshal(payload.Input, invocationSpec); err != nil {
+		return "", fmt.Errorf("[CallerChaincodeHelper] failed to unmarshal chaincode invocation spec: %v", err)
+	}
+
+	return invocationSpec.GetChaincodeSpec().GetChaincodeId().GetName(), nil
+}
+
+/*
+	IsLockerHelper 查询链码是否为登记的锁定方
+*/
+func IsLockerHelper(ctx contractapi.TransactionContextInterface, lockerChaincode string) (bool, error) {
+	lockerKey, err := ctx.GetStub().CreateCompositeKey(proto.LockerPrefix, []string{lockerChaincode})
+	if err != nil {
+		return false, fmt.Errorf("[IsLockerHelper] failed to create the composite key for prefix %s: %v", proto.LockerPrefix, err)
+	}
+	lockerBytes, err := ctx.GetStub().GetState(lockerKey)
+	if err != nil {
+		return false, fmt.Errorf("[IsLockerHelper] failed to read locker %s: %v", lockerChaincode, err)
+	}
+
+	return lockerBytes != nil, nil
+}
+
+/*
+	CheckLockerHelper 校验交易由登记的锁定方链码发起
+*/
+func CheckLockerHelper(ctx contractapi.TransactionContextInterface, lockerChaincode string) error {
+	isLocker, err := IsLockerHelper(ctx, lockerChaincode)
+	if err != nil {
+		return err
+	}
+	if !isLocker {
+		return fmt.Errorf("[CheckLockerHelper] chaincode (%s) is not a registered locker", lockerChaincode)
+	}
+
+	caller, err := CallerChaincodeHelper(ctx)
+	if err != nil {
+		return err
+	}
+	if caller != lockerChaincode {
+		return fmt.Errorf("[CheckLockerHelper] transaction was proposed to chaincode (%s), not locker (%s)", caller, lockerChaincode)
+	}
+
+	return nil
+}
+
+/*
+	ReadTokenLockHelper 查询NFR的锁定状态, 没有锁定则返回nil
+*/
+func ReadTokenLockHelper(ctx contractapi.TransactionContextInterface, tokenId string) (*proto.TokenLock, error) {
+	lockKey, err := ctx.GetStub().CreateCompositeKey(proto.TokenLockPrefix, []string{tokenId})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadTokenLockHelper] failed to create the composite key for prefix %s: %v", proto.TokenLockPrefix, err)
+	}
+	lockBytes, err := ctx.GetStub().GetState(lockKey)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadTokenLockHelper] failed to read lock of token %s: %v", tokenId, err)
+	}
+	if lockBytes == nil {
+		return nil, nil
+	}
+
+	lock := new(proto.TokenLock)
+	if err = json.Unmarshal(lockBytes, lock); err != nil {
+		return nil, fmt.Errorf("[ReadTokenLockHelper] json unmarshal lock failed, err: %v", err)
+	}
+
+	return lock, nil
+}
+
+/*
+	BalanceKeyLockedHelper 判断余额复合键对应的NFR是否被锁定
+*/
+func BalanceKeyLockedHelper(ctx contractapi.TransactionContextInterface, balanceKey string) (bool, error) {
+	_, compositeKeyParts, err := ctx.GetStub().SplitCompositeKey(balanceKey)
+	if err != nil {
+		return false, fmt.Errorf("[BalanceKeyLockedHelper] SplitCompositeKey failed, err: %v", err)
+	}
+	lock, err := ReadTokenLockHelper(ctx, compositeKeyParts[2])
+	if err != nil {
+		return false, err
+	}
+
+	return lock != nil, nil
+}
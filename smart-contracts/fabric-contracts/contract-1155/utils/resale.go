@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"math"
+	"math/big"
+)
+
+/*
+	ReadFaceValueHelper 查询批次登记的票面价, 没有登记则返回nil
+*/
+func ReadFaceValueHelper(ctx contractapi.TransactionContextInterface, batchId string) (*proto.FaceValue, error) {
+	faceValueKey, err := ctx.GetStub().CreateCompositeKey(proto.FaceValuePrefix, []string{batchId})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadFaceValueHelper] failed to create the composite key for prefix %s: %v", proto.FaceValuePrefix, err)
+	}
+	faceValueBytes, err := ctx.GetStub().GetState(faceValueKey)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadFaceValueHelper] failed to read face value of batch %s: %v", batchId, err)
+	}
+	if faceValueBytes == nil {
+		return nil, nil
+	}
+
+	faceValue := new(proto.FaceValue)
+	if err = json.Unmarshal(faceValueBytes, faceValue); err != nil {
+		return nil, fmt.Errorf("[ReadFaceValueHelper] json unmarshal face value failed, err: %v", err)
+	}
+
+	return faceValue, nil
+}
+
+/*
+	PutFaceValueHelper 登记批次的票面价
+*/
+func PutFaceValueHelper(ctx contractapi.TransactionContextInterface, faceValue *proto.FaceValue) error {
+	faceValueKey, err := ctx.GetStub().CreateCompositeKey(proto.FaceValuePrefix, []string{faceValue.BatchID})
+	if err != nil {
+		return fmt.Errorf("[PutFaceValueHelper] failed to create the composite key for prefix %s: %v", proto.FaceValuePrefix, err)
+	}
+	faceValueBytes, err := json.Marshal(faceValue)
+	if err != nil {
+		return fmt.Errorf("[PutFaceValueHelper] json marshal face value failed, err: %v", err)
+	}
+	if err = ctx.GetStub().PutState(faceValueKey, faceValueBytes); err != nil {
+		return fmt.Errorf("[PutFaceValueHelper] failed to put face value of batch %s: %v", faceValue.BatchID, err)
+	}
+
+	return nil
+}
+
+/*
+	ReadResaleCapHelper 查询转售限价配置, 没有配置时不限价
+*/
+func ReadResaleCapHelper(ctx contractapi.TransactionContextInterface) (*proto.ResaleCap, error) {
+	resaleCap := &proto.ResaleCap{Exempt: make([]string, 0)}
+
+	resaleCapBytes, err := ctx.GetStub().GetState(proto.ResaleCapKey)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadResaleCapHelper] failed to read resale cap: %v", err)
+	}
+	if resaleCapBytes == nil {
+		return resaleCap, nil
+	}
+	if err = json.Unmarshal(resaleCapBytes, resaleCap); err != nil {
+		return nil, fmt.Errorf("[ReadResaleCapHelper] json unmarshal resale cap failed, err: %v", err)
+	}
+
+	return resaleCap, nil
+}
+
+/*
+	ResaleMaxPriceHelper 计算卖方转售这些NFR允许的最高总价
+	只对登记了票面价且卖方不是发行账户的批次限价, 最高总价为这些批次的票面价合计乘以限价比例
+	capped 为false时不限价: 未设置限价、卖方是豁免账户, 或所有批次都不限价
+	限价批次与不限价批次在同一笔交易中出售时无法区分各自的成交价, 返回错误
+*/
+func ResaleMaxPriceHelper(ctx contractapi.TransactionContextInterface, seller string, batchIds []string, amounts []uint64) (maxPrice uint64, capped bool, err error) {
+	if len(batchIds) != len(amounts) {
+		return 0, false, fmt.Errorf("[ResaleMaxPriceHelper] batchIds and amounts must have the same length")
+	}
+
+	resaleCap, err := ReadResaleCapHelper(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	if resaleCap.CapBps == 0 {
+		return 0, false, nil
+	}
+	for _, account := range resaleCap.Exempt {
+		if account == seller {
+			return 0, false, nil
+		}
+	}
+
+	// 按限价批次的票面价合计, 再乘以限价比例
+	total := new(big.Int)
+	var uncapped []string
+	for i, batchId := range batchIds {
+		faceValue, err := ReadFaceValueHelper(ctx, batchId)
+		if err != nil {
+			return 0, false, err
+		}
+		if faceValue == nil || faceValue.Issuer == seller {
+			uncapped = append(uncapped, batchId)
+			continue
+		}
+		capped = true
+		value := new(big.Int).SetUint64(faceValue.FaceValue)
+		total.Add(total, value.Mul(value, new(big.Int).SetUint64(amounts[i])))
+	}
+	if !capped {
+		return 0, false, nil
+	}
+	if len(uncapped) > 0 {
+		return 0, false, fmt.Errorf("[ResaleMaxPriceHelper] batches %v have no resale cap and must be sold separately from capped batches", uncapped)
+	}
+	total.Mul(total, new(big.Int).SetUint64(resaleCap.CapBps))
+	total.Quo(total, big.NewInt(proto.FeeShareTotal))
+	if !total.IsUint64() {
+		return math.MaxUint64, true, nil
+	}
+
+	return total.Uint64(), true, nil
+}
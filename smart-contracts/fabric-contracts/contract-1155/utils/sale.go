@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"math/bits"
+	"strconv"
+)
+
+/*
+	ReadSaleHelper 查询批次的发售配置, 没有配置则返回nil
+*/
+func ReadSaleHelper(ctx contractapi.TransactionContextInterface, batchId string) (*proto.SaleConfig, error) {
+	saleKey, err := ctx.GetStub().CreateCompositeKey(proto.SalePrefix, []string{batchId})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadSaleHelper] failed to create the composite key for prefix %s: %v", proto.SalePrefix, err)
+	}
+	saleBytes, err := ctx.GetStub().GetState(saleKey)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadSaleHelper] failed to read sale of batch %s: %v", batchId, err)
+	}
+	if saleBytes == nil {
+		return nil, nil
+	}
+
+	sale := new(proto.SaleConfig)
+	if err = json.Unmarshal(saleBytes, sale); err != nil {
+		return nil, fmt.Errorf("[ReadSaleHelper] json unmarshal sale failed, err: %v", err)
+	}
+
+	return sale, nil
+}
+
+/*
+	PutSaleHelper 保存批次的发售配置
+*/
+func PutSaleHelper(ctx contractapi.TransactionContextInterface, sale *proto.SaleConfig) error {
+	saleKey, err := ctx.GetStub().CreateCompositeKey(proto.SalePrefix, []string{sale.BatchID})
+	if err != nil {
+		return fmt.Errorf("[PutSaleHelper] failed to create the composite key for prefix %s: %v", proto.SalePrefix, err)
+	}
+	saleBytes, err := json.Marshal(sale)
+	if err != nil {
+		return fmt.Errorf("[PutSaleHelper] json marshal sale failed, err: %v", err)
+	}
+	if err = ctx.GetStub().PutState(saleKey, saleBytes); err != nil {
+		return fmt.Errorf("[PutSaleHelper] failed to put sale of batch %s: %v", sale.BatchID, err)
+	}
+
+	return nil
+}
+
+/*
+	SalePriceHelper 计算在已售出数量之后再购买 amount 张的总价
+	now: 交易时间戳(Unix秒), 早鸟期内统一使用早鸟价
+*/
+func SalePriceHelper(sale *proto.SaleConfig, now int64, amount uint64) (uint64, error) {
+	capacity := sale.Tiers[len(sale.Tiers)-1].UpTo
+	if amount > capacity-sale.Sold {
+		return 0, fmt.Errorf("[SalePriceHelper] batch %s has %d left, want %d", sale.BatchID, capacity-sale.Sold, amount)
+	}
+
+	if sale.EarlyBirdPrice > 0 && now < sale.EarlyBirdEnd {
+		hi, total := bits.Mul64(sale.EarlyBirdPrice, amount)
+		if hi != 0 {
+			return 0, fmt.Errorf("[SalePriceHelper] total price of %d at %d overflows", amount, sale.EarlyBirdPrice)
+		}
+		return total, nil
+	}
+
+	// 按阶梯逐档计算
+	var total uint64
+	sold, left := sale.Sold, amount
+	for _, tier := range sale.Tiers {
+		if left == 0 {
+			break
+		}
+		if sold >= tier.UpTo {
+			continue
+		}
+		count := tier.UpTo - sold
+		if count > left {
+			count = left
+		}
+		hi, price := bits.Mul64(count, tier.Price)
+		var carry uint64
+		total, carry = bits.Add64(total, price, 0)
+		if hi != 0 || carry != 0 {
+			return 0, fmt.Errorf("[SalePriceHelper] total price of %d overflows", amount)
+		}
+		sold += count
+		left -= count
+	}
+
+	return total, nil
+}
+
+/*
+	SalePurchasedHelper 查询账户在批次发售中已购买的数量
+*/
+func SalePurchasedHelper(ctx contractapi.TransactionContextInterface, batchId, buyer string) (uint64, error) {
+	purchasedKey, err := ctx.GetStub().CreateCompositeKey(proto.SalePurchasedPrefix, []string{batchId, buyer})
+	if err != nil {
+		return 0, fmt.Errorf("[SalePurchasedHelper] failed to create the composite key for prefix %s: %v", proto.SalePurchasedPrefix, err)
+	}
+	purchasedBytes, err := ctx.GetStub().GetState(purchasedKey)
+	if err != nil {
+		return 0, fmt.Errorf("[SalePurchasedHelper] failed to read purchased amount: %v", err)
+	}
+	if purchasedBytes == nil {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(string(purchasedBytes), 10, 64)
+}
+
+/*
+	PutSalePurchasedHelper 更新账户在批次发售中已购买的数量
+*/
+func PutSalePurchasedHelper(ctx contractapi.TransactionContextInterface, batchId, buyer string, purchased uint64) error {
+	purchasedKey, err := ctx.GetStub().CreateCompositeKey(proto.SalePurchasedPrefix, []string{batchId, buyer})
+	if err != nil {
+		return fmt.Errorf("[PutSalePurchasedHelper] failed to create the composite key for prefix %s: %v", proto.SalePurchasedPrefix, err)
+	}
+	if err = ctx.GetStub().PutState(purchasedKey, []byte(strconv.FormatUint(purchased, 10))); err != nil {
+		return fmt.Errorf("[PutSalePurchasedHelper] failed to put purchased amount: %v", err)
+	}
+
+	return nil
+}
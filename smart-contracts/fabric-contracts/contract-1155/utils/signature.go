@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+	"strconv"
+)
+
+/*
+	VerifySignatureHelper: 使用账户在稳定币合约登记的公钥验证签名
+	account: 签名账户
+	payload: 签名数据
+	signature: base64编码的ASN.1 DER格式ECDSA签名，签名对象为payload的SHA-256
+*/
+func VerifySignatureHelper(ctx contractapi.TransactionContextInterface, account string, payload []byte, signature string) error {
+	args := [][]byte{[]byte(proto.FcnCoinsVerifySignature), []byte(account), payload, []byte(signature)}
+	response := ctx.GetStub().InvokeChaincode(proto.ChaincodeNameCoins, args, proto.ChannelID)
+	if response.Status != shim.OK {
+		log.Printf("[ERROR]-[VerifySignatureHelper] verify signature failed, err: %v", response.Message)
+		return fmt.Errorf("[VerifySignatureHelper] verify signature failed, err: %v", response.Message)
+	}
+
+	return nil
+}
+
+/*
+	NonceHelper: 查询账户在本合约下一个可用的签名序号
+	序号登记在稳定币合约, 按链码名称区分, 本合约的名称即当前交易提案调用的链码
+*/
+func NonceHelper(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	chaincode, err := CallerChaincodeHelper(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	args := [][]byte{[]byte(proto.FcnCoinsNoncesOf), []byte(account), []byte(chaincode)}
+	response := ctx.GetStub().InvokeChaincode(proto.ChaincodeNameCoins, args, proto.ChannelID)
+	if response.Status != shim.OK {
+		log.Printf("[ERROR]-[NonceHelper] query nonce failed, err: %v", response.Message)
+		return 0, fmt.Errorf("[NonceHelper] query nonce failed, err: %v", response.Message)
+	}
+
+	nonce, err := strconv.Atoi(string(response.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("[NonceHelper] invalid nonce (%s): %v", response.Payload, err)
+	}
+
+	return nonce, nil
+}
+
+/*
+	UseNonceHelper: 由稳定币合约验证签名并消耗签名者在本合约的序号，防止重放
+	payload: 签名数据原文
+	nonce: 必须等于签名者当前的序号
+*/
+func UseNonceHelper(ctx contractapi.TransactionContextInterface, account string, payload []byte, signature string, nonce int) error {
+	args := [][]byte{[]byte(proto.FcnCoinsUseNonce), []byte(account), payload, []byte(signature), []byte(strconv.Itoa(nonce))}
+	response := ctx.GetStub().InvokeChaincode(proto.ChaincodeNameCoins, args, proto.ChannelID)
+	if response.Status != shim.OK {
+		log.Printf("[ERROR]-[UseNonceHelper] use nonce failed, err: %v", response.Message)
+		return fmt.Errorf("[UseNonceHelper] use nonce failed, err: %v", response.Message)
+	}
+
+	return nil
+}
+
+/*
+	VerifySignedPayloadHelper: 解析并校验代理调用的签名数据
+	校验合约域名、通道、有效期和签名，并消耗签名者的序号
+	payload: JSON编码的proto.SignedPayload
+	signature: 对payload原始字节的签名
+*/
+func VerifySignedPayloadHelper(ctx contractapi.TransactionContextInterface, payload string, signature string) (*proto.SignedPayload, error) {
+	signed := new(proto.SignedPayload)
+	if err := json.Unmarshal([]byte(payload), signed); err != nil {
+		return nil, fmt.Errorf("[VerifySignedPayloadHelper] json unmarshal payload failed, err: %v", err)
+	}
+	if signed.Signer == "" {
+		return nil, fmt.Errorf("[VerifySignedPayloadHelper] signer is empty")
+	}
+
+	// 合约域名和通道必须与当前合约一致，防止签名在其他合约重放
+	if signed.Domain != proto.SignedDomain {
+		return nil, fmt.Errorf("[VerifySignedPayloadHelper] domain (%s) does not match (%s)", signed.Domain, proto.SignedDomain)
+	}
+	if channel := ctx.GetStub().GetChannelID(); signed.Channel != channel {
+		return nil, fmt.Errorf("[VerifySignedPayloadHelper] channel (%s) does not match (%s)", signed.Channel, channel)
+	}
+
+	// 校验签名有效期
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("[VerifySignedPayloadHelper] failed to get transaction timestamp: %v", err)
+	}
+	if txTimestamp.GetSeconds() > signed.Deadline {
+		return nil, fmt.Errorf("[VerifySignedPayloadHelper] signature expired at (%d), transaction time (%d)", signed.Deadline, txTimestamp.GetSeconds())
+	}
+
+	// 验证签名并消耗序号，防止重放
+	if err = UseNonceHelper(ctx, signed.Signer, []byte(payload), signature, signed.Nonce); err != nil {
+		return nil, err
+	}
+
+	return signed, nil
+}
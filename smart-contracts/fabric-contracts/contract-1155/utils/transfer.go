@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"contract-1155/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	ReadTransferRuleHelper 查询批次的转让限制, 没有设置时为自由转让
+*/
+func ReadTransferRuleHelper(ctx contractapi.TransactionContextInterface, batchId string) (*proto.TransferRule, error) {
+	rule := &proto.TransferRule{BatchID: batchId, Mode: proto.TransferModeFree, Allowlist: make([]string, 0)}
+
+	ruleKey, err := ctx.GetStub().CreateCompositeKey(proto.TransferRulePrefix, []string{batchId})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadTransferRuleHelper] failed to create the composite key for prefix %s: %v", proto.TransferRulePrefix, err)
+	}
+	ruleBytes, err := ctx.GetStub().GetState(ruleKey)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadTransferRuleHelper] failed to read transfer rule of batch %s: %v", batchId, err)
+	}
+	if ruleBytes == nil {
+		return rule, nil
+	}
+	if err = json.Unmarshal(ruleBytes, rule); err != nil {
+		return nil, fmt.Errorf("[ReadTransferRuleHelper] json unmarshal transfer rule failed, err: %v", err)
+	}
+
+	return rule, nil
+}
+
+/*
+	TransferableHelper 判断在 now 时刻批次是否可以转让, allowlist 方式只要有白名单账户即可转让
+*/
+func TransferableHelper(rule *proto.TransferRule, now int64) bool {
+	switch rule.Mode {
+	case proto.TransferModeSoulbound:
+		return false
+	case proto.TransferModeTimelock:
+		return now >= rule.Until
+	case proto.TransferModeAllowlist:
+		return len(rule.Allowlist) > 0
+	}
+
+	return true
+}
+
+/*
+	CheckTransferRuleHelper 校验批次是否允许从 sender 转给 recipient
+*/
+func CheckTransferRuleHelper(ctx contractapi.TransactionContextInterface, sender, recipient, batchId string) error {
+	rule, err := ReadTransferRuleHelper(ctx, batchId)
+	if err != nil {
+		return err
+	}
+	if rule.Mode == proto.TransferModeFree || sender == rule.Issuer {
+		return nil
+	}
+
+	switch rule.Mode {
+	case proto.TransferModeSoulbound:
+		return fmt.Errorf("[CheckTransferRuleHelper] batch (%s) is soulbound and cannot be transferred", batchId)
+	case proto.TransferModeTimelock:
+		txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+		if err != nil {
+			return fmt.Errorf("[CheckTransferRuleHelper] failed to get transaction timestamp: %v", err)
+		}
+		if txTimestamp.GetSeconds() < rule.Until {
+			return fmt.Errorf("[CheckTransferRuleHelper] batch (%s) is locked until (%d)", batchId, rule.Until)
+		}
+	case proto.TransferModeAllowlist:
+		for _, account := range rule.Allowlist {
+			if account == recipient {
+				return nil
+			}
+		}
+		return fmt.Errorf("[CheckTransferRuleHelper] batch (%s) cannot be transferred to (%s) which is not on the allowlist", batchId, recipient)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package contract
+
+import (
+	"contract-20/proto"
+	"contract-20/utils"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+)
+
+// InitAdmin 将调用者设为第一个管理员，只能由配置的管理组织的管理员证书调用一次
+func (s *SmartContract) InitAdmin(ctx contractapi.TransactionContextInterface) error {
+
+	// 只有管理组织的管理员证书可以初始化
+	if err := utils.AuthorizeAdminMSPHelper(ctx); err != nil {
+		return fmt.Errorf("[InitAdmin] %v", err)
+	}
+
+	// 只能初始化一次
+	initBytes, err := ctx.GetStub().GetState(proto.AdminInitKey)
+	if err != nil {
+		return fmt.Errorf("[InitAdmin] failed to read from world state: %v", err)
+	}
+	if initBytes != nil {
+		return fmt.Errorf("[InitAdmin] admin is already initialized")
+	}
+
+	// 获取用户客户端信息ID
+	operator, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[InitAdmin] failed to get client id: %v", err)
+	}
+
+	if err = utils.PutAdminHelper(ctx, operator, true, operator); err != nil {
+		return fmt.Errorf("[InitAdmin] %v", err)
+	}
+	if err = ctx.GetStub().PutState(proto.AdminInitKey, []byte{'0'}); err != nil {
+		return fmt.Errorf("[InitAdmin] failed to put to world state: %v", err)
+	}
+
+	log.Printf("[InitAdmin] client (%s) initialized as admin", operator)
+
+	return nil
+}
+
+// SetAdmin 设置或撤销管理员，只有管理员可以调用，管理员不能撤销自己
+func (s *SmartContract) SetAdmin(ctx contractapi.TransactionContextInterface, account string, enabled bool) error {
+	if account == "" {
+		return fmt.Errorf("[SetAdmin] account cannot be empty")
+	}
+
+	// 权限验证
+	operator, err := utils.AuthorizeAdminHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[SetAdmin] %v", err)
+	}
+	if operator == account && !enabled {
+		return fmt.Errorf("[SetAdmin] admin cannot revoke itself")
+	}
+
+	if err = utils.PutAdminHelper(ctx, account, enabled, operator); err != nil {
+		return fmt.Errorf("[SetAdmin] %v", err)
+	}
+
+	return nil
+}
+
+// IsAdmin 查询账户是否为管理员
+func (s *SmartContract) IsAdmin(ctx contractapi.TransactionContextInterface, account string) (bool, error) {
+	isAdmin, err := utils.IsAdminHelper(ctx, account)
+	if err != nil {
+		return false, fmt.Errorf("[IsAdmin] %v", err)
+	}
+
+	return isAdmin, nil
+}
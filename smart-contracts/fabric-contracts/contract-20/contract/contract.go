@@ -31,6 +31,11 @@ func (s *SmartContract) Decimals(ctx contractapi.TransactionContextInterface) (u
 
 // Mint 创建新的币并发放到账户中
 func (s *SmartContract) Mint(ctx contractapi.TransactionContextInterface, amount int) error {
+	return _mint(ctx, proto.PrimaryCurrency, amount)
+}
+
+// _mint 创建指定币种的新币并发放到客户端账户中
+func _mint(ctx contractapi.TransactionContextInterface, currency string, amount int) error {
 	// 参数校验
 	if amount <= 0 {
 		return fmt.Errorf("[Mint] mint amount must be a positive integer")
@@ -44,13 +49,17 @@ func (s *SmartContract) Mint(ctx contractapi.TransactionContextInterface, amount
 	}
 
 	// 获取用户客户端身份ID
-	minter, err := ctx.GetClientIdentity().GetID()
+	minter, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return fmt.Errorf("[Mint] failed to get client id: %v", err)
 	}
 
 	// 获取该账户信息
-	currentBalanceBytes, err := ctx.GetStub().GetState(minter)
+	balanceKey, err := utils.BalanceKeyHelper(ctx, currency, minter)
+	if err != nil {
+		return fmt.Errorf("[Mint] %v", err)
+	}
+	currentBalanceBytes, err := ctx.GetStub().GetState(balanceKey)
 	if err != nil {
 		return fmt.Errorf("[Mint] failed to read minter account %s from world state: %v", minter, err)
 	}
@@ -69,12 +78,16 @@ func (s *SmartContract) Mint(ctx contractapi.TransactionContextInterface, amount
 	updatedBalance := currentBalance + amount
 
 	// 将新的余额更新到账户信息中
-	if err = ctx.GetStub().PutState(minter, []byte(strconv.Itoa(updatedBalance))); err != nil {
+	if err = ctx.GetStub().PutState(balanceKey, []byte(strconv.Itoa(updatedBalance))); err != nil {
 		return fmt.Errorf("[Mint] failed to put state: %v", err)
 	}
 
 	// 获取目前币的数量
-	totalSupplyBytes, err := ctx.GetStub().GetState(proto.TotalSupplyKey)
+	totalSupplyKey, err := utils.TotalSupplyKeyHelper(ctx, currency)
+	if err != nil {
+		return fmt.Errorf("[Mint] %v", err)
+	}
+	totalSupplyBytes, err := ctx.GetStub().GetState(totalSupplyKey)
 	if err != nil {
 		return fmt.Errorf("[Mint] failed to retrieve total token supply: %v", err)
 	}
@@ -92,15 +105,16 @@ func (s *SmartContract) Mint(ctx contractapi.TransactionContextInterface, amount
 	totalSupply += amount
 
 	// 更新总数量
-	if err = ctx.GetStub().PutState(proto.TotalSupplyKey, []byte(strconv.Itoa(totalSupply))); err != nil {
+	if err = ctx.GetStub().PutState(totalSupplyKey, []byte(strconv.Itoa(totalSupply))); err != nil {
 		return err
 	}
 
 	// 事件触发
 	transferEvent := proto.Event{
-		From:   proto.EmptyAccount,
-		To:     minter,
-		Amount: amount,
+		Currency: currency,
+		From:     proto.EmptyAccount,
+		To:       minter,
+		Amount:   amount,
 	}
 	transferEventJSON, err := json.Marshal(transferEvent)
 	if err != nil {
@@ -118,6 +132,11 @@ func (s *SmartContract) Mint(ctx contractapi.TransactionContextInterface, amount
 
 // Burn 销毁账户中的代币
 func (s *SmartContract) Burn(ctx contractapi.TransactionContextInterface, amount int) error {
+	return _burn(ctx, proto.PrimaryCurrency, amount)
+}
+
+// _burn 销毁客户端账户中指定币种的代币
+func _burn(ctx contractapi.TransactionContextInterface, currency string, amount int) error {
 	// 校验参数
 	if amount <= 0 {
 		return fmt.Errorf("[Burn] burn amount must be a positive integer")
@@ -131,13 +150,17 @@ func (s *SmartContract) Burn(ctx contractapi.TransactionContextInterface, amount
 	}
 
 	// 获取用户客户端身份ID
-	minter, err := ctx.GetClientIdentity().GetID()
+	minter, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return fmt.Errorf("[Burn] failed to get client id: %v", err)
 	}
 
 	// 获取该用户的账户信息
-	currentBalanceBytes, err := ctx.GetStub().GetState(minter)
+	balanceKey, err := utils.BalanceKeyHelper(ctx, currency, minter)
+	if err != nil {
+		return fmt.Errorf("[Burn] %v", err)
+	}
+	currentBalanceBytes, err := ctx.GetStub().GetState(balanceKey)
 	if err != nil {
 		return fmt.Errorf("[Burn] failed to read minter account %s from world state: %v", minter, err)
 	}
@@ -151,16 +174,32 @@ func (s *SmartContract) Burn(ctx contractapi.TransactionContextInterface, amount
 
 	currentBalance, _ = strconv.Atoi(string(currentBalanceBytes))
 
+	// 锁仓和冻结中的部分不能销毁, 锁仓和冻结仅适用于主币种
+	reservedBalance := 0
+	if currency == proto.PrimaryCurrency {
+		reservedBalance, err = utils.ReservedBalanceHelper(ctx, minter)
+		if err != nil {
+			return fmt.Errorf("[Burn] failed to read reserved balance: %v", err)
+		}
+	}
+	if currentBalance-reservedBalance < amount {
+		return fmt.Errorf("[Burn] available balance (%d) not enough to burn (%d)", currentBalance-reservedBalance, amount)
+	}
+
 	// 计算出销毁后的余额
 	updatedBalance := currentBalance - amount
 
 	// 更新账户余额
-	if err = ctx.GetStub().PutState(minter, []byte(strconv.Itoa(updatedBalance))); err != nil {
+	if err = ctx.GetStub().PutState(balanceKey, []byte(strconv.Itoa(updatedBalance))); err != nil {
 		return fmt.Errorf("[Burn] put state update balance failed, err: %v", err)
 	}
 
 	// 获取代币总量
-	totalSupplyBytes, err := ctx.GetStub().GetState(proto.TotalSupplyKey)
+	totalSupplyKey, err := utils.TotalSupplyKeyHelper(ctx, currency)
+	if err != nil {
+		return fmt.Errorf("[Burn] %v", err)
+	}
+	totalSupplyBytes, err := ctx.GetStub().GetState(totalSupplyKey)
 	if err != nil {
 		return fmt.Errorf("[Burn] failed to retrieve total token supply: %v", err)
 	}
@@ -172,15 +211,16 @@ func (s *SmartContract) Burn(ctx contractapi.TransactionContextInterface, amount
 	totalSupply, _ := strconv.Atoi(string(totalSupplyBytes))
 	totalSupply -= amount
 	// 更新代币总量
-	if err = ctx.GetStub().PutState(proto.TotalSupplyKey, []byte(strconv.Itoa(totalSupply))); err != nil {
+	if err = ctx.GetStub().PutState(totalSupplyKey, []byte(strconv.Itoa(totalSupply))); err != nil {
 		return fmt.Errorf("[Burn] update total supply failed, err: %v", err)
 	}
 
 	// 事件触发
 	transferEvent := proto.Event{
-		From:   minter,
-		To:     proto.EmptyAccount,
-		Amount: amount,
+		Currency: currency,
+		From:     minter,
+		To:       proto.EmptyAccount,
+		Amount:   amount,
 	}
 	transferEventJSON, err := json.Marshal(transferEvent)
 	if err != nil {
@@ -201,30 +241,37 @@ func (s *SmartContract) Burn(ctx contractapi.TransactionContextInterface, amount
 func (s *SmartContract) Transfer(ctx contractapi.TransactionContextInterface, recipient string, amount int) error {
 
 	// 获取用户客户端信息ID
-	clientID, err := ctx.GetClientIdentity().GetID()
+	clientID, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return fmt.Errorf("[Transfer] failed to get client id: %v", err)
 	}
 
+	return _transfer(ctx, proto.PrimaryCurrency, clientID, recipient, amount)
+}
+
+// _transfer 从sender账户转移资产到另一个账户
+func _transfer(ctx contractapi.TransactionContextInterface, currency string, clientID string, recipient string, amount int) error {
+
 	// 资产转移
-	err = utils.TransferHelper(ctx, clientID, []string{recipient}, []int{amount})
+	err := utils.TransferCurrencyHelper(ctx, currency, clientID, []string{recipient}, []int{amount})
 	if err != nil {
-		return fmt.Errorf("[Transfer] failed to transfer: %v", err)
+		return fmt.Errorf("[_transfer] failed to transfer: %v", err)
 	}
 
 	// 事件触发
 	transferEvent := proto.Event{
-		From:   clientID,
-		To:     recipient,
-		Amount: amount,
+		Currency: currency,
+		From:     clientID,
+		To:       recipient,
+		Amount:   amount,
 	}
 	transferEventJSON, err := json.Marshal(transferEvent)
 	if err != nil {
-		return fmt.Errorf("[Transfer] failed to obtain JSON encoding: %v", err)
+		return fmt.Errorf("[_transfer] failed to obtain JSON encoding: %v", err)
 	}
 
 	if err = ctx.GetStub().SetEvent("Transfer", transferEventJSON); err != nil {
-		return fmt.Errorf("[Transfer] failed to set event: %v", err)
+		return fmt.Errorf("[_transfer] failed to set event: %v", err)
 	}
 
 	return nil
@@ -234,21 +281,27 @@ func (s *SmartContract) Transfer(ctx contractapi.TransactionContextInterface, re
 func (s *SmartContract) TransferFrom(ctx contractapi.TransactionContextInterface, from string, to string, amount int) error {
 
 	// 获取操作的用户客户端信息ID
-	spender, err := ctx.GetClientIdentity().GetID()
+	spender, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return fmt.Errorf("[TransferFrom] failed to get client id: %v", err)
 	}
 
+	return _transferFrom(ctx, proto.PrimaryCurrency, spender, from, to, amount)
+}
+
+// _transferFrom spender从一个账户转移已授权的资产到另一个账户
+func _transferFrom(ctx contractapi.TransactionContextInterface, currency string, spender string, from string, to string, amount int) error {
+
 	// 拼接授权的key
-	allowanceKey, err := ctx.GetStub().CreateCompositeKey(proto.AllowancePrefix, []string{from, spender})
+	allowanceKey, err := utils.AllowanceKeyHelper(ctx, currency, from, spender)
 	if err != nil {
-		return fmt.Errorf("[TransferFrom] failed to create the composite key for prefix (%s): %v", proto.AllowancePrefix, err)
+		return fmt.Errorf("[_transferFrom] %v", err)
 	}
 
 	// 获取授权额度信息
 	currentAllowanceBytes, err := ctx.GetStub().GetState(allowanceKey)
 	if err != nil {
-		return fmt.Errorf("[TransferFrom] failed to retrieve the allowance for (%s) from world state: %v", allowanceKey, err)
+		return fmt.Errorf("[_transferFrom] failed to retrieve the allowance for (%s) from world state: %v", allowanceKey, err)
 	}
 
 	var currentAllowance int
@@ -256,37 +309,38 @@ func (s *SmartContract) TransferFrom(ctx contractapi.TransactionContextInterface
 
 	// 授权额度不足
 	if currentAllowance < amount {
-		return fmt.Errorf("[TransferFrom] spender does not have enough allowance for transfer")
+		return fmt.Errorf("[_transferFrom] spender does not have enough allowance for transfer")
 	}
 
 	// 转移资产
-	err = utils.TransferHelper(ctx, from, []string{to}, []int{amount})
+	err = utils.TransferCurrencyHelper(ctx, currency, from, []string{to}, []int{amount})
 	if err != nil {
-		return fmt.Errorf("[TransferFrom] failed to transfer, err: %v", err)
+		return fmt.Errorf("[_transferFrom] failed to transfer, err: %v", err)
 	}
 
 	// 更新授权额度信息
 	updatedAllowance := currentAllowance - amount
 	if err = ctx.GetStub().PutState(allowanceKey, []byte(strconv.Itoa(updatedAllowance))); err != nil {
-		return fmt.Errorf("[TransferFrom] failed to update allowance, err: %v", err)
+		return fmt.Errorf("[_transferFrom] failed to update allowance, err: %v", err)
 	}
 
 	// 事件触发
 	transferEvent := proto.Event{
-		From:   from,
-		To:     to,
-		Amount: amount,
+		Currency: currency,
+		From:     from,
+		To:       to,
+		Amount:   amount,
 	}
 	transferEventJSON, err := json.Marshal(transferEvent)
 	if err != nil {
-		return fmt.Errorf("[TransferFrom] failed to obtain JSON encoding: %v", err)
+		return fmt.Errorf("[_transferFrom] failed to obtain JSON encoding: %v", err)
 	}
 
 	if err = ctx.GetStub().SetEvent("Transfer", transferEventJSON); err != nil {
-		return fmt.Errorf("[TransferFrom] failed to set event: %v", err)
+		return fmt.Errorf("[_transferFrom] failed to set event: %v", err)
 	}
 
-	log.Printf("[TransferFrom] spender (%s) allowance updated from %d to %d", spender, currentAllowance, updatedAllowance)
+	log.Printf("[_transferFrom] spender (%s) allowance updated from %d to %d", spender, currentAllowance, updatedAllowance)
 
 	return nil
 }
@@ -295,30 +349,37 @@ func (s *SmartContract) TransferFrom(ctx contractapi.TransactionContextInterface
 func (s *SmartContract) TransferBatch(ctx contractapi.TransactionContextInterface, recipients []string, amounts []int) error {
 
 	// 获取用户客户端信息ID
-	clientID, err := ctx.GetClientIdentity().GetID()
+	clientID, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return fmt.Errorf("[TransferBatch] failed to get client id: %v", err)
 	}
 
+	return _transferBatch(ctx, proto.PrimaryCurrency, clientID, recipients, amounts)
+}
+
+// _transferBatch 从sender账户批量转移资产到其他账户
+func _transferBatch(ctx contractapi.TransactionContextInterface, currency string, clientID string, recipients []string, amounts []int) error {
+
 	// 资产转移
-	err = utils.TransferHelper(ctx, clientID, recipients, amounts)
+	err := utils.TransferCurrencyHelper(ctx, currency, clientID, recipients, amounts)
 	if err != nil {
-		return fmt.Errorf("[TransferBatch] failed to transfer: %v", err)
+		return fmt.Errorf("[_transferBatch] failed to transfer: %v", err)
 	}
 
 	// 事件触发
 	transferEvent := proto.EventBatch{
-		From:    clientID,
-		Tos:     recipients,
-		Amounts: amounts,
+		Currency: currency,
+		From:     clientID,
+		Tos:      recipients,
+		Amounts:  amounts,
 	}
 	transferEventJSON, err := json.Marshal(transferEvent)
 	if err != nil {
-		return fmt.Errorf("[TransferBatch] failed to obtain JSON encoding: %v", err)
+		return fmt.Errorf("[_transferBatch] failed to obtain JSON encoding: %v", err)
 	}
 
 	if err = ctx.GetStub().SetEvent("TransferBatch", transferEventJSON); err != nil {
-		return fmt.Errorf("[TransferBatch] failed to set event: %v", err)
+		return fmt.Errorf("[_transferBatch] failed to set event: %v", err)
 	}
 
 	return nil
@@ -326,15 +387,24 @@ func (s *SmartContract) TransferBatch(ctx contractapi.TransactionContextInterfac
 
 // ClientAccountBalance 查询客户端账户的余额
 func (s *SmartContract) ClientAccountBalance(ctx contractapi.TransactionContextInterface) (int, error) {
+	return _clientAccountBalance(ctx, proto.PrimaryCurrency)
+}
+
+// _clientAccountBalance 查询客户端账户指定币种的余额
+func _clientAccountBalance(ctx contractapi.TransactionContextInterface, currency string) (int, error) {
 
 	// 获取客户端用户信息的ID
-	clientID, err := ctx.GetClientIdentity().GetID()
+	clientID, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("[ClientAccountBalance] failed to get client id: %v", err)
 	}
 
 	// 根据客户端ID查询用户余额信息
-	balanceBytes, err := ctx.GetStub().GetState(clientID)
+	balanceKey, err := utils.BalanceKeyHelper(ctx, currency, clientID)
+	if err != nil {
+		return 0, fmt.Errorf("[ClientAccountBalance] %v", err)
+	}
+	balanceBytes, err := ctx.GetStub().GetState(balanceKey)
 	if err != nil {
 		return 0, fmt.Errorf("[ClientAccountBalance] failed to read from world state: %v", err)
 	}
@@ -350,8 +420,17 @@ func (s *SmartContract) ClientAccountBalance(ctx contractapi.TransactionContextI
 
 // BalanceOf 查询指定账户的余额
 func (s *SmartContract) BalanceOf(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	return _balanceOf(ctx, proto.PrimaryCurrency, account)
+}
+
+// _balanceOf 查询指定账户指定币种的余额
+func _balanceOf(ctx contractapi.TransactionContextInterface, currency string, account string) (int, error) {
 	// 根据账户查询余额信息
-	balanceBytes, err := ctx.GetStub().GetState(account)
+	balanceKey, err := utils.BalanceKeyHelper(ctx, currency, account)
+	if err != nil {
+		return 0, err
+	}
+	balanceBytes, err := ctx.GetStub().GetState(balanceKey)
 	if err != nil {
 		return 0, fmt.Errorf("failed to read from world state: %v", err)
 	}
@@ -366,8 +445,17 @@ func (s *SmartContract) BalanceOf(ctx contractapi.TransactionContextInterface, a
 
 // TotalSupply 查询已经发行的代币总量
 func (s *SmartContract) TotalSupply(ctx contractapi.TransactionContextInterface) (int, error) {
+	return _totalSupply(ctx, proto.PrimaryCurrency)
+}
+
+// _totalSupply 查询指定币种已经发行的代币总量
+func _totalSupply(ctx contractapi.TransactionContextInterface, currency string) (int, error) {
 	// 根据代币总量的key查询
-	totalSupplyBytes, err := ctx.GetStub().GetState(proto.TotalSupplyKey)
+	totalSupplyKey, err := utils.TotalSupplyKeyHelper(ctx, currency)
+	if err != nil {
+		return 0, fmt.Errorf("[TotalSupply] %v", err)
+	}
+	totalSupplyBytes, err := ctx.GetStub().GetState(totalSupplyKey)
 	if err != nil {
 		return 0, fmt.Errorf("[TotalSupply] failed to retrieve total token supply: %v", err)
 	}
@@ -381,37 +469,37 @@ func (s *SmartContract) TotalSupply(ctx contractapi.TransactionContextInterface)
 		totalSupply, _ = strconv.Atoi(string(totalSupplyBytes))
 	}
 
-	log.Printf("[TotalSupply] TotalSupply: (%d) tokens", totalSupply)
+	log.Printf("[TotalSupply] TotalSupply of (%s): (%d) tokens", currency, totalSupply)
 
 	return totalSupply, nil
 }
 
 // Approve 授权账户可以从客户端账户转移的资产
 func (s *SmartContract) Approve(ctx contractapi.TransactionContextInterface, spender string, value int) error {
+	return _approve(ctx, proto.PrimaryCurrency, spender, value)
+}
+
+// _approve 授权账户可以从客户端账户转移的指定币种资产
+func _approve(ctx contractapi.TransactionContextInterface, currency string, spender string, value int) error {
 
 	// Get ID of submitting client identity
-	owner, err := ctx.GetClientIdentity().GetID()
+	owner, err := utils.ClientAccountHelper(ctx)
 	if err != nil {
 		return fmt.Errorf("[Approve] failed to get client id: %v", err)
 	}
 
-	// 拼接授权的key
-	allowanceKey, err := ctx.GetStub().CreateCompositeKey(proto.AllowancePrefix, []string{owner, spender})
-	if err != nil {
-		return fmt.Errorf("[Approve] failed to create the composite key for prefix %s: %v", proto.AllowancePrefix, err)
-	}
-
 	// 更新授权和对应的额度
-	err = ctx.GetStub().PutState(allowanceKey, []byte(strconv.Itoa(value)))
+	err = utils.ApproveCurrencyHelper(ctx, currency, owner, spender, value)
 	if err != nil {
-		return fmt.Errorf("[Approve] failed to update state of smart contract for key %s: %v", allowanceKey, err)
+		return fmt.Errorf("[Approve] failed to approve: %v", err)
 	}
 
 	// 事件触发
 	approvalEvent := proto.Event{
-		From:   owner,
-		To:     spender,
-		Amount: value,
+		Currency: currency,
+		From:     owner,
+		To:       spender,
+		Amount:   value,
 	}
 	approvalEventJSON, err := json.Marshal(approvalEvent)
 	if err != nil {
@@ -429,11 +517,16 @@ func (s *SmartContract) Approve(ctx contractapi.TransactionContextInterface, spe
 
 // Allowance 查询owner授权给spender的额度
 func (s *SmartContract) Allowance(ctx contractapi.TransactionContextInterface, owner string, spender string) (int, error) {
+	return _allowance(ctx, proto.PrimaryCurrency, owner, spender)
+}
+
+// _allowance 查询owner授权给spender的指定币种额度
+func _allowance(ctx contractapi.TransactionContextInterface, currency string, owner string, spender string) (int, error) {
 
 	// 拼接授权的key
-	allowanceKey, err := ctx.GetStub().CreateCompositeKey(proto.AllowancePrefix, []string{owner, spender})
+	allowanceKey, err := utils.AllowanceKeyHelper(ctx, currency, owner, spender)
 	if err != nil {
-		return 0, fmt.Errorf("[Allowance] failed to create the composite key for prefix %s: %v", proto.AllowancePrefix, err)
+		return 0, fmt.Errorf("[Allowance] %v", err)
 	}
 
 	// 根据授权的key查询到对应的额度
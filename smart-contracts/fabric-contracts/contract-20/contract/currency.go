@@ -0,0 +1,232 @@
+package contract
+
+import (
+	"contract-20/proto"
+	"contract-20/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+)
+
+// RegisterCurrency 管理员登记新的币种并成为发行方，登记后即可使用带 Currency 后缀的方法发行和转移
+func (s *SmartContract) RegisterCurrency(ctx contractapi.TransactionContextInterface, code string, name string, symbol string, decimals uint8, metadata string) error {
+	// 参数校验
+	if code == "" || name == "" || symbol == "" {
+		return fmt.Errorf("[RegisterCurrency] code, name and symbol must not be empty")
+	}
+
+	// 只有管理员可以登记币种，登记的管理员即币种发行方
+	issuer, err := utils.AuthorizeAdminHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[RegisterCurrency] %v", err)
+	}
+
+	// 币种不能重复登记
+	existing, err := utils.ReadCurrencyHelper(ctx, code)
+	if err != nil {
+		return fmt.Errorf("[RegisterCurrency] failed to read currency: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("[RegisterCurrency] currency (%s) already exists", code)
+	}
+
+	// 保存币种信息
+	currency := proto.Currency{
+		Code:     code,
+		Name:     name,
+		Symbol:   symbol,
+		Decimals: decimals,
+		Metadata: metadata,
+		Issuer:   issuer,
+	}
+	currencyJSON, err := json.Marshal(currency)
+	if err != nil {
+		return fmt.Errorf("[RegisterCurrency] failed to obtain JSON encoding: %v", err)
+	}
+	currencyKey, err := ctx.GetStub().CreateCompositeKey(proto.CurrencyPrefix, []string{code})
+	if err != nil {
+		return fmt.Errorf("[RegisterCurrency] failed to create the composite key for prefix (%s): %v", proto.CurrencyPrefix, err)
+	}
+	if err = ctx.GetStub().PutState(currencyKey, currencyJSON); err != nil {
+		return fmt.Errorf("[RegisterCurrency] failed to put state: %v", err)
+	}
+
+	// 事件触发
+	if err = ctx.GetStub().SetEvent("CurrencyRegistered", currencyJSON); err != nil {
+		return fmt.Errorf("[RegisterCurrency] failed to set event: %v", err)
+	}
+
+	log.Printf("[RegisterCurrency] issuer (%s) registered currency (%s)", issuer, code)
+
+	return nil
+}
+
+// CurrencyInfo 查询币种信息
+func (s *SmartContract) CurrencyInfo(ctx contractapi.TransactionContextInterface, currency string) (*proto.Currency, error) {
+	info, err := utils.ReadCurrencyHelper(ctx, currency)
+	if err != nil {
+		return nil, fmt.Errorf("[CurrencyInfo] %v", err)
+	}
+	if info == nil {
+		return nil, fmt.Errorf("[CurrencyInfo] currency (%s) is not registered", currency)
+	}
+
+	return info, nil
+}
+
+// Currencies 查询全部币种，主币种在第一位
+func (s *SmartContract) Currencies(ctx contractapi.TransactionContextInterface) ([]*proto.Currency, error) {
+	primary, err := utils.ReadCurrencyHelper(ctx, proto.PrimaryCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("[Currencies] %v", err)
+	}
+	currencies := []*proto.Currency{primary}
+
+	currencyIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(proto.CurrencyPrefix, []string{})
+	if err != nil {
+		return nil, fmt.Errorf("[Currencies] failed to get state for prefix %v: %v", proto.CurrencyPrefix, err)
+	}
+	defer currencyIterator.Close()
+
+	for currencyIterator.HasNext() {
+		queryResponse, err := currencyIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("[Currencies] failed to get the next state for prefix %v: %v", proto.CurrencyPrefix, err)
+		}
+
+		currency := new(proto.Currency)
+		if err = json.Unmarshal(queryResponse.Value, currency); err != nil {
+			return nil, fmt.Errorf("[Currencies] json unmarshal currency failed, err: %v", err)
+		}
+		currencies = append(currencies, currency)
+	}
+
+	return currencies, nil
+}
+
+// MintCurrency 币种发行方创建指定币种的新币并发放到自己的账户中
+func (s *SmartContract) MintCurrency(ctx contractapi.TransactionContextInterface, currency string, amount int) error {
+	info, err := utils.ReadCurrencyHelper(ctx, currency)
+	if err != nil {
+		return fmt.Errorf("[Mint] %v", err)
+	}
+	if info == nil {
+		return fmt.Errorf("[Mint] currency (%s) is not registered", currency)
+	}
+
+	// 主币种与 Mint 相同，其他币种只有登记的发行方可以发行
+	if currency != proto.PrimaryCurrency {
+		minter, err := utils.ClientAccountHelper(ctx)
+		if err != nil {
+			return fmt.Errorf("[Mint] failed to get client id: %v", err)
+		}
+		if minter != info.Issuer {
+			return fmt.Errorf("[Mint] client account (%s) is not the issuer of currency (%s)", minter, currency)
+		}
+	}
+
+	return _mint(ctx, currency, amount)
+}
+
+// BurnCurrency 销毁账户中指定币种的代币
+func (s *SmartContract) BurnCurrency(ctx contractapi.TransactionContextInterface, currency string, amount int) error {
+	if err := utils.CheckCurrencyHelper(ctx, currency); err != nil {
+		return fmt.Errorf("[Burn] %v", err)
+	}
+
+	return _burn(ctx, currency, amount)
+}
+
+// TransferCurrency 从客户端账户转移指定币种的资产到另一个账户
+func (s *SmartContract) TransferCurrency(ctx contractapi.TransactionContextInterface, currency string, recipient string, amount int) error {
+	if err := utils.CheckCurrencyHelper(ctx, currency); err != nil {
+		return fmt.Errorf("[Transfer] %v", err)
+	}
+
+	// 获取用户客户端信息ID
+	clientID, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[Transfer] failed to get client id: %v", err)
+	}
+
+	return _transfer(ctx, currency, clientID, recipient, amount)
+}
+
+// TransferFromCurrency 从一个账户转移已授权的指定币种资产到另一个账户
+func (s *SmartContract) TransferFromCurrency(ctx contractapi.TransactionContextInterface, currency string, from string, to string, amount int) error {
+	if err := utils.CheckCurrencyHelper(ctx, currency); err != nil {
+		return fmt.Errorf("[TransferFrom] %v", err)
+	}
+
+	// 获取操作的用户客户端信息ID
+	spender, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[TransferFrom] failed to get client id: %v", err)
+	}
+
+	return _transferFrom(ctx, currency, spender, from, to, amount)
+}
+
+// TransferBatchCurrency 从客户端账户批量转移指定币种的资产到其他账户
+func (s *SmartContract) TransferBatchCurrency(ctx contractapi.TransactionContextInterface, currency string, recipients []string, amounts []int) error {
+	if err := utils.CheckCurrencyHelper(ctx, currency); err != nil {
+		return fmt.Errorf("[TransferBatch] %v", err)
+	}
+	if len(recipients) != len(amounts) {
+		return fmt.Errorf("[TransferBatch] recipients and amounts must have the same length")
+	}
+
+	// 获取用户客户端信息ID
+	clientID, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[TransferBatch] failed to get client id: %v", err)
+	}
+
+	return _transferBatch(ctx, currency, clientID, recipients, amounts)
+}
+
+// ClientAccountBalanceCurrency 查询客户端账户指定币种的余额
+func (s *SmartContract) ClientAccountBalanceCurrency(ctx contractapi.TransactionContextInterface, currency string) (int, error) {
+	if err := utils.CheckCurrencyHelper(ctx, currency); err != nil {
+		return 0, fmt.Errorf("[ClientAccountBalance] %v", err)
+	}
+
+	return _clientAccountBalance(ctx, currency)
+}
+
+// BalanceOfCurrency 查询指定账户指定币种的余额
+func (s *SmartContract) BalanceOfCurrency(ctx contractapi.TransactionContextInterface, currency string, account string) (int, error) {
+	if err := utils.CheckCurrencyHelper(ctx, currency); err != nil {
+		return 0, fmt.Errorf("[BalanceOf] %v", err)
+	}
+
+	return _balanceOf(ctx, currency, account)
+}
+
+// TotalSupplyCurrency 查询指定币种已经发行的代币总量
+func (s *SmartContract) TotalSupplyCurrency(ctx contractapi.TransactionContextInterface, currency string) (int, error) {
+	if err := utils.CheckCurrencyHelper(ctx, currency); err != nil {
+		return 0, fmt.Errorf("[TotalSupply] %v", err)
+	}
+
+	return _totalSupply(ctx, currency)
+}
+
+// ApproveCurrency 授权账户可以从客户端账户转移的指定币种资产
+func (s *SmartContract) ApproveCurrency(ctx contractapi.TransactionContextInterface, currency string, spender string, value int) error {
+	if err := utils.CheckCurrencyHelper(ctx, currency); err != nil {
+		return fmt.Errorf("[Approve] %v", err)
+	}
+
+	return _approve(ctx, currency, spender, value)
+}
+
+// AllowanceCurrency 查询owner授权给spender的指定币种额度
+func (s *SmartContract) AllowanceCurrency(ctx contractapi.TransactionContextInterface, currency string, owner string, spender string) (int, error) {
+	if err := utils.CheckCurrencyHelper(ctx, currency); err != nil {
+		return 0, fmt.Errorf("[Allowance] %v", err)
+	}
+
+	return _allowance(ctx, currency, owner, spender)
+}
@@ -0,0 +1,384 @@
+package contract
+
+import (
+	"contract-20/proto"
+	"contract-20/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+	"strconv"
+)
+
+// Hold 冻结付款账户的资产等待公证人确认，冻结的资产不能转移，由公证人执行转给收款人或释放
+// 公证人可以是账户，也可以是登记的链码(由该链码通过 InvokeChaincode 执行或释放)
+// 调用者不是付款账户时需要付款账户的授权额度，expiry 为过期时间(Unix秒)，为0表示不过期
+func (s *SmartContract) Hold(ctx contractapi.TransactionContextInterface, holdID string, from string, to string, amount int, expiry int64, notary string) error {
+	// 校验参数
+	if holdID == "" {
+		return fmt.Errorf("[Hold] hold id must not be empty")
+	}
+	if amount <= 0 {
+		return fmt.Errorf("[Hold] hold amount must be a positive integer")
+	}
+	if to == "" || to == proto.EmptyAccount || notary == "" {
+		return fmt.Errorf("[Hold] recipient and notary must not be empty")
+	}
+	if from == to {
+		return fmt.Errorf("[Hold] cannot hold to and from same client account")
+	}
+
+	// 获取交易时间戳
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[Hold] failed to get transaction timestamp: %v", err)
+	}
+	if expiry != 0 && expiry <= txTimestamp.GetSeconds() {
+		return fmt.Errorf("[Hold] expiry (%d) must be later than transaction time (%d)", expiry, txTimestamp.GetSeconds())
+	}
+
+	// 冻结ID不能重复
+	existing, err := utils.ReadHoldHelper(ctx, holdID)
+	if err != nil {
+		return fmt.Errorf("[Hold] failed to read hold: %v", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("[Hold] hold (%s) already exists", holdID)
+	}
+
+	// 获取操作的用户客户端信息ID
+	issuer, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[Hold] failed to get client id: %v", err)
+	}
+
+	// 代替付款账户冻结时扣减授权额度
+	if issuer != from {
+		allowanceKey, err := ctx.GetStub().CreateCompositeKey(proto.AllowancePrefix, []string{from, issuer})
+		if err != nil {
+			return fmt.Errorf("[Hold] failed to create the composite key for prefix (%s): %v", proto.AllowancePrefix, err)
+		}
+		currentAllowanceBytes, err := ctx.GetStub().GetState(allowanceKey)
+		if err != nil {
+			return fmt.Errorf("[Hold] failed to retrieve the allowance for (%s) from world state: %v", allowanceKey, err)
+		}
+		currentAllowance, _ := strconv.Atoi(string(currentAllowanceBytes))
+		if currentAllowance < amount {
+			return fmt.Errorf("[Hold] issuer does not have enough allowance for hold")
+		}
+		if err = utils.ApproveHelper(ctx, from, issuer, currentAllowance-amount); err != nil {
+			return fmt.Errorf("[Hold] failed to update allowance: %v", err)
+		}
+	}
+
+	// 付款账户可用余额必须足够
+	balanceBytes, err := ctx.GetStub().GetState(from)
+	if err != nil {
+		return fmt.Errorf("[Hold] failed to read account %s from world state: %v", from, err)
+	}
+	balance, _ := strconv.Atoi(string(balanceBytes))
+	reserved, err := utils.ReservedBalanceHelper(ctx, from)
+	if err != nil {
+		return fmt.Errorf("[Hold] failed to read reserved balance: %v", err)
+	}
+	if balance-reserved < amount {
+		return fmt.Errorf("[Hold] client account (%s) have available balance(%d), need balance(%d), balance not enough", from, balance-reserved, amount)
+	}
+
+	// 保存冻结
+	hold := &proto.Hold{
+		ID:     holdID,
+		Issuer: issuer,
+		From:   from,
+		To:     to,
+		Notary: notary,
+		Amount: amount,
+		Expiry: expiry,
+		Status: proto.HoldStatusOrdered,
+	}
+	if err = utils.PutHoldHelper(ctx, hold); err != nil {
+		return fmt.Errorf("[Hold] failed to save hold: %v", err)
+	}
+
+	// 事件触发
+	if err = _setHoldEvent(ctx, "HoldCreated", hold); err != nil {
+		return fmt.Errorf("[Hold] %v", err)
+	}
+
+	log.Printf("[Hold] issuer (%s) held %d of account (%s) as (%s)", issuer, amount, from, holdID)
+
+	return nil
+}
+
+// ExecuteHold 公证人确认冻结，冻结的资产转给收款人，冻结过期后不能执行
+func (s *SmartContract) ExecuteHold(ctx contractapi.TransactionContextInterface, holdID string) error {
+
+	hold, err := _activeHold(ctx, holdID)
+	if err != nil {
+		return fmt.Errorf("[ExecuteHold] %v", err)
+	}
+	if hold == nil {
+		return fmt.Errorf("[ExecuteHold] hold (%s) is expired", holdID)
+	}
+
+	// 获取操作的用户客户端信息ID
+	operator, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[ExecuteHold] failed to get client id: %v", err)
+	}
+	if isNotary, err := utils.HoldNotaryHelper(ctx, hold, operator); err != nil {
+		return fmt.Errorf("[ExecuteHold] %v", err)
+	} else if !isNotary {
+		return fmt.Errorf("[ExecuteHold] only the notary can execute the hold")
+	}
+
+	// 冻结的资产已预留，直接转移
+	if err = utils.MoveBalanceHelper(ctx, proto.PrimaryCurrency, hold.From, []string{hold.To}, []int{hold.Amount}); err != nil {
+		return fmt.Errorf("[ExecuteHold] failed to transfer: %v", err)
+	}
+
+	hold.Status = proto.HoldStatusExecuted
+	if err = utils.PutHoldHelper(ctx, hold); err != nil {
+		return fmt.Errorf("[ExecuteHold] failed to update hold: %v", err)
+	}
+
+	// 事件触发
+	if err = _setHoldEvent(ctx, "HoldExecuted", hold); err != nil {
+		return fmt.Errorf("[ExecuteHold] %v", err)
+	}
+
+	log.Printf("[ExecuteHold] notary (%s) executed hold (%s)", operator, holdID)
+
+	return nil
+}
+
+// ExecuteHoldSplit 公证人确认冻结，冻结的资产按金额分别转给多个账户(如收款人、版税和手续费账户)
+// amounts 的总和不能超过冻结金额，剩余部分随冻结结束回到付款账户的可用余额(并退回发起者的授权额度)，冻结过期后不能执行
+func (s *SmartContract) ExecuteHoldSplit(ctx contractapi.TransactionContextInterface, holdID string, recipients []string, amounts []int) error {
+	// 校验参数
+	if len(recipients) == 0 || len(recipients) != len(amounts) {
+		return fmt.Errorf("[ExecuteHoldSplit] recipients and amounts must be non-empty and have the same length")
+	}
+
+	hold, err := _activeHold(ctx, holdID)
+	if err != nil {
+		return fmt.Errorf("[ExecuteHoldSplit] %v", err)
+	}
+	if hold == nil {
+		return fmt.Errorf("[ExecuteHoldSplit] hold (%s) is expired", holdID)
+	}
+
+	// 同一交易内读不到本交易的写入，接收账户不能重复，也不能是付款账户
+	total := 0
+	seen := make(map[string]bool)
+	for i, recipient := range recipients {
+		if recipient == "" || recipient == proto.EmptyAccount || recipient == hold.From {
+			return fmt.Errorf("[ExecuteHoldSplit] recipient must not be empty, the zero address or the payer")
+		}
+		if seen[recipient] {
+			return fmt.Errorf("[ExecuteHoldSplit] duplicate recipient (%s)", recipient)
+		}
+		if amounts[i] < 0 {
+			return fmt.Errorf("[ExecuteHoldSplit] amount must not be negative")
+		}
+		seen[recipient] = true
+		total += amounts[i]
+	}
+	if total > hold.Amount {
+		return fmt.Errorf("[ExecuteHoldSplit] amounts add up to %d, hold amount is only %d", total, hold.Amount)
+	}
+
+	// 获取操作的用户客户端信息ID
+	operator, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[ExecuteHoldSplit] failed to get client id: %v", err)
+	}
+	if isNotary, err := utils.HoldNotaryHelper(ctx, hold, operator); err != nil {
+		return fmt.Errorf("[ExecuteHoldSplit] %v", err)
+	} else if !isNotary {
+		return fmt.Errorf("[ExecuteHoldSplit] only the notary can execute the hold")
+	}
+
+	// 冻结的资产已预留，直接转移
+	if err = utils.MoveBalanceHelper(ctx, proto.PrimaryCurrency, hold.From, recipients, amounts); err != nil {
+		return fmt.Errorf("[ExecuteHoldSplit] failed to transfer: %v", err)
+	}
+
+	// 未支付的剩余部分退回发起者的授权额度
+	if err = utils.RestoreHoldAllowanceHelper(ctx, hold, hold.Amount-total); err != nil {
+		return fmt.Errorf("[ExecuteHoldSplit] failed to restore allowance: %v", err)
+	}
+
+	hold.Status = proto.HoldStatusExecuted
+	if err = utils.PutHoldHelper(ctx, hold); err != nil {
+		return fmt.Errorf("[ExecuteHoldSplit] failed to update hold: %v", err)
+	}
+
+	// 事件触发
+	if err = _setHoldEvent(ctx, "HoldExecuted", hold); err != nil {
+		return fmt.Errorf("[ExecuteHoldSplit] %v", err)
+	}
+
+	log.Printf("[ExecuteHoldSplit] notary (%s) executed hold (%s) to %d recipients", operator, holdID, len(recipients))
+
+	return nil
+}
+
+// SetNotaryChaincode 登记或取消可以担任冻结公证人的链码(如拍卖合约)
+func (s *SmartContract) SetNotaryChaincode(ctx contractapi.TransactionContextInterface, chaincode string, enabled bool) error {
+	// 校验参数
+	if chaincode == "" {
+		return fmt.Errorf("[SetNotaryChaincode] chaincode must not be empty")
+	}
+
+	// 只有管理员可以登记公证人链码
+	operator, err := utils.AuthorizeAdminHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[SetNotaryChaincode] %v", err)
+	}
+
+	notaryKey, err := ctx.GetStub().CreateCompositeKey(proto.NotaryPrefix, []string{chaincode})
+	if err != nil {
+		return fmt.Errorf("[SetNotaryChaincode] failed to create the composite key for prefix (%s): %v", proto.NotaryPrefix, err)
+	}
+	if enabled {
+		err = ctx.GetStub().PutState(notaryKey, []byte("1"))
+	} else {
+		err = ctx.GetStub().DelState(notaryKey)
+	}
+	if err != nil {
+		return fmt.Errorf("[SetNotaryChaincode] failed to update notary chaincode (%s): %v", chaincode, err)
+	}
+
+	log.Printf("[SetNotaryChaincode] operator (%s) set notary chaincode (%s) enabled: %v", operator, chaincode, enabled)
+
+	return nil
+}
+
+// IsNotaryChaincode 查询链码是否登记为可以担任公证人
+func (s *SmartContract) IsNotaryChaincode(ctx contractapi.TransactionContextInterface, chaincode string) (bool, error) {
+
+	return utils.IsNotaryChaincodeHelper(ctx, chaincode)
+}
+
+// ReleaseHold 释放冻结，资产回到付款账户的可用余额，代替付款账户发起的冻结同时退回扣减的授权额度
+// 公证人或收款人可随时释放，冻结过期后任何人都可以释放
+func (s *SmartContract) ReleaseHold(ctx contractapi.TransactionContextInterface, holdID string) error {
+
+	hold, err := utils.ReadHoldHelper(ctx, holdID)
+	if err != nil {
+		return fmt.Errorf("[ReleaseHold] failed to read hold: %v", err)
+	}
+	if hold == nil {
+		return fmt.Errorf("[ReleaseHold] hold (%s) does not exist", holdID)
+	}
+	if hold.Status != proto.HoldStatusOrdered {
+		return fmt.Errorf("[ReleaseHold] hold (%s) is already %s", holdID, hold.Status)
+	}
+
+	// 获取操作的用户客户端信息ID
+	operator, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[ReleaseHold] failed to get client id: %v", err)
+	}
+
+	// 获取交易时间戳
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[ReleaseHold] failed to get transaction timestamp: %v", err)
+	}
+
+	isNotary, err := utils.HoldNotaryHelper(ctx, hold, operator)
+	if err != nil {
+		return fmt.Errorf("[ReleaseHold] %v", err)
+	}
+
+	switch {
+	case !utils.HoldActiveHelper(hold, txTimestamp.GetSeconds()):
+		hold.Status = proto.HoldStatusReleasedOnExpiration
+	case isNotary:
+		hold.Status = proto.HoldStatusReleasedByNotary
+	case operator == hold.To:
+		hold.Status = proto.HoldStatusReleasedByPayee
+	default:
+		return fmt.Errorf("[ReleaseHold] only the notary or the payee can release the hold before expiry")
+	}
+
+	// 冻结的金额退回发起者的授权额度
+	if err = utils.RestoreHoldAllowanceHelper(ctx, hold, hold.Amount); err != nil {
+		return fmt.Errorf("[ReleaseHold] failed to restore allowance: %v", err)
+	}
+
+	if err = utils.PutHoldHelper(ctx, hold); err != nil {
+		return fmt.Errorf("[ReleaseHold] failed to update hold: %v", err)
+	}
+
+	// 事件触发
+	if err = _setHoldEvent(ctx, "HoldReleased", hold); err != nil {
+		return fmt.Errorf("[ReleaseHold] %v", err)
+	}
+
+	log.Printf("[ReleaseHold] client (%s) released hold (%s) as %s", operator, holdID, hold.Status)
+
+	return nil
+}
+
+// BalanceOnHold 查询账户有效冻结的总额
+func (s *SmartContract) BalanceOnHold(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	onHold, err := utils.BalanceOnHoldHelper(ctx, account)
+	if err != nil {
+		return 0, fmt.Errorf("[BalanceOnHold] %v", err)
+	}
+
+	return onHold, nil
+}
+
+// RetrieveHoldData 查询冻结信息
+func (s *SmartContract) RetrieveHoldData(ctx contractapi.TransactionContextInterface, holdID string) (*proto.Hold, error) {
+	hold, err := utils.ReadHoldHelper(ctx, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("[RetrieveHoldData] %v", err)
+	}
+	if hold == nil {
+		return nil, fmt.Errorf("[RetrieveHoldData] hold (%s) does not exist", holdID)
+	}
+
+	return hold, nil
+}
+
+// _activeHold 查询冻结，冻结必须存在且未执行或释放，已过期时返回nil
+func _activeHold(ctx contractapi.TransactionContextInterface, holdID string) (*proto.Hold, error) {
+	hold, err := utils.ReadHoldHelper(ctx, holdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hold: %v", err)
+	}
+	if hold == nil {
+		return nil, fmt.Errorf("hold (%s) does not exist", holdID)
+	}
+	if hold.Status != proto.HoldStatusOrdered {
+		return nil, fmt.Errorf("hold (%s) is already %s", holdID, hold.Status)
+	}
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	if !utils.HoldActiveHelper(hold, txTimestamp.GetSeconds()) {
+		return nil, nil
+	}
+
+	return hold, nil
+}
+
+// _setHoldEvent 触发冻结相关事件
+func _setHoldEvent(ctx contractapi.TransactionContextInterface, name string, hold *proto.Hold) error {
+	holdEventJSON, err := json.Marshal(hold)
+	if err != nil {
+		return fmt.Errorf("failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().SetEvent(name, holdEventJSON); err != nil {
+		return fmt.Errorf("failed to set event: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package contract
+
+import (
+	"contract-20/proto"
+	"contract-20/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+)
+
+// RegisterIdentity 登记客户端证书身份，当前的证书身份ID即为稳定账户地址，已有的余额不受影响
+func (s *SmartContract) RegisterIdentity(ctx contractapi.TransactionContextInterface) (string, error) {
+
+	// 获取用户客户端信息ID
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("[RegisterIdentity] failed to get client id: %v", err)
+	}
+
+	// 证书身份不能重复登记
+	identity, err := utils.ReadIdentityHelper(ctx, proto.IdentityPrefix, clientID)
+	if err != nil {
+		return "", fmt.Errorf("[RegisterIdentity] failed to read identity: %v", err)
+	}
+	if identity != nil {
+		return "", fmt.Errorf("[RegisterIdentity] client identity is already registered to account (%s)", identity.Account)
+	}
+
+	// 账户地址不能被占用(该证书已被更换)
+	account, err := utils.ReadIdentityHelper(ctx, proto.IdentityAccountPrefix, clientID)
+	if err != nil {
+		return "", fmt.Errorf("[RegisterIdentity] failed to read account: %v", err)
+	}
+	if account != nil {
+		return "", fmt.Errorf("[RegisterIdentity] account (%s) is already registered", clientID)
+	}
+
+	// 保存身份映射
+	err = utils.PutIdentityHelper(ctx, &proto.Identity{
+		Account:   clientID,
+		ClientID:  clientID,
+		UpdatedBy: clientID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("[RegisterIdentity] failed to register identity: %v", err)
+	}
+
+	log.Printf("[RegisterIdentity] client (%s) registered as account", clientID)
+
+	return clientID, nil
+}
+
+// RotateIdentity 将账户绑定到新的证书身份(证书更换后由管理员审批)，旧证书不能再代表该账户
+func (s *SmartContract) RotateIdentity(ctx contractapi.TransactionContextInterface, account string, newClientID string) error {
+
+	// 权限验证, 只有管理员可以更换账户的证书身份
+	operator, err := utils.AuthorizeAdminHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[RotateIdentity] %v", err)
+	}
+
+	// 账户必须已登记
+	current, err := utils.ReadIdentityHelper(ctx, proto.IdentityAccountPrefix, account)
+	if err != nil {
+		return fmt.Errorf("[RotateIdentity] failed to read account: %v", err)
+	}
+	if current == nil {
+		return fmt.Errorf("[RotateIdentity] account (%s) is not registered", account)
+	}
+	if current.ClientID == newClientID {
+		return fmt.Errorf("[RotateIdentity] account (%s) is already bound to the client identity", account)
+	}
+
+	// 新证书身份不能已经绑定账户，也不能是其他已登记的账户地址
+	identity, err := utils.ReadIdentityHelper(ctx, proto.IdentityPrefix, newClientID)
+	if err != nil {
+		return fmt.Errorf("[RotateIdentity] failed to read identity: %v", err)
+	}
+	if identity != nil {
+		return fmt.Errorf("[RotateIdentity] client identity is already registered to account (%s)", identity.Account)
+	}
+	if newClientID != account {
+		other, err := utils.ReadIdentityHelper(ctx, proto.IdentityAccountPrefix, newClientID)
+		if err != nil {
+			return fmt.Errorf("[RotateIdentity] failed to read account: %v", err)
+		}
+		if other != nil {
+			return fmt.Errorf("[RotateIdentity] client identity is the address of account (%s)", newClientID)
+		}
+	}
+
+	// 删除旧证书身份的映射
+	oldIdentityKey, err := ctx.GetStub().CreateCompositeKey(proto.IdentityPrefix, []string{current.ClientID})
+	if err != nil {
+		return fmt.Errorf("[RotateIdentity] failed to create the composite key for prefix (%s): %v", proto.IdentityPrefix, err)
+	}
+	if err = ctx.GetStub().DelState(oldIdentityKey); err != nil {
+		return fmt.Errorf("[RotateIdentity] failed to delete identity (%s): %v", oldIdentityKey, err)
+	}
+
+	// 保存新的身份映射
+	err = utils.PutIdentityHelper(ctx, &proto.Identity{
+		Account:   account,
+		ClientID:  newClientID,
+		UpdatedBy: operator,
+	})
+	if err != nil {
+		return fmt.Errorf("[RotateIdentity] failed to rotate identity: %v", err)
+	}
+
+	// 事件触发
+	rotatedEvent := proto.IdentityRotated{
+		Account:     account,
+		OldClientID: current.ClientID,
+		NewClientID: newClientID,
+		Operator:    operator,
+	}
+	rotatedEventJSON, err := json.Marshal(rotatedEvent)
+	if err != nil {
+		return fmt.Errorf("[RotateIdentity] failed to obtain JSON encoding: %v", err)
+	}
+
+	if err = ctx.GetStub().SetEvent("IdentityRotated", rotatedEventJSON); err != nil {
+		return fmt.Errorf("[RotateIdentity] failed to set event: %v", err)
+	}
+
+	return nil
+}
+
+// ResolveAccount 查询证书身份ID对应的稳定账户地址
+func (s *SmartContract) ResolveAccount(ctx contractapi.TransactionContextInterface, clientID string) (string, error) {
+	account, err := utils.ResolveAccountHelper(ctx, clientID)
+	if err != nil {
+		return "", fmt.Errorf("[ResolveAccount] %v", err)
+	}
+
+	return account, nil
+}
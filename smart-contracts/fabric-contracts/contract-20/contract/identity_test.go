@@ -0,0 +1,108 @@
+package contract
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// mockIdentity 测试用的客户端证书身份
+type mockIdentity struct {
+	id    string
+	mspID string
+	ou    []string
+}
+
+func (m *mockIdentity) GetID() (string, error) { return m.id, nil }
+
+func (m *mockIdentity) GetMSPID() (string, error) { return m.mspID, nil }
+
+func (m *mockIdentity) GetAttributeValue(string) (string, bool, error) { return "", false, nil }
+
+func (m *mockIdentity) AssertAttributeValue(string, string) error { return nil }
+
+func (m *mockIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{Subject: pkix.Name{OrganizationalUnit: m.ou}}, nil
+}
+
+var (
+	adminIdentity   = &mockIdentity{id: "x509::CN=admin", mspID: "yilvtong", ou: []string{"admin"}}
+	aliceIdentity   = &mockIdentity{id: "x509::CN=alice", mspID: "yilvtong", ou: []string{"client"}}
+	aliceRenewed    = &mockIdentity{id: "x509::CN=alice-renewed", mspID: "yilvtong", ou: []string{"client"}}
+	malloryIdentity = &mockIdentity{id: "x509::CN=mallory", mspID: "yilvtong", ou: []string{"client"}}
+)
+
+// newTestContext 每次调用开始一笔新的模拟交易
+func newTestContext(stub *shimtest.MockStub, txID string, identity *mockIdentity) *contractapi.TransactionContext {
+	stub.MockTransactionStart(txID)
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(identity)
+
+	return ctx
+}
+
+func TestRotateIdentityRequiresAdmin(t *testing.T) {
+	s := new(SmartContract)
+	stub := shimtest.NewMockStub("contract-20", nil)
+
+	if _, err := s.RegisterIdentity(newTestContext(stub, "tx1", aliceIdentity)); err != nil {
+		t.Fatalf("RegisterIdentity: %v", err)
+	}
+
+	// 非管理员不能把别人的账户绑定到自己的证书
+	if err := s.RotateIdentity(newTestContext(stub, "tx2", malloryIdentity), aliceIdentity.id, malloryIdentity.id); err == nil {
+		t.Fatal("RotateIdentity by a non-admin must fail")
+	}
+	account, err := s.ResolveAccount(newTestContext(stub, "tx3", malloryIdentity), malloryIdentity.id)
+	if err != nil || account != malloryIdentity.id {
+		t.Fatalf("mallory resolved to (%s, %v), want her own address", account, err)
+	}
+
+	// 在管理员初始化之前，任何人都不是管理员
+	if err = s.RotateIdentity(newTestContext(stub, "tx4", adminIdentity), aliceIdentity.id, aliceRenewed.id); err == nil {
+		t.Fatal("RotateIdentity before InitAdmin must fail")
+	}
+}
+
+func TestInitAdminRequiresMspAdmin(t *testing.T) {
+	s := new(SmartContract)
+	stub := shimtest.NewMockStub("contract-20", nil)
+
+	if err := s.InitAdmin(newTestContext(stub, "tx1", malloryIdentity)); err == nil {
+		t.Fatal("InitAdmin by a client certificate must fail")
+	}
+	if err := s.InitAdmin(newTestContext(stub, "tx2", adminIdentity)); err != nil {
+		t.Fatalf("InitAdmin: %v", err)
+	}
+	if err := s.InitAdmin(newTestContext(stub, "tx3", adminIdentity)); err == nil {
+		t.Fatal("InitAdmin must only succeed once")
+	}
+}
+
+func TestRotateIdentityByAdmin(t *testing.T) {
+	s := new(SmartContract)
+	stub := shimtest.NewMockStub("contract-20", nil)
+
+	if err := s.InitAdmin(newTestContext(stub, "tx1", adminIdentity)); err != nil {
+		t.Fatalf("InitAdmin: %v", err)
+	}
+	if _, err := s.RegisterIdentity(newTestContext(stub, "tx2", aliceIdentity)); err != nil {
+		t.Fatalf("RegisterIdentity: %v", err)
+	}
+	if err := s.RotateIdentity(newTestContext(stub, "tx3", adminIdentity), aliceIdentity.id, aliceRenewed.id); err != nil {
+		t.Fatalf("RotateIdentity: %v", err)
+	}
+
+	// 新证书代表原账户，旧证书不能再代表该账户
+	account, err := s.ResolveAccount(newTestContext(stub, "tx4", aliceRenewed), aliceRenewed.id)
+	if err != nil || account != aliceIdentity.id {
+		t.Fatalf("renewed certificate resolved to (%s, %v), want %s", account, err, aliceIdentity.id)
+	}
+	if _, err = s.ResolveAccount(newTestContext(stub, "tx5", aliceIdentity), aliceIdentity.id); err == nil {
+		t.Fatal("the rotated certificate must no longer resolve")
+	}
+}
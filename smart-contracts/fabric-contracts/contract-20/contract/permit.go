@@ -0,0 +1,224 @@
+package contract
+
+import (
+	"contract-20/proto"
+	"contract-20/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+	"strconv"
+)
+
+// RegisterPublicKey 登记客户端账户用于链下签名的ECDSA公钥，登记后不能自行修改，更换公钥需要管理员调用RotatePublicKey
+// 本合约是唯一登记公钥的合约，其他链码通过VerifySignature和UseNonce校验签名
+func (s *SmartContract) RegisterPublicKey(ctx contractapi.TransactionContextInterface, publicKeyPem string) error {
+
+	// 校验公钥格式
+	if _, err := utils.ParsePublicKeyHelper(publicKeyPem); err != nil {
+		return fmt.Errorf("[RegisterPublicKey] invalid public key: %v", err)
+	}
+
+	// 获取用户客户端信息ID
+	clientID, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[RegisterPublicKey] failed to get client id: %v", err)
+	}
+
+	// 拼接公钥的key
+	publicKeyKey, err := ctx.GetStub().CreateCompositeKey(proto.PublicKeyPrefix, []string{clientID})
+	if err != nil {
+		return fmt.Errorf("[RegisterPublicKey] failed to create the composite key for prefix %s: %v", proto.PublicKeyPrefix, err)
+	}
+
+	// 首次登记后不能覆盖，防止盗用证书的人替换公钥
+	publicKeyBytes, err := ctx.GetStub().GetState(publicKeyKey)
+	if err != nil {
+		return fmt.Errorf("[RegisterPublicKey] failed to read public key for (%s) from world state: %v", publicKeyKey, err)
+	}
+	if publicKeyBytes != nil {
+		return fmt.Errorf("[RegisterPublicKey] client account (%s) already registered a public key", clientID)
+	}
+
+	// 更新公钥
+	if err = ctx.GetStub().PutState(publicKeyKey, []byte(publicKeyPem)); err != nil {
+		return fmt.Errorf("[RegisterPublicKey] failed to update state of smart contract for key %s: %v", publicKeyKey, err)
+	}
+
+	log.Printf("[RegisterPublicKey] client (%s) registered a public key", clientID)
+
+	return nil
+}
+
+// RotatePublicKey 更换账户登记的公钥(密钥丢失或泄露后由管理员审批)，只有管理员可以调用
+func (s *SmartContract) RotatePublicKey(ctx contractapi.TransactionContextInterface, account string, publicKeyPem string) error {
+	if account == "" {
+		return fmt.Errorf("[RotatePublicKey] account cannot be empty")
+	}
+
+	// 校验公钥格式
+	if _, err := utils.ParsePublicKeyHelper(publicKeyPem); err != nil {
+		return fmt.Errorf("[RotatePublicKey] invalid public key: %v", err)
+	}
+
+	// 权限验证
+	operator, err := utils.AuthorizeAdminHelper(ctx)
+	if err != nil {
+		return fmt.Errorf("[RotatePublicKey] %v", err)
+	}
+
+	// 拼接公钥的key
+	publicKeyKey, err := ctx.GetStub().CreateCompositeKey(proto.PublicKeyPrefix, []string{account})
+	if err != nil {
+		return fmt.Errorf("[RotatePublicKey] failed to create the composite key for prefix %s: %v", proto.PublicKeyPrefix, err)
+	}
+
+	// 更新公钥
+	if err = ctx.GetStub().PutState(publicKeyKey, []byte(publicKeyPem)); err != nil {
+		return fmt.Errorf("[RotatePublicKey] failed to update state of smart contract for key %s: %v", publicKeyKey, err)
+	}
+
+	// 事件触发
+	rotatedEventJSON, err := json.Marshal(proto.PublicKeyRotated{Account: account, Operator: operator})
+	if err != nil {
+		return fmt.Errorf("[RotatePublicKey] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().SetEvent("PublicKeyRotated", rotatedEventJSON); err != nil {
+		return fmt.Errorf("[RotatePublicKey] failed to set event: %v", err)
+	}
+
+	log.Printf("[RotatePublicKey] admin (%s) rotated the public key of account (%s)", operator, account)
+
+	return nil
+}
+
+// VerifySignature 使用账户登记的公钥验证签名，供其他链码通过InvokeChaincode调用，签名无效时返回错误
+// payload: 签名数据原文
+// signature: base64编码的ASN.1 DER格式ECDSA签名，签名对象为payload的SHA-256
+func (s *SmartContract) VerifySignature(ctx contractapi.TransactionContextInterface, account string, payload string, signature string) (bool, error) {
+	if err := utils.VerifySignatureHelper(ctx, account, []byte(payload), signature); err != nil {
+		return false, fmt.Errorf("[VerifySignature] %v", err)
+	}
+
+	return true, nil
+}
+
+// PublicKeyOf 查询账户登记的公钥
+func (s *SmartContract) PublicKeyOf(ctx contractapi.TransactionContextInterface, account string) (string, error) {
+
+	// 拼接公钥的key
+	publicKeyKey, err := ctx.GetStub().CreateCompositeKey(proto.PublicKeyPrefix, []string{account})
+	if err != nil {
+		return "", fmt.Errorf("[PublicKeyOf] failed to create the composite key for prefix %s: %v", proto.PublicKeyPrefix, err)
+	}
+
+	// 根据公钥的key查询，没有登记则返回空
+	publicKeyBytes, err := ctx.GetStub().GetState(publicKeyKey)
+	if err != nil {
+		return "", fmt.Errorf("[PublicKeyOf] failed to read public key for (%s) from world state: %v", publicKeyKey, err)
+	}
+
+	return string(publicKeyBytes), nil
+}
+
+// Nonces 查询账户下一个签名(Permit或ExecuteSigned)需要使用的序号
+func (s *SmartContract) Nonces(ctx contractapi.TransactionContextInterface, owner string) (int, error) {
+	nonce, err := utils.NonceHelper(ctx, owner, "")
+	if err != nil {
+		return 0, fmt.Errorf("[Nonces] failed to read nonce: %v", err)
+	}
+
+	return nonce, nil
+}
+
+// NoncesOf 查询账户在其他链码(通过UseNonce)下一个签名需要使用的序号
+func (s *SmartContract) NoncesOf(ctx contractapi.TransactionContextInterface, owner string, chaincode string) (int, error) {
+	if chaincode == "" {
+		return 0, fmt.Errorf("[NoncesOf] chaincode cannot be empty")
+	}
+
+	nonce, err := utils.NonceHelper(ctx, owner, chaincode)
+	if err != nil {
+		return 0, fmt.Errorf("[NoncesOf] failed to read nonce: %v", err)
+	}
+
+	return nonce, nil
+}
+
+// UseNonce 验证账户对payload的签名并消耗账户在调用链码下的序号，供其他链码的代理调用通过InvokeChaincode使用
+// 序号按发起交易的链码区分，各链码的签名不会互相消耗序号；payload的合约域名、通道和有效期由调用链码校验
+func (s *SmartContract) UseNonce(ctx contractapi.TransactionContextInterface, account string, payload string, signature string, nonce int) (bool, error) {
+
+	// 获取发起交易的链码
+	caller, err := utils.CallerChaincodeHelper(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[UseNonce] %v", err)
+	}
+
+	// 验证签名
+	if err = utils.VerifySignatureHelper(ctx, account, []byte(payload), signature); err != nil {
+		return false, fmt.Errorf("[UseNonce] %v", err)
+	}
+
+	// 消耗序号，防止重放
+	if err = utils.UseNonceHelper(ctx, account, caller, nonce); err != nil {
+		return false, fmt.Errorf("[UseNonce] %v", err)
+	}
+
+	log.Printf("[UseNonce] chaincode (%s) used nonce (%d) of account (%s)", caller, nonce, account)
+
+	return true, nil
+}
+
+// Permit 使用owner的链下签名授权spender可以转移的资产
+// 签名数据为 ["Permit", 合约域名, 通道, owner, spender, value, deadline, nonce] 的JSON编码
+func (s *SmartContract) Permit(ctx contractapi.TransactionContextInterface, owner string, spender string, value int, deadline int64, nonce int, signature string) error {
+	// 参数校验
+	if value < 0 {
+		return fmt.Errorf("[Permit] permit value cannot be negative")
+	}
+
+	// 校验签名有效期
+	if err := utils.CheckDeadlineHelper(ctx, deadline); err != nil {
+		return fmt.Errorf("[Permit] %v", err)
+	}
+
+	// 验证签名
+	payload, err := utils.SignedPayloadHelper(ctx, "Permit", owner, spender, strconv.Itoa(value), strconv.FormatInt(deadline, 10), strconv.Itoa(nonce))
+	if err != nil {
+		return fmt.Errorf("[Permit] failed to build payload: %v", err)
+	}
+	if err = utils.VerifySignatureHelper(ctx, owner, payload, signature); err != nil {
+		return fmt.Errorf("[Permit] %v", err)
+	}
+
+	// 消耗序号，防止重放
+	if err = utils.UseNonceHelper(ctx, owner, "", nonce); err != nil {
+		return fmt.Errorf("[Permit] %v", err)
+	}
+
+	// 更新授权和对应的额度
+	if err = utils.ApproveHelper(ctx, owner, spender, value); err != nil {
+		return fmt.Errorf("[Permit] failed to approve: %v", err)
+	}
+
+	// 事件触发
+	approvalEvent := proto.Event{
+		Currency: proto.PrimaryCurrency,
+		From:     owner,
+		To:       spender,
+		Amount:   value,
+	}
+	approvalEventJSON, err := json.Marshal(approvalEvent)
+	if err != nil {
+		return fmt.Errorf("[Permit] failed to obtain JSON encoding: %v", err)
+	}
+
+	if err = ctx.GetStub().SetEvent("Approval", approvalEventJSON); err != nil {
+		return fmt.Errorf("[Permit] failed to set event: %v", err)
+	}
+
+	log.Printf("[Permit] owner (%s) permitted a withdrawal allowance of (%d) for spender (%s) with nonce (%d)", owner, value, spender, nonce)
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package contract
+
+import (
+	"contract-20/proto"
+	"contract-20/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+	"strconv"
+)
+
+// ExecuteSigned 以签名者的身份执行 Transfer、TransferFrom 或 TransferBatch，由平台代为提交交易
+// payload: JSON编码的proto.SignedPayload，args与对应方法的参数一致，TransferBatch的参数为JSON数组
+// signature: 对payload原始字节的签名
+func (s *SmartContract) ExecuteSigned(ctx contractapi.TransactionContextInterface, payload string, signature string) error {
+	// 校验签名数据
+	signed, err := utils.VerifySignedPayloadHelper(ctx, payload, signature)
+	if err != nil {
+		return fmt.Errorf("[ExecuteSigned] %v", err)
+	}
+
+	switch signed.Function {
+	case "Transfer":
+		if len(signed.Args) != 2 {
+			return fmt.Errorf("[ExecuteSigned] Transfer expects 2 args (recipient, amount), got %d", len(signed.Args))
+		}
+		amount, err := strconv.Atoi(signed.Args[1])
+		if err != nil {
+			return fmt.Errorf("[ExecuteSigned] invalid amount (%s): %v", signed.Args[1], err)
+		}
+		err = _transfer(ctx, proto.PrimaryCurrency, signed.Signer, signed.Args[0], amount)
+		if err != nil {
+			return err
+		}
+	case "TransferFrom":
+		if len(signed.Args) != 3 {
+			return fmt.Errorf("[ExecuteSigned] TransferFrom expects 3 args (from, to, amount), got %d", len(signed.Args))
+		}
+		amount, err := strconv.Atoi(signed.Args[2])
+		if err != nil {
+			return fmt.Errorf("[ExecuteSigned] invalid amount (%s): %v", signed.Args[2], err)
+		}
+		err = _transferFrom(ctx, proto.PrimaryCurrency, signed.Signer, signed.Args[0], signed.Args[1], amount)
+		if err != nil {
+			return err
+		}
+	case "TransferBatch":
+		if len(signed.Args) != 2 {
+			return fmt.Errorf("[ExecuteSigned] TransferBatch expects 2 args (recipients, amounts), got %d", len(signed.Args))
+		}
+		var recipients []string
+		var amounts []int
+		if err = json.Unmarshal([]byte(signed.Args[0]), &recipients); err != nil {
+			return fmt.Errorf("[ExecuteSigned] invalid recipients: %v", err)
+		}
+		if err = json.Unmarshal([]byte(signed.Args[1]), &amounts); err != nil {
+			return fmt.Errorf("[ExecuteSigned] invalid amounts: %v", err)
+		}
+		if len(recipients) != len(amounts) {
+			return fmt.Errorf("[ExecuteSigned] recipients and amounts must have the same length")
+		}
+		err = _transferBatch(ctx, proto.PrimaryCurrency, signed.Signer, recipients, amounts)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("[ExecuteSigned] function (%s) cannot be relayed", signed.Function)
+	}
+
+	log.Printf("[ExecuteSigned] executed (%s) for signer (%s) with nonce (%d)", signed.Function, signed.Signer, signed.Nonce)
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package contract
+
+import (
+	"contract-20/proto"
+	"contract-20/utils"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"log"
+)
+
+// CreateVesting 管理员从自己的账户向受益人发放按时间线性解锁的资产，资产计入受益人余额但在释放前处于锁定状态
+// start: 开始时间(Unix秒)，cliff: 开始后多少秒开始解锁，duration: 开始后多少秒全部解锁
+func (s *SmartContract) CreateVesting(ctx contractapi.TransactionContextInterface, beneficiary string, total int, start int64, cliff int64, duration int64) (string, error) {
+	// 校验参数
+	if total <= 0 {
+		return "", fmt.Errorf("[CreateVesting] vesting total must be a positive integer")
+	}
+	if start <= 0 || cliff < 0 || duration <= 0 {
+		return "", fmt.Errorf("[CreateVesting] start and duration must be positive, cliff must not be negative")
+	}
+	if cliff > duration {
+		return "", fmt.Errorf("[CreateVesting] cliff (%d) must not exceed duration (%d)", cliff, duration)
+	}
+	if beneficiary == "" || beneficiary == proto.EmptyAccount {
+		return "", fmt.Errorf("[CreateVesting] beneficiary must not be empty or the zero address")
+	}
+
+	// 只有管理员可以创建锁仓计划，资产从管理员账户发放
+	grantor, err := utils.AuthorizeAdminHelper(ctx)
+	if err != nil {
+		return "", fmt.Errorf("[CreateVesting] %v", err)
+	}
+
+	// 资产转移到受益人账户
+	if err = utils.TransferHelper(ctx, grantor, []string{beneficiary}, []int{total}); err != nil {
+		return "", fmt.Errorf("[CreateVesting] failed to transfer: %v", err)
+	}
+
+	// 锁定受益人账户中对应的余额
+	if err = utils.UpdateLockedBalanceHelper(ctx, beneficiary, total); err != nil {
+		return "", fmt.Errorf("[CreateVesting] failed to lock balance: %v", err)
+	}
+
+	// 保存锁仓计划，以交易ID作为计划ID
+	vesting := &proto.Vesting{
+		ID:          ctx.GetStub().GetTxID(),
+		Beneficiary: beneficiary,
+		Grantor:     grantor,
+		Total:       total,
+		Released:    0,
+		Start:       start,
+		Cliff:       cliff,
+		Duration:    duration,
+	}
+	if err = utils.PutVestingHelper(ctx, vesting); err != nil {
+		return "", fmt.Errorf("[CreateVesting] failed to save vesting: %v", err)
+	}
+
+	// 事件触发
+	vestingEventJSON, err := json.Marshal(vesting)
+	if err != nil {
+		return "", fmt.Errorf("[CreateVesting] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().SetEvent("VestingCreated", vestingEventJSON); err != nil {
+		return "", fmt.Errorf("[CreateVesting] failed to set event: %v", err)
+	}
+
+	log.Printf("[CreateVesting] grantor (%s) created vesting (%s) of %d for beneficiary (%s)", grantor, vesting.ID, total, beneficiary)
+
+	return vesting.ID, nil
+}
+
+// Release 按交易时间戳释放客户端账户全部锁仓计划中已解锁的部分，返回本次释放的总量
+func (s *SmartContract) Release(ctx contractapi.TransactionContextInterface) (int, error) {
+
+	// 获取用户客户端信息ID
+	beneficiary, err := utils.ClientAccountHelper(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("[Release] failed to get client id: %v", err)
+	}
+
+	// 获取交易时间戳
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("[Release] failed to get transaction timestamp: %v", err)
+	}
+	now := txTimestamp.GetSeconds()
+
+	schedules, err := utils.VestingSchedulesHelper(ctx, beneficiary)
+	if err != nil {
+		return 0, fmt.Errorf("[Release] failed to read vesting schedules: %v", err)
+	}
+
+	releasedEvent := proto.VestingReleased{
+		Beneficiary: beneficiary,
+		IDs:         make([]string, 0),
+		Amounts:     make([]int, 0),
+	}
+	releasedTotal := 0
+	for _, vesting := range schedules {
+		releasable := utils.VestedAmountHelper(vesting, now) - vesting.Released
+		if releasable <= 0 {
+			continue
+		}
+
+		vesting.Released += releasable
+		if err = utils.PutVestingHelper(ctx, vesting); err != nil {
+			return 0, fmt.Errorf("[Release] failed to update vesting: %v", err)
+		}
+
+		releasedEvent.IDs = append(releasedEvent.IDs, vesting.ID)
+		releasedEvent.Amounts = append(releasedEvent.Amounts, releasable)
+		releasedTotal += releasable
+	}
+
+	if releasedTotal == 0 {
+		return 0, fmt.Errorf("[Release] no vested amount to release")
+	}
+
+	// 账本不支持读取本交易内的写入，锁定余额汇总后一次性更新
+	if err = utils.UpdateLockedBalanceHelper(ctx, beneficiary, -releasedTotal); err != nil {
+		return 0, fmt.Errorf("[Release] failed to unlock balance: %v", err)
+	}
+
+	// 事件触发
+	releasedEventJSON, err := json.Marshal(releasedEvent)
+	if err != nil {
+		return 0, fmt.Errorf("[Release] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().SetEvent("VestingReleased", releasedEventJSON); err != nil {
+		return 0, fmt.Errorf("[Release] failed to set event: %v", err)
+	}
+
+	log.Printf("[Release] beneficiary (%s) released %d", beneficiary, releasedTotal)
+
+	return releasedTotal, nil
+}
+
+// LockedBalanceOf 查询账户锁仓中(尚未释放)的余额
+func (s *SmartContract) LockedBalanceOf(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	locked, err := utils.LockedBalanceHelper(ctx, account)
+	if err != nil {
+		return 0, fmt.Errorf("[LockedBalanceOf] %v", err)
+	}
+
+	return locked, nil
+}
+
+// VestingSchedules 查询账户的全部锁仓计划
+func (s *SmartContract) VestingSchedules(ctx contractapi.TransactionContextInterface, account string) ([]*proto.Vesting, error) {
+	schedules, err := utils.VestingSchedulesHelper(ctx, account)
+	if err != nil {
+		return nil, fmt.Errorf("[VestingSchedules] %v", err)
+	}
+
+	return schedules, nil
+}
@@ -7,23 +7,142 @@ const (
 	TotalSupplyKey  = "totalSupply"
 	EmptyAccount    = "0x0"
 	AllowancePrefix = "allowance"
+	PublicKeyPrefix = "publicKey"
+	NoncePrefix     = "nonce"
+	PermitDomain    = "contract-20"
+
+	IdentityPrefix        = "identity"
+	IdentityAccountPrefix = "identityAccount"
+
+	VestingPrefix = "vesting"
+	LockedPrefix  = "locked"
+
+	HoldPrefix        = "hold"
+	HoldAccountPrefix = "holdAccount"
+	NotaryPrefix      = "notaryChaincode"
+
+	// 主币种沿用原有的余额、授权和总量key, 其他币种使用以下前缀
+	PrimaryCurrency         = CoinName
+	CurrencyPrefix          = "currency"
+	BalancePrefix           = "balance"
+	CurrencyAllowancePrefix = "currencyAllowance"
 
 	OperateAuthLevelName = "level"
+
+	AdminPrefix  = "admin"
+	AdminInitKey = "adminInitialized"
+
+	// 只有该组织的管理员证书(NodeOU为admin)可以初始化合约管理员
+	AdminMSPID = "yilvtong"
+	AdminOU    = "admin"
 )
 
 const (
 	OperateAuthNeedLevel = 999
 )
 
+// 冻结状态
+const (
+	HoldStatusOrdered              = "ordered"
+	HoldStatusExecuted             = "executed"
+	HoldStatusReleasedByNotary     = "releasedByNotary"
+	HoldStatusReleasedByPayee      = "releasedByPayee"
+	HoldStatusReleasedOnExpiration = "releasedOnExpiration"
+)
+
 // Event 事件触发结构体
 type Event struct {
+	Currency string `json:"currency"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Amount   int    `json:"amount"`
+}
+
+type EventBatch struct {
+	Currency string   `json:"currency"`
+	From     string   `json:"from"`
+	Tos      []string `json:"tos"`
+	Amounts  []int    `json:"amounts"`
+}
+
+// SignedPayload 链下签名的代理调用数据
+type SignedPayload struct {
+	Signer   string   `json:"signer"`
+	Function string   `json:"function"`
+	Args     []string `json:"args"`
+	Nonce    int      `json:"nonce"`
+	Deadline int64    `json:"deadline"`
+	Domain   string   `json:"domain"`
+	Channel  string   `json:"channel"`
+}
+
+// Identity 证书身份与稳定账户地址的映射
+type Identity struct {
+	Account   string `json:"account"`
+	ClientID  string `json:"clientId"`
+	UpdatedBy string `json:"updatedBy"`
+}
+
+// AdminUpdated 设置或撤销管理员时触发的事件
+type AdminUpdated struct {
+	Account  string `json:"account"`
+	Enabled  bool   `json:"enabled"`
+	Operator string `json:"operator"`
+}
+
+// PublicKeyRotated 管理员更换账户公钥时触发的事件
+type PublicKeyRotated struct {
+	Account  string `json:"account"`
+	Operator string `json:"operator"`
+}
+
+// IdentityRotated 更换账户证书身份时触发的事件
+type IdentityRotated struct {
+	Account     string `json:"account"`
+	OldClientID string `json:"oldClientId"`
+	NewClientID string `json:"newClientId"`
+	Operator    string `json:"operator"`
+}
+
+// Vesting 线性释放的锁仓计划
+// Start 为开始时间(Unix秒), Cliff 为开始后多少秒才开始释放, Duration 为开始后多少秒全部释放
+type Vesting struct {
+	ID          string `json:"id"`
+	Beneficiary string `json:"beneficiary"`
+	Grantor     string `json:"grantor"`
+	Total       int    `json:"total"`
+	Released    int    `json:"released"`
+	Start       int64  `json:"start"`
+	Cliff       int64  `json:"cliff"`
+	Duration    int64  `json:"duration"`
+}
+
+// VestingReleased 释放锁仓时触发的事件
+type VestingReleased struct {
+	Beneficiary string   `json:"beneficiary"`
+	IDs         []string `json:"ids"`
+	Amounts     []int    `json:"amounts"`
+}
+
+// Hold 待支付的冻结资产, 由公证人执行或释放
+// Expiry 为过期时间(Unix秒), 为0表示不过期, 过期后冻结自动失效
+type Hold struct {
+	ID     string `json:"id"`
+	Issuer string `json:"issuer"`
 	From   string `json:"from"`
 	To     string `json:"to"`
+	Notary string `json:"notary"`
 	Amount int    `json:"amount"`
+	Expiry int64  `json:"expiry"`
+	Status string `json:"status"`
 }
 
-type EventBatch struct {
-	From    string   `json:"from"`
-	Tos     []string `json:"tos"`
-	Amounts []int    `json:"amounts"`
+// Currency 币种信息
+type Currency struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+	Metadata string `json:"metadata"`
+	Issuer   string `json:"issuer"`
 }
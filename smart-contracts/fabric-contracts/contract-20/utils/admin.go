@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"contract-20/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	IsAdminHelper: 查询账户是否为管理员
+*/
+func IsAdminHelper(ctx contractapi.TransactionContextInterface, account string) (bool, error) {
+	adminKey, err := ctx.GetStub().CreateCompositeKey(proto.AdminPrefix, []string{account})
+	if err != nil {
+		return false, fmt.Errorf("[IsAdminHelper] failed to create the composite key for prefix (%s): %v", proto.AdminPrefix, err)
+	}
+	adminBytes, err := ctx.GetStub().GetState(adminKey)
+	if err != nil {
+		return false, fmt.Errorf("[IsAdminHelper] failed to read admin (%s) from world state: %v", adminKey, err)
+	}
+
+	return adminBytes != nil, nil
+}
+
+/*
+	AuthorizeAdminHelper: 校验调用者是否为管理员, 返回调用者的账户地址
+*/
+func AuthorizeAdminHelper(ctx contractapi.TransactionContextInterface) (string, error) {
+	operator, err := ClientAccountHelper(ctx)
+	if err != nil {
+		return "", fmt.Errorf("[AuthorizeAdminHelper] failed to get client id: %v", err)
+	}
+
+	isAdmin, err := IsAdminHelper(ctx, operator)
+	if err != nil {
+		return "", err
+	}
+	if !isAdmin {
+		return "", fmt.Errorf("[AuthorizeAdminHelper] client account (%s) is not an admin", operator)
+	}
+
+	return operator, nil
+}
+
+/*
+	AuthorizeAdminMSPHelper: 校验调用者是否为配置的管理组织的管理员证书(NodeOU为admin)
+*/
+func AuthorizeAdminMSPHelper(ctx contractapi.TransactionContextInterface) error {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("[AuthorizeAdminMSPHelper] failed to get client msp id: %v", err)
+	}
+	if clientMSPID != proto.AdminMSPID {
+		return fmt.Errorf("[AuthorizeAdminMSPHelper] client msp (%s) is not the admin msp (%s)", clientMSPID, proto.AdminMSPID)
+	}
+
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return fmt.Errorf("[AuthorizeAdminMSPHelper] failed to get client certificate: %v", err)
+	}
+	if cert == nil {
+		return fmt.Errorf("[AuthorizeAdminMSPHelper] client certificate is empty")
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == proto.AdminOU {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("[AuthorizeAdminMSPHelper] client is not an admin of msp (%s)", clientMSPID)
+}
+
+/*
+	PutAdminHelper: 设置或撤销管理员并触发AdminUpdated事件
+*/
+func PutAdminHelper(ctx contractapi.TransactionContextInterface, account string, enabled bool, operator string) error {
+	adminKey, err := ctx.GetStub().CreateCompositeKey(proto.AdminPrefix, []string{account})
+	if err != nil {
+		return fmt.Errorf("[PutAdminHelper] failed to create the composite key for prefix (%s): %v", proto.AdminPrefix, err)
+	}
+	if enabled {
+		err = ctx.GetStub().PutState(adminKey, []byte{'0'})
+	} else {
+		err = ctx.GetStub().DelState(adminKey)
+	}
+	if err != nil {
+		return fmt.Errorf("[PutAdminHelper] failed to update admin (%s): %v", adminKey, err)
+	}
+
+	adminEventJSON, err := json.Marshal(proto.AdminUpdated{Account: account, Enabled: enabled, Operator: operator})
+	if err != nil {
+		return fmt.Errorf("[PutAdminHelper] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().SetEvent("AdminUpdated", adminEventJSON); err != nil {
+		return fmt.Errorf("[PutAdminHelper] failed to set event: %v", err)
+	}
+
+	return nil
+}
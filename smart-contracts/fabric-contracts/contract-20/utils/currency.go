@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"contract-20/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	ReadCurrencyHelper: 查询币种信息, 主币种由proto常量定义, 未登记的币种返回nil
+*/
+func ReadCurrencyHelper(ctx contractapi.TransactionContextInterface, currency string) (*proto.Currency, error) {
+	if currency == proto.PrimaryCurrency {
+		return &proto.Currency{
+			Code:     proto.PrimaryCurrency,
+			Name:     proto.CoinName,
+			Symbol:   proto.CoinSymbol,
+			Decimals: proto.CoinDecimals,
+		}, nil
+	}
+
+	currencyKey, err := ctx.GetStub().CreateCompositeKey(proto.CurrencyPrefix, []string{currency})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadCurrencyHelper] failed to create the composite key for prefix (%s): %v", proto.CurrencyPrefix, err)
+	}
+	currencyBytes, err := ctx.GetStub().GetState(currencyKey)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadCurrencyHelper] failed to read currency (%s) from world state: %v", currency, err)
+	}
+	if currencyBytes == nil {
+		return nil, nil
+	}
+
+	info := new(proto.Currency)
+	if err = json.Unmarshal(currencyBytes, info); err != nil {
+		return nil, fmt.Errorf("[ReadCurrencyHelper] json unmarshal currency failed, err: %v", err)
+	}
+
+	return info, nil
+}
+
+/*
+	CheckCurrencyHelper: 校验币种已登记
+*/
+func CheckCurrencyHelper(ctx contractapi.TransactionContextInterface, currency string) error {
+	info, err := ReadCurrencyHelper(ctx, currency)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		return fmt.Errorf("[CheckCurrencyHelper] currency (%s) is not registered", currency)
+	}
+
+	return nil
+}
+
+/*
+	BalanceKeyHelper: 账户余额的key, 主币种直接使用账户地址
+*/
+func BalanceKeyHelper(ctx contractapi.TransactionContextInterface, currency string, account string) (string, error) {
+	if currency == proto.PrimaryCurrency {
+		return account, nil
+	}
+
+	balanceKey, err := ctx.GetStub().CreateCompositeKey(proto.BalancePrefix, []string{currency, account})
+	if err != nil {
+		return "", fmt.Errorf("[BalanceKeyHelper] failed to create the composite key for prefix (%s): %v", proto.BalancePrefix, err)
+	}
+
+	return balanceKey, nil
+}
+
+/*
+	TotalSupplyKeyHelper: 币种发行总量的key, 主币种直接使用proto.TotalSupplyKey
+*/
+func TotalSupplyKeyHelper(ctx contractapi.TransactionContextInterface, currency string) (string, error) {
+	if currency == proto.PrimaryCurrency {
+		return proto.TotalSupplyKey, nil
+	}
+
+	totalSupplyKey, err := ctx.GetStub().CreateCompositeKey(proto.TotalSupplyKey, []string{currency})
+	if err != nil {
+		return "", fmt.Errorf("[TotalSupplyKeyHelper] failed to create the composite key for prefix (%s): %v", proto.TotalSupplyKey, err)
+	}
+
+	return totalSupplyKey, nil
+}
+
+/*
+	AllowanceKeyHelper: owner授权给spender额度的key
+*/
+func AllowanceKeyHelper(ctx contractapi.TransactionContextInterface, currency string, owner string, spender string) (string, error) {
+	prefix, attributes := proto.AllowancePrefix, []string{owner, spender}
+	if currency != proto.PrimaryCurrency {
+		prefix, attributes = proto.CurrencyAllowancePrefix, []string{currency, owner, spender}
+	}
+
+	allowanceKey, err := ctx.GetStub().CreateCompositeKey(prefix, attributes)
+	if err != nil {
+		return "", fmt.Errorf("[AllowanceKeyHelper] failed to create the composite key for prefix (%s): %v", prefix, err)
+	}
+
+	return allowanceKey, nil
+}
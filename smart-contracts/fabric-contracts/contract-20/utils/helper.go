@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"contract-20/proto"
 	"fmt"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	"log"
@@ -28,12 +29,20 @@ func AuthorizationHelper(ctx contractapi.TransactionContextInterface, needLevel
 }
 
 /*
-	TransferHelper: 从一个账户向另一个账户转移资产
+	TransferHelper: 从一个账户向另一个账户转移主币种资产
 	from: 发送者账户
 	to: 接收者账户
 	amount: 数量
 */
 func TransferHelper(ctx contractapi.TransactionContextInterface, from string, tos []string, amounts []int) error {
+	return TransferCurrencyHelper(ctx, proto.PrimaryCurrency, from, tos, amounts)
+}
+
+/*
+	TransferCurrencyHelper: 从一个账户向另一个账户转移指定币种的资产
+	currency: 币种代码
+*/
+func TransferCurrencyHelper(ctx contractapi.TransactionContextInterface, currency string, from string, tos []string, amounts []int) error {
 	// 参数校验
 	var totalAmount = 0
 	for i := 0; i < len(tos); i++ {
@@ -47,7 +56,11 @@ func TransferHelper(ctx contractapi.TransactionContextInterface, from string, to
 	}
 
 	// 获取发送方账户信息
-	fromCurrentBalanceBytes, err := ctx.GetStub().GetState(from)
+	fromBalanceKey, err := BalanceKeyHelper(ctx, currency, from)
+	if err != nil {
+		return fmt.Errorf("[TransferHelper] %v", err)
+	}
+	fromCurrentBalanceBytes, err := ctx.GetStub().GetState(fromBalanceKey)
 	if err != nil {
 		return fmt.Errorf("[TransferHelper] failed to read sender account (%s) from world state, err: %v", from, err)
 	}
@@ -57,25 +70,66 @@ func TransferHelper(ctx contractapi.TransactionContextInterface, from string, to
 	}
 	// 发送方余额转为int类型
 	fromCurrentBalance, _ := strconv.Atoi(string(fromCurrentBalanceBytes))
+	// 锁仓和冻结中的部分不能转移, 锁仓和冻结仅适用于主币种
+	fromReservedBalance := 0
+	if currency == proto.PrimaryCurrency {
+		fromReservedBalance, err = ReservedBalanceHelper(ctx, from)
+		if err != nil {
+			return fmt.Errorf("[TransferHelper] failed to read reserved balance of sender account (%s), err: %v", from, err)
+		}
+	}
+	// 发送方可用余额不足
+	if fromCurrentBalance-fromReservedBalance < totalAmount {
+		return fmt.Errorf("[TransferHelper] client account (%s) have balance(%d) with reserved(%d), need balance(%d), balance not enough", from, fromCurrentBalance, fromReservedBalance, totalAmount)
+	}
+
+	return MoveBalanceHelper(ctx, currency, from, tos, amounts)
+}
+
+/*
+	MoveBalanceHelper: 更新发送方和接收方的余额, 不校验锁仓和冻结
+	仅用于已经预留了资产的场景(如执行冻结), 其他转账使用TransferHelper
+*/
+func MoveBalanceHelper(ctx contractapi.TransactionContextInterface, currency string, from string, tos []string, amounts []int) error {
+	var totalAmount = 0
+	for i := 0; i < len(amounts); i++ {
+		totalAmount += amounts[i]
+	}
+
+	// 获取发送方账户信息
+	fromBalanceKey, err := BalanceKeyHelper(ctx, currency, from)
+	if err != nil {
+		return fmt.Errorf("[MoveBalanceHelper] %v", err)
+	}
+	fromCurrentBalanceBytes, err := ctx.GetStub().GetState(fromBalanceKey)
+	if err != nil {
+		return fmt.Errorf("[MoveBalanceHelper] failed to read sender account (%s) from world state, err: %v", from, err)
+	}
+	// 发送方余额转为int类型
+	fromCurrentBalance, _ := strconv.Atoi(string(fromCurrentBalanceBytes))
 	// 发送方余额不足
 	if fromCurrentBalance < totalAmount {
-		return fmt.Errorf("[TransferHelper] client account (%s) have balance(%d), need balance(%d), balance not enough", from, fromCurrentBalance, totalAmount)
+		return fmt.Errorf("[MoveBalanceHelper] client account (%s) have balance(%d), need balance(%d), balance not enough", from, fromCurrentBalance, totalAmount)
 	}
 
 	// 发送方转账后余额
 	fromUpdatedBalance := fromCurrentBalance - totalAmount
 	// 更新发送方的账户信息
-	if err = ctx.GetStub().PutState(from, []byte(strconv.Itoa(fromUpdatedBalance))); err != nil {
+	if err = ctx.GetStub().PutState(fromBalanceKey, []byte(strconv.Itoa(fromUpdatedBalance))); err != nil {
 		return err
 	}
-	log.Printf("[TransferHelper] sender (%s) balance updated from %d to %d", from, fromCurrentBalance, fromUpdatedBalance)
+	log.Printf("[MoveBalanceHelper] sender (%s) balance updated from %d to %d", from, fromCurrentBalance, fromUpdatedBalance)
 
 	// 循环给接收人加资产
 	for i := 0; i < len(tos); i++ {
 		// 获取接收方账户信息
-		toCurrentBalanceBytes, err := ctx.GetStub().GetState(tos[i])
+		toBalanceKey, err := BalanceKeyHelper(ctx, currency, tos[i])
+		if err != nil {
+			return fmt.Errorf("[MoveBalanceHelper] %v", err)
+		}
+		toCurrentBalanceBytes, err := ctx.GetStub().GetState(toBalanceKey)
 		if err != nil {
-			return fmt.Errorf("[TransferHelper] failed to read recipient account (%s) from world state, err: %v", tos[i], err)
+			return fmt.Errorf("[MoveBalanceHelper] failed to read recipient account (%s) from world state, err: %v", tos[i], err)
 		}
 
 		var toCurrentBalance int
@@ -90,10 +144,40 @@ func TransferHelper(ctx contractapi.TransactionContextInterface, from string, to
 		// 转移资产后接收方的余额
 		toUpdatedBalance := toCurrentBalance + amounts[i]
 		// 更新接收方的账户信息
-		if err = ctx.GetStub().PutState(tos[i], []byte(strconv.Itoa(toUpdatedBalance))); err != nil {
+		if err = ctx.GetStub().PutState(toBalanceKey, []byte(strconv.Itoa(toUpdatedBalance))); err != nil {
 			return err
 		}
-		log.Printf("[TransferHelper] recipient (%s) balance updated from %d to %d", tos[i], toCurrentBalance, toUpdatedBalance)
+		log.Printf("[MoveBalanceHelper] recipient (%s) balance updated from %d to %d", tos[i], toCurrentBalance, toUpdatedBalance)
+	}
+
+	return nil
+}
+
+/*
+	ApproveHelper: 更新owner授权给spender的主币种额度
+	owner: 授权账户
+	spender: 被授权账户
+	value: 授权额度
+*/
+func ApproveHelper(ctx contractapi.TransactionContextInterface, owner string, spender string, value int) error {
+	return ApproveCurrencyHelper(ctx, proto.PrimaryCurrency, owner, spender, value)
+}
+
+/*
+	ApproveCurrencyHelper: 更新owner授权给spender的指定币种额度
+	currency: 币种代码
+*/
+func ApproveCurrencyHelper(ctx contractapi.TransactionContextInterface, currency string, owner string, spender string, value int) error {
+	// 拼接授权的key
+	allowanceKey, err := AllowanceKeyHelper(ctx, currency, owner, spender)
+	if err != nil {
+		return fmt.Errorf("[ApproveHelper] %v", err)
+	}
+
+	// 更新授权和对应的额度
+	err = ctx.GetStub().PutState(allowanceKey, []byte(strconv.Itoa(value)))
+	if err != nil {
+		return fmt.Errorf("[ApproveHelper] failed to update state of smart contract for key %s: %v", allowanceKey, err)
 	}
 
 	return nil
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"contract-20/proto"
+	"encoding/json"
+	"fmt"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"strconv"
+)
+
+/*
+	ReadHoldHelper: 按冻结ID查询冻结, 不存在则返回nil
+*/
+func ReadHoldHelper(ctx contractapi.TransactionContextInterface, holdID string) (*proto.Hold, error) {
+	holdKey, err := ctx.GetStub().CreateCompositeKey(proto.HoldPrefix, []string{holdID})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadHoldHelper] failed to create the composite key for prefix (%s): %v", proto.HoldPrefix, err)
+	}
+	holdBytes, err := ctx.GetStub().GetState(holdKey)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadHoldHelper] failed to read hold (%s) from world state: %v", holdID, err)
+	}
+	if holdBytes == nil {
+		return nil, nil
+	}
+
+	hold := new(proto.Hold)
+	if err = json.Unmarshal(holdBytes, hold); err != nil {
+		return nil, fmt.Errorf("[ReadHoldHelper] json unmarshal hold failed, err: %v", err)
+	}
+
+	return hold, nil
+}
+
+/*
+	PutHoldHelper: 保存冻结
+	冻结中的记录同时写入付款账户的索引, 执行或释放后从索引中删除
+*/
+func PutHoldHelper(ctx contractapi.TransactionContextInterface, hold *proto.Hold) error {
+	holdBytes, err := json.Marshal(hold)
+	if err != nil {
+		return fmt.Errorf("[PutHoldHelper] failed to obtain JSON encoding: %v", err)
+	}
+
+	holdKey, err := ctx.GetStub().CreateCompositeKey(proto.HoldPrefix, []string{hold.ID})
+	if err != nil {
+		return fmt.Errorf("[PutHoldHelper] failed to create the composite key for prefix (%s): %v", proto.HoldPrefix, err)
+	}
+	if err = ctx.GetStub().PutState(holdKey, holdBytes); err != nil {
+		return fmt.Errorf("[PutHoldHelper] failed to put hold (%s): %v", hold.ID, err)
+	}
+
+	holdAccountKey, err := ctx.GetStub().CreateCompositeKey(proto.HoldAccountPrefix, []string{hold.From, hold.ID})
+	if err != nil {
+		return fmt.Errorf("[PutHoldHelper] failed to create the composite key for prefix (%s): %v", proto.HoldAccountPrefix, err)
+	}
+	if hold.Status == proto.HoldStatusOrdered {
+		err = ctx.GetStub().PutState(holdAccountKey, holdBytes)
+	} else {
+		err = ctx.GetStub().DelState(holdAccountKey)
+	}
+	if err != nil {
+		return fmt.Errorf("[PutHoldHelper] failed to update hold index (%s): %v", holdAccountKey, err)
+	}
+
+	return nil
+}
+
+/*
+	HoldActiveHelper: 冻结是否仍然有效(未执行、未释放且未过期)
+	now: 交易时间戳(Unix秒)
+*/
+func HoldActiveHelper(hold *proto.Hold, now int64) bool {
+	if hold.Status != proto.HoldStatusOrdered {
+		return false
+	}
+
+	return hold.Expiry == 0 || now < hold.Expiry
+}
+
+/*
+	RestoreHoldAllowanceHelper: 冻结结束时把未支付的部分退回发起者的授权额度
+	冻结由付款账户以外的账户发起时扣减过授权额度, 释放或部分执行后未使用的额度需要退回
+	amount: 未支付的金额
+*/
+func RestoreHoldAllowanceHelper(ctx contractapi.TransactionContextInterface, hold *proto.Hold, amount int) error {
+	if hold.Issuer == hold.From || amount <= 0 {
+		return nil
+	}
+
+	allowanceKey, err := AllowanceKeyHelper(ctx, proto.PrimaryCurrency, hold.From, hold.Issuer)
+	if err != nil {
+		return fmt.Errorf("[RestoreHoldAllowanceHelper] %v", err)
+	}
+	currentAllowanceBytes, err := ctx.GetStub().GetState(allowanceKey)
+	if err != nil {
+		return fmt.Errorf("[RestoreHoldAllowanceHelper] failed to retrieve the allowance for (%s) from world state: %v", allowanceKey, err)
+	}
+	currentAllowance, _ := strconv.Atoi(string(currentAllowanceBytes))
+
+	if err = ApproveHelper(ctx, hold.From, hold.Issuer, currentAllowance+amount); err != nil {
+		return fmt.Errorf("[RestoreHoldAllowanceHelper] %v", err)
+	}
+
+	return nil
+}
+
+/*
+	BalanceOnHoldHelper: 查询账户有效冻结的总额, 过期的冻结不再计入
+*/
+func BalanceOnHoldHelper(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("[BalanceOnHoldHelper] failed to get transaction timestamp: %v", err)
+	}
+
+	holdIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(proto.HoldAccountPrefix, []string{account})
+	if err != nil {
+		return 0, fmt.Errorf("[BalanceOnHoldHelper] failed to get state for prefix %v: %v", proto.HoldAccountPrefix, err)
+	}
+	defer holdIterator.Close()
+
+	onHold := 0
+	for holdIterator.HasNext() {
+		queryResponse, err := holdIterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("[BalanceOnHoldHelper] failed to get the next state for prefix %v: %v", proto.HoldAccountPrefix, err)
+		}
+
+		hold := new(proto.Hold)
+		if err = json.Unmarshal(queryResponse.Value, hold); err != nil {
+			return 0, fmt.Errorf("[BalanceOnHoldHelper] json unmarshal hold failed, err: %v", err)
+		}
+		if HoldActiveHelper(hold, txTimestamp.GetSeconds()) {
+			onHold += hold.Amount
+		}
+	}
+
+	return onHold, nil
+}
+
+/*
+	ReservedBalanceHelper: 查询账户不可用的余额, 即锁仓与有效冻结之和
+*/
+func ReservedBalanceHelper(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	locked, err := LockedBalanceHelper(ctx, account)
+	if err != nil {
+		return 0, fmt.Errorf("[ReservedBalanceHelper] %v", err)
+	}
+	onHold, err := BalanceOnHoldHelper(ctx, account)
+	if err != nil {
+		return 0, fmt.Errorf("[ReservedBalanceHelper] %v", err)
+	}
+
+	return locked + onHold, nil
+}
+
+/*
+	CallerChaincodeHelper: 获取交易提案调用的链码名称
+	其他链码通过 InvokeChaincode 调用本合约时, 返回的是该链码的名称
+*/
+func CallerChaincodeHelper(ctx contractapi.TransactionContextInterface) (string, error) {
+	signedProposal, err := ctx.GetStub().GetSignedProposal()
+	if err != nil {
+		return "", fmt.Errorf("[CallerChaincodeHelper] failed to get signed proposal: %v", err)
+	}
+	if signedProposal == nil {
+		return "", fmt.Errorf("[CallerChaincodeHelper] signed proposal is empty")
+	}
+
+	proposal := new(peer.Proposal)
+	if err = protobuf.Unmarshal(signedProposal.ProposalBytes, proposal); err != nil {
+		return "", fmt.Errorf("[CallerChaincodeHelper] failed to unmarshal proposal: %v", err)
+	}
+	payload := new(peer.ChaincodeProposalPayload)
+	if err = protobuf.Unmarshal(proposal.Payload, payload); err != nil {
+		return "", fmt.Errorf("[CallerChaincodeHelper] failed to unmarshal proposal payload: %v", err)
+	}
+	invocationSpec := new(peer.ChaincodeInvocationSpec)
+	if err = protobuf.Unmarshal(payload.Input, invocationSpec); err != nil {
+		return "", fmt.Errorf("[CallerChaincodeHelper] failed to unmarshal chaincode invocation spec: %v", err)
+	}
+
+	return invocationSpec.GetChaincodeSpec().GetChaincodeId().GetName(), nil
+}
+
+/*
+	IsNotaryChaincodeHelper: 查询链码是否登记为可以担任公证人
+*/
+func IsNotaryChaincodeHelper(ctx contractapi.TransactionContextInterface, chaincode string) (bool, error) {
+	notaryKey, err := ctx.GetStub().CreateCompositeKey(proto.NotaryPrefix, []string{chaincode})
+	if err != nil {
+		return false, fmt.Errorf("[IsNotaryChaincodeHelper] failed to create the composite key for prefix (%s): %v", proto.NotaryPrefix, err)
+	}
+	notaryBytes, err := ctx.GetStub().GetState(notaryKey)
+	if err != nil {
+		return false, fmt.Errorf("[IsNotaryChaincodeHelper] failed to read notary chaincode (%s) from world state: %v", chaincode, err)
+	}
+
+	return notaryBytes != nil, nil
+}
+
+/*
+	HoldNotaryHelper: 判断操作者是否为冻结的公证人
+	公证人是登记的链码时, 交易必须由该链码通过 InvokeChaincode 调用
+*/
+func HoldNotaryHelper(ctx contractapi.TransactionContextInterface, hold *proto.Hold, operator string) (bool, error) {
+	if operator == hold.Notary {
+		return true, nil
+	}
+
+	isNotaryChaincode, err := IsNotaryChaincodeHelper(ctx, hold.Notary)
+	if err != nil || !isNotaryChaincode {
+		return false, err
+	}
+	caller, err := CallerChaincodeHelper(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return caller == hold.Notary, nil
+}
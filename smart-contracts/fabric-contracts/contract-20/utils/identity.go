@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"contract-20/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	ClientAccountHelper: 获取客户端证书身份对应的稳定账户地址
+*/
+func ClientAccountHelper(ctx contractapi.TransactionContextInterface) (string, error) {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", err
+	}
+
+	return ResolveAccountHelper(ctx, clientID)
+}
+
+/*
+	ResolveAccountHelper: 根据证书身份ID查询稳定账户地址
+	未登记的身份直接使用证书身份ID作为账户地址(兼容登记前的数据)
+*/
+func ResolveAccountHelper(ctx contractapi.TransactionContextInterface, clientID string) (string, error) {
+	identity, err := ReadIdentityHelper(ctx, proto.IdentityPrefix, clientID)
+	if err != nil {
+		return "", err
+	}
+	if identity != nil {
+		return identity.Account, nil
+	}
+
+	// 证书身份ID本身是已登记的账户地址但没有映射，说明该证书已被更换，不能再代表该账户
+	account, err := ReadIdentityHelper(ctx, proto.IdentityAccountPrefix, clientID)
+	if err != nil {
+		return "", err
+	}
+	if account != nil {
+		return "", fmt.Errorf("[ResolveAccountHelper] client identity of account (%s) was rotated", clientID)
+	}
+
+	return clientID, nil
+}
+
+/*
+	ReadIdentityHelper: 查询身份映射
+	prefix: proto.IdentityPrefix 按证书身份ID查询，proto.IdentityAccountPrefix 按账户地址查询
+*/
+func ReadIdentityHelper(ctx contractapi.TransactionContextInterface, prefix string, key string) (*proto.Identity, error) {
+	identityKey, err := ctx.GetStub().CreateCompositeKey(prefix, []string{key})
+	if err != nil {
+		return nil, fmt.Errorf("[ReadIdentityHelper] failed to create the composite key for prefix (%s): %v", prefix, err)
+	}
+	identityBytes, err := ctx.GetStub().GetState(identityKey)
+	if err != nil {
+		return nil, fmt.Errorf("[ReadIdentityHelper] failed to read identity (%s) from world state: %v", identityKey, err)
+	}
+	if identityBytes == nil {
+		return nil, nil
+	}
+
+	identity := new(proto.Identity)
+	if err = json.Unmarshal(identityBytes, identity); err != nil {
+		return nil, fmt.Errorf("[ReadIdentityHelper] json unmarshal identity failed, err: %v", err)
+	}
+
+	return identity, nil
+}
+
+/*
+	PutIdentityHelper: 保存证书身份ID到账户地址的映射和账户当前的证书身份
+*/
+func PutIdentityHelper(ctx contractapi.TransactionContextInterface, identity *proto.Identity) error {
+	identityBytes, err := json.Marshal(identity)
+	if err != nil {
+		return fmt.Errorf("[PutIdentityHelper] failed to obtain JSON encoding: %v", err)
+	}
+
+	for _, pair := range [][2]string{{proto.IdentityPrefix, identity.ClientID}, {proto.IdentityAccountPrefix, identity.Account}} {
+		prefix := pair[0]
+		identityKey, err := ctx.GetStub().CreateCompositeKey(prefix, []string{pair[1]})
+		if err != nil {
+			return fmt.Errorf("[PutIdentityHelper] failed to create the composite key for prefix (%s): %v", prefix, err)
+		}
+		if err = ctx.GetStub().PutState(identityKey, identityBytes); err != nil {
+			return fmt.Errorf("[PutIdentityHelper] failed to put identity (%s): %v", identityKey, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"contract-20/proto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"strconv"
+)
+
+/*
+	ParsePublicKeyHelper: 解析PEM编码的ECDSA公钥
+	publicKeyPem: PEM编码的公钥(PUBLIC KEY)
+*/
+func ParsePublicKeyHelper(publicKeyPem string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPem))
+	if block == nil {
+		return nil, fmt.Errorf("[ParsePublicKeyHelper] public key is not PEM encoded")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("[ParsePublicKeyHelper] failed to parse public key: %v", err)
+	}
+
+	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("[ParsePublicKeyHelper] public key is not an ECDSA key")
+	}
+
+	return ecdsaPublicKey, nil
+}
+
+/*
+	SignedPayloadHelper: 生成待签名的规范数据
+	签名数据为字段组成的JSON字符串数组，第一个字段为方法名
+*/
+func SignedPayloadHelper(ctx contractapi.TransactionContextInterface, function string, fields ...string) ([]byte, error) {
+	payload := append([]string{function, proto.PermitDomain, ctx.GetStub().GetChannelID()}, fields...)
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("[SignedPayloadHelper] failed to obtain JSON encoding: %v", err)
+	}
+
+	return payloadJSON, nil
+}
+
+/*
+	VerifySignatureHelper: 使用账户登记的公钥验证签名
+	account: 签名账户
+	payload: 签名数据
+	signature: base64编码的ASN.1 DER格式ECDSA签名，签名对象为payload的SHA-256
+*/
+func VerifySignatureHelper(ctx contractapi.TransactionContextInterface, account string, payload []byte, signature string) error {
+	// 获取账户登记的公钥
+	publicKeyKey, err := ctx.GetStub().CreateCompositeKey(proto.PublicKeyPrefix, []string{account})
+	if err != nil {
+		return fmt.Errorf("[VerifySignatureHelper] failed to create the composite key for prefix (%s): %v", proto.PublicKeyPrefix, err)
+	}
+	publicKeyBytes, err := ctx.GetStub().GetState(publicKeyKey)
+	if err != nil {
+		return fmt.Errorf("[VerifySignatureHelper] failed to read public key of account (%s) from world state: %v", account, err)
+	}
+	if publicKeyBytes == nil {
+		return fmt.Errorf("[VerifySignatureHelper] account (%s) has no registered public key", account)
+	}
+
+	publicKey, err := ParsePublicKeyHelper(string(publicKeyBytes))
+	if err != nil {
+		return fmt.Errorf("[VerifySignatureHelper] failed to parse public key of account (%s): %v", account, err)
+	}
+
+	// 验证签名
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("[VerifySignatureHelper] signature is not base64 encoded: %v", err)
+	}
+	digest := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(publicKey, digest[:], signatureBytes) {
+		return fmt.Errorf("[VerifySignatureHelper] invalid signature for account (%s)", account)
+	}
+
+	return nil
+}
+
+/*
+	NonceHelper: 查询账户下一个可用的签名序号
+	chaincode: 为空表示本合约自己的签名(Permit、ExecuteSigned), 否则为通过UseNonce使用序号的链码, 各链码的序号互不影响
+*/
+func NonceHelper(ctx contractapi.TransactionContextInterface, account string, chaincode string) (int, error) {
+	nonceKey, err := NonceKeyHelper(ctx, account, chaincode)
+	if err != nil {
+		return 0, err
+	}
+	nonceBytes, err := ctx.GetStub().GetState(nonceKey)
+	if err != nil {
+		return 0, fmt.Errorf("[NonceHelper] failed to read nonce of account (%s) from world state: %v", account, err)
+	}
+	if nonceBytes == nil {
+		return 0, nil
+	}
+
+	nonce, _ := strconv.Atoi(string(nonceBytes))
+
+	return nonce, nil
+}
+
+/*
+	UseNonceHelper: 校验并消耗签名序号，防止重放
+	nonce: 必须等于账户当前的序号，使用后序号加一
+*/
+func UseNonceHelper(ctx contractapi.TransactionContextInterface, account string, chaincode string, nonce int) error {
+	currentNonce, err := NonceHelper(ctx, account, chaincode)
+	if err != nil {
+		return err
+	}
+	if nonce != currentNonce {
+		return fmt.Errorf("[UseNonceHelper] invalid nonce (%d) for account (%s), expected (%d)", nonce, account, currentNonce)
+	}
+
+	nonceKey, err := NonceKeyHelper(ctx, account, chaincode)
+	if err != nil {
+		return err
+	}
+	if err = ctx.GetStub().PutState(nonceKey, []byte(strconv.Itoa(currentNonce+1))); err != nil {
+		return fmt.Errorf("[UseNonceHelper] failed to update nonce of account (%s): %v", account, err)
+	}
+
+	return nil
+}
+
+/*
+	NonceKeyHelper: 拼接签名序号的key
+*/
+func NonceKeyHelper(ctx contractapi.TransactionContextInterface, account string, chaincode string) (string, error) {
+	attributes := []string{account}
+	if chaincode != "" {
+		attributes = append(attributes, chaincode)
+	}
+
+	nonceKey, err := ctx.GetStub().CreateCompositeKey(proto.NoncePrefix, attributes)
+	if err != nil {
+		return "", fmt.Errorf("[NonceKeyHelper] failed to create the composite key for prefix (%s): %v", proto.NoncePrefix, err)
+	}
+
+	return nonceKey, nil
+}
+
+/*
+	CheckDeadlineHelper: 校验签名是否过期
+	deadline: 签名有效期(Unix秒)，与交易时间戳比较
+*/
+func CheckDeadlineHelper(ctx contractapi.TransactionContextInterface, deadline int64) error {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("[CheckDeadlineHelper] failed to get transaction timestamp: %v", err)
+	}
+	if txTimestamp.GetSeconds() > deadline {
+		return fmt.Errorf("[CheckDeadlineHelper] signature expired at (%d), transaction time (%d)", deadline, txTimestamp.GetSeconds())
+	}
+
+	return nil
+}
+
+/*
+	VerifySignedPayloadHelper: 解析并校验代理调用的签名数据
+	校验合约域名、通道、有效期和签名，并消耗签名者的序号
+	payload: JSON编码的proto.SignedPayload
+	signature: 对payload原始字节的签名
+*/
+func VerifySignedPayloadHelper(ctx contractapi.TransactionContextInterface, payload string, signature string) (*proto.SignedPayload, error) {
+	signed := new(proto.SignedPayload)
+	if err := json.Unmarshal([]byte(payload), signed); err != nil {
+		return nil, fmt.Errorf("[VerifySignedPayloadHelper] json unmarshal payload failed, err: %v", err)
+	}
+	if signed.Signer == "" {
+		return nil, fmt.Errorf("[VerifySignedPayloadHelper] signer is empty")
+	}
+
+	// 合约域名和通道必须与当前合约一致，防止签名在其他合约重放
+	if signed.Domain != proto.PermitDomain {
+		return nil, fmt.Errorf("[VerifySignedPayloadHelper] domain (%s) does not match (%s)", signed.Domain, proto.PermitDomain)
+	}
+	if channel := ctx.GetStub().GetChannelID(); signed.Channel != channel {
+		return nil, fmt.Errorf("[VerifySignedPayloadHelper] channel (%s) does not match (%s)", signed.Channel, channel)
+	}
+
+	if err := CheckDeadlineHelper(ctx, signed.Deadline); err != nil {
+		return nil, err
+	}
+	if err := VerifySignatureHelper(ctx, signed.Signer, []byte(payload), signature); err != nil {
+		return nil, err
+	}
+	if err := UseNonceHelper(ctx, signed.Signer, "", signed.Nonce); err != nil {
+		return nil, err
+	}
+
+	return signed, nil
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"contract-20/proto"
+	"encoding/json"
+	"fmt"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"math/big"
+	"strconv"
+)
+
+/*
+	LockedBalanceHelper: 查询账户锁仓中(尚未释放)的余额
+*/
+func LockedBalanceHelper(ctx contractapi.TransactionContextInterface, account string) (int, error) {
+	lockedKey, err := ctx.GetStub().CreateCompositeKey(proto.LockedPrefix, []string{account})
+	if err != nil {
+		return 0, fmt.Errorf("[LockedBalanceHelper] failed to create the composite key for prefix (%s): %v", proto.LockedPrefix, err)
+	}
+	lockedBytes, err := ctx.GetStub().GetState(lockedKey)
+	if err != nil {
+		return 0, fmt.Errorf("[LockedBalanceHelper] failed to read locked balance of account (%s) from world state: %v", account, err)
+	}
+	if lockedBytes == nil {
+		return 0, nil
+	}
+
+	locked, _ := strconv.Atoi(string(lockedBytes))
+
+	return locked, nil
+}
+
+/*
+	UpdateLockedBalanceHelper: 更新账户锁仓中的余额
+	delta: 变化量, 锁仓为正, 释放为负
+*/
+func UpdateLockedBalanceHelper(ctx contractapi.TransactionContextInterface, account string, delta int) error {
+	locked, err := LockedBalanceHelper(ctx, account)
+	if err != nil {
+		return err
+	}
+	locked += delta
+	if locked < 0 {
+		return fmt.Errorf("[UpdateLockedBalanceHelper] locked balance of account (%s) cannot be negative", account)
+	}
+
+	lockedKey, err := ctx.GetStub().CreateCompositeKey(proto.LockedPrefix, []string{account})
+	if err != nil {
+		return fmt.Errorf("[UpdateLockedBalanceHelper] failed to create the composite key for prefix (%s): %v", proto.LockedPrefix, err)
+	}
+	if locked == 0 {
+		err = ctx.GetStub().DelState(lockedKey)
+	} else {
+		err = ctx.GetStub().PutState(lockedKey, []byte(strconv.Itoa(locked)))
+	}
+	if err != nil {
+		return fmt.Errorf("[UpdateLockedBalanceHelper] failed to update locked balance of account (%s): %v", account, err)
+	}
+
+	return nil
+}
+
+/*
+	VestedAmountHelper: 计算锁仓计划在某一时刻已解锁的总量(含已释放部分)
+	now: 交易时间戳(Unix秒)
+*/
+func VestedAmountHelper(vesting *proto.Vesting, now int64) int {
+	elapsed := now - vesting.Start
+	if elapsed < vesting.Cliff {
+		return 0
+	}
+	if elapsed >= vesting.Duration {
+		return vesting.Total
+	}
+
+	// total * elapsed / duration, 使用大数避免溢出
+	vested := new(big.Int).Mul(big.NewInt(int64(vesting.Total)), big.NewInt(elapsed))
+	vested.Quo(vested, big.NewInt(vesting.Duration))
+
+	return int(vested.Int64())
+}
+
+/*
+	VestingSchedulesHelper: 查询账户的全部锁仓计划
+*/
+func VestingSchedulesHelper(ctx contractapi.TransactionContextInterface, beneficiary string) ([]*proto.Vesting, error) {
+	vestingIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(proto.VestingPrefix, []string{beneficiary})
+	if err != nil {
+		return nil, fmt.Errorf("[VestingSchedulesHelper] failed to get state for prefix %v: %v", proto.VestingPrefix, err)
+	}
+	defer vestingIterator.Close()
+
+	schedules := make([]*proto.Vesting, 0)
+	for vestingIterator.HasNext() {
+		queryResponse, err := vestingIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("[VestingSchedulesHelper] failed to get the next state for prefix %v: %v", proto.VestingPrefix, err)
+		}
+
+		vesting := new(proto.Vesting)
+		if err = json.Unmarshal(queryResponse.Value, vesting); err != nil {
+			return nil, fmt.Errorf("[VestingSchedulesHelper] json unmarshal vesting failed, err: %v", err)
+		}
+		schedules = append(schedules, vesting)
+	}
+
+	return schedules, nil
+}
+
+/*
+	PutVestingHelper: 保存锁仓计划
+*/
+func PutVestingHelper(ctx contractapi.TransactionContextInterface, vesting *proto.Vesting) error {
+	vestingKey, err := ctx.GetStub().CreateCompositeKey(proto.VestingPrefix, []string{vesting.Beneficiary, vesting.ID})
+	if err != nil {
+		return fmt.Errorf("[PutVestingHelper] failed to create the composite key for prefix (%s): %v", proto.VestingPrefix, err)
+	}
+	vestingBytes, err := json.Marshal(vesting)
+	if err != nil {
+		return fmt.Errorf("[PutVestingHelper] failed to obtain JSON encoding: %v", err)
+	}
+	if err = ctx.GetStub().PutState(vestingKey, vestingBytes); err != nil {
+		return fmt.Errorf("[PutVestingHelper] failed to put vesting (%s): %v", vestingKey, err)
+	}
+
+	return nil
+}
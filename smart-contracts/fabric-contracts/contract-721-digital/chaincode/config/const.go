@@ -7,11 +7,102 @@ const (
 	NftPrefix      = "nft"
 	UriPrefix      = "uniqueUri"
 	ApprovalPrefix = "approvalAll"
+	SupplyPrefix   = "supply"
+
+	CollectionPrefix      = "collection"
+	CollectionTokenPrefix = "collectionToken"
+
+	AdminPrefix     = "admin"
+	MinterPrefix    = "minter"
+	MinterMspPrefix = "minterMsp"
+
+	ContentHashPrefix = "contentHash"
+	ReceiverPrefix    = "receiver"
+	ModeratorPrefix   = "moderator"
+	TombstonePrefix   = "tombstone"
+
+	TransferRulePrefix           = "transferRule"
+	CollectionTransferRulePrefix = "collectionTransferRule"
+
+	LockerPrefix    = "locker"
+	TokenLockPrefix = "tokenLock"
+
+	FractionVaultPrefix  = "fractionVault"
+	FractionSharesPrefix = "fractionShares"
+
+	AuctionPrefix = "auction"
 
 	// Define key names for options
 
-	NameKey   = "name"
-	SymbolKey = "symbol"
+	NameKey              = "name"
+	SymbolKey            = "symbol"
+	TotalSupplyKey       = "totalSupply"
+	AdminInitKey         = "adminInitialized"
+	ReserveUriKey        = "reserveBurnedUri"
+	AuctionConfigKey     = "auctionConfig"
+	IdentityChaincodeKey = "identityChaincode"
+
+	// Define the MSP whose admin certificates may initialize the contract admin
+
+	AdminMSPID = "yilvtong"
+	AdminOU    = "admin"
+
+	// Define account and function names
+
+	EmptyAccount         = "0x0"
+	FractionVaultAccount = "fractionVault"
+	AuctionEscrowAccount = "auctionEscrow"
+	OnERC721ReceivedFcn  = "OnERC721Received"
+
+	// Define function names of the stablecoin chaincode
+
+	CoinsHoldFcn             = "Hold"
+	CoinsReleaseHoldFcn      = "ReleaseHold"
+	CoinsExecuteHoldSplitFcn = "ExecuteHoldSplit"
+	CoinsRetrieveHoldDataFcn = "RetrieveHoldData"
+	CoinsTransferBatchFcn    = "TransferBatch"
+	CoinsResolveAccountFcn   = "ResolveAccount"
+	CoinsUseNonceFcn         = "UseNonce"
+	CoinsNoncesOfFcn         = "NoncesOf"
+
+	// Define the domain bound into off-chain signed payloads
+
+	PermitDomain = "contract-721-digital"
+)
+
+const (
+	// Royalty is expressed in basis points of the sale price
+
+	MaxRoyalty = 10000
+)
+
+const (
+	// Define transfer modes of a token or collection
+
+	TransferModeFree      = "free"
+	TransferModeSoulbound = "soulbound"
+	TransferModeTimelock  = "timelock"
+	TransferModeAllowlist = "allowlist"
+)
+
+const (
+	// Define auction types and states
+
+	AuctionTypeEnglish = "english"
+	AuctionTypeDutch   = "dutch"
+
+	AuctionStatusActive    = "active"
+	AuctionStatusSettled   = "settled"
+	AuctionStatusUnsold    = "unsold"
+	AuctionStatusCancelled = "cancelled"
+
+	// A held bid expires this many seconds after the auction ends if nobody settles it
+
+	AuctionHoldGracePeriod = 7 * 24 * 60 * 60
+
+	// Define the state of a hold that can still be executed
+
+	CoinsHoldStatusOrdered = "ordered"
 )
 
 const (
@@ -20,4 +111,13 @@ const (
 	CODE_MINT_TOKEN_EXISTS    = 2
 	CODE_MINT_TOKENID_MINTED  = 3
 	CODE_MINT_TOKENURI_MINTED = 4
+
+	CODE_MINT_COLLECTION_NOT_FOUND = 5
+	CODE_MINT_COLLECTION_FULL      = 6
+	CODE_MINT_COLLECTION_FORBIDDEN = 7
+
+	CODE_MINT_UNAUTHORIZED   = 8
+	CODE_MINT_MINTER_REVOKED = 9
+
+	CODE_MINT_CONTENT_HASH_MINTED = 10
 )
@@ -0,0 +1,360 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define access control struct
+*/
+
+type DigitalUgcMinterData struct {
+	Subject   string
+	Allowed   bool
+	UpdatedBy string
+}
+
+type EventAdminUpdated struct {
+	Account  string
+	Enabled  bool
+	Operator string
+}
+
+type EventMinterUpdated struct {
+	Subject  string
+	IsMsp    bool
+	Allowed  bool
+	Operator string
+}
+
+// ============== Access control extension ===============
+
+// InitAdmin
+// @title       InitAdmin
+// @description "InitAdmin makes the caller the first admin, it can only be called once by an admin certificate of the configured MSP"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @return                bool                         "Return whether the admin was initialized or not"
+func (ugc *DigitalUgcContact) InitAdmin(ctx contractapi.TransactionContextInterface) (bool, error) {
+	if err := ugc._authorizeAdminMSP(ctx); err != nil {
+		return false, fmt.Errorf("[InitAdmin] _authorizeAdminMSP error, throw-err: %v", err)
+	}
+
+	initBytes, err := ctx.GetStub().GetState(config.AdminInitKey)
+	if err != nil {
+		return false, fmt.Errorf("[InitAdmin] GetState[ %s ] error, throw-err: %v", config.AdminInitKey, err)
+	}
+	if len(initBytes) > 0 {
+		return false, fmt.Errorf("[InitAdmin] The admin was already initialized")
+	}
+
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[InitAdmin] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	err = ugc._setAdmin(ctx, sender, true, sender)
+	if err != nil {
+		return false, fmt.Errorf("[InitAdmin] _setAdmin error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().PutState(config.AdminInitKey, []byte{'0'})
+	if err != nil {
+		return false, fmt.Errorf("[InitAdmin] PutState[ %s ] error, throw-err: %v", config.AdminInitKey, err)
+	}
+
+	return true, nil
+}
+
+// SetAdmin
+// @title       SetAdmin
+// @description "SetAdmin grants or revokes the admin role, admin only"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       account   string                       "The client to update"
+// @param       enabled   bool                         "True to grant the admin role, false to revoke it"
+// @return                bool                         "Return whether the update was successful or not"
+func (ugc *DigitalUgcContact) SetAdmin(ctx contractapi.TransactionContextInterface, account string, enabled bool) (bool, error) {
+	if account = utils.StringStrip(account); account == "" {
+		return false, fmt.Errorf("[SetAdmin] account was empty")
+	}
+
+	if err := ugc._authorizeAdmin(ctx); err != nil {
+		return false, fmt.Errorf("[SetAdmin] _authorizeAdmin error, throw-err: %v", err)
+	}
+
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[SetAdmin] _clientAccount for sender error, throw-err: %v", err)
+	}
+	if sender == account && !enabled {
+		return false, fmt.Errorf("[SetAdmin] An admin cannot revoke itself")
+	}
+
+	err = ugc._setAdmin(ctx, account, enabled, sender)
+	if err != nil {
+		return false, fmt.Errorf("[SetAdmin] _setAdmin error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// IsAdmin
+// @title       IsAdmin
+// @description "IsAdmin returns if a client holds the admin role"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       account   string                       "The client to check"
+// @return                bool                         "Return true if the client is an admin"
+func (ugc *DigitalUgcContact) IsAdmin(ctx contractapi.TransactionContextInterface, account string) (bool, error) {
+	adminKey, err := ctx.GetStub().CreateCompositeKey(config.AdminPrefix, []string{account})
+	if err != nil {
+		return false, fmt.Errorf("[IsAdmin] CreateCompositeKey[ adminKey: %s%s ] error, throw-err: %v", config.AdminPrefix, account, err)
+	}
+	adminBytes, err := ctx.GetStub().GetState(adminKey)
+	if err != nil {
+		return false, fmt.Errorf("[IsAdmin] GetState[ adminKey: %s ] error, throw-err: %v", adminKey, err)
+	}
+
+	return len(adminBytes) > 0, nil
+}
+
+// SetMinter
+// @title       SetMinter
+// @description "SetMinter allows or denies a single identity to mint, the identity entry overrides its MSP entry, admin only"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       account   string                       "The client to update"
+// @param       allowed   bool                         "True to allow minting, false to deny it"
+// @return                bool                         "Return whether the update was successful or not"
+func (ugc *DigitalUgcContact) SetMinter(ctx contractapi.TransactionContextInterface, account string, allowed bool) (bool, error) {
+	if account = utils.StringStrip(account); account == "" {
+		return false, fmt.Errorf("[SetMinter] account was empty")
+	}
+
+	err := ugc._setMinter(ctx, config.MinterPrefix, account, allowed)
+	if err != nil {
+		return false, fmt.Errorf("[SetMinter] _setMinter error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// SetMinterMSP
+// @title       SetMinterMSP
+// @description "SetMinterMSP allows or denies every identity of an MSP to mint, admin only"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       mspId     string                       "The MSP to update"
+// @param       allowed   bool                         "True to allow minting, false to deny it"
+// @return                bool                         "Return whether the update was successful or not"
+func (ugc *DigitalUgcContact) SetMinterMSP(ctx contractapi.TransactionContextInterface, mspId string, allowed bool) (bool, error) {
+	if mspId = utils.StringStrip(mspId); mspId == "" {
+		return false, fmt.Errorf("[SetMinterMSP] mspId was empty")
+	}
+
+	err := ugc._setMinter(ctx, config.MinterMspPrefix, mspId, allowed)
+	if err != nil {
+		return false, fmt.Errorf("[SetMinterMSP] _setMinter error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// IsMinter
+// @title       IsMinter
+// @description "IsMinter returns if a client of an MSP may mint"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       account   string                       "The client to check"
+// @param       mspId     string                       "The MSP of the client"
+// @return                bool                         "Return true if the client may mint"
+func (ugc *DigitalUgcContact) IsMinter(ctx contractapi.TransactionContextInterface, account string, mspId string) (bool, error) {
+	code, err := ugc._checkMinter(ctx, account, mspId)
+	if err != nil {
+		return false, fmt.Errorf("[IsMinter] _checkMinter error, throw-err: %v", err)
+	}
+
+	return code == config.CODE_MINT_SUCCESS, nil
+}
+
+// 校验调用者是否有管理权限
+func (ugc *DigitalUgcContact) _authorizeAdmin(ctx contractapi.TransactionContextInterface) error {
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("[_authorizeAdmin] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	isAdmin, err := ugc.IsAdmin(ctx, sender)
+	if err != nil {
+		return fmt.Errorf("[_authorizeAdmin] IsAdmin error, throw-err: %v", err)
+	}
+	if !isAdmin {
+		return fmt.Errorf("[_authorizeAdmin] client is not an admin")
+	}
+
+	return nil
+}
+
+// 校验调用者是否为配置的管理组织的 admin 证书 (NodeOU 为 admin)
+func (ugc *DigitalUgcContact) _authorizeAdminMSP(ctx contractapi.TransactionContextInterface) error {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("[_authorizeAdminMSP] GetClientIdentity.GetMSPID error, throw-err: %v", err)
+	}
+	if clientMSPID != config.AdminMSPID {
+		return fmt.Errorf("[_authorizeAdminMSP] client MSP[ %s ] is not the admin MSP[ %s ]", clientMSPID, config.AdminMSPID)
+	}
+
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return fmt.Errorf("[_authorizeAdminMSP] GetClientIdentity.GetX509Certificate error, throw-err: %v", err)
+	}
+	if cert == nil {
+		return fmt.Errorf("[_authorizeAdminMSP] client certificate was empty")
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == config.AdminOU {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("[_authorizeAdminMSP] client is not an admin of the MSP[ %s ]", clientMSPID)
+}
+
+// 校验调用者是否有铸造权限, 返回铸造错误码
+func (ugc *DigitalUgcContact) _authorizeMinter(ctx contractapi.TransactionContextInterface) (int, error) {
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[_authorizeMinter] _clientAccount for sender error, throw-err: %v", err)
+	}
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[_authorizeMinter] GetClientIdentity.GetMSPID error, throw-err: %v", err)
+	}
+
+	code, err := ugc._checkMinter(ctx, sender, clientMSPID)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[_authorizeMinter] _checkMinter error, throw-err: %v", err)
+	}
+	switch code {
+	case config.CODE_MINT_MINTER_REVOKED:
+		return code, fmt.Errorf("[_authorizeMinter] The minter role of the client was revoked")
+	case config.CODE_MINT_UNAUTHORIZED:
+		return code, fmt.Errorf("[_authorizeMinter] Neither the client nor its MSP[ %s ] is an allowlisted minter", clientMSPID)
+	}
+
+	return config.CODE_MINT_SUCCESS, nil
+}
+
+// 查询铸造白名单, 身份的记录优先于 MSP 的记录
+func (ugc *DigitalUgcContact) _checkMinter(ctx contractapi.TransactionContextInterface, account string, mspId string) (int, error) {
+	minter, err := ugc._readMinter(ctx, config.MinterPrefix, account)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[_checkMinter] _readMinter[ account: %s ] error, throw-err: %v", account, err)
+	}
+	if minter != nil {
+		if minter.Allowed {
+			return config.CODE_MINT_SUCCESS, nil
+		}
+		return config.CODE_MINT_MINTER_REVOKED, nil
+	}
+
+	mspMinter, err := ugc._readMinter(ctx, config.MinterMspPrefix, mspId)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[_checkMinter] _readMinter[ mspId: %s ] error, throw-err: %v", mspId, err)
+	}
+	if mspMinter != nil && mspMinter.Allowed {
+		return config.CODE_MINT_SUCCESS, nil
+	}
+
+	return config.CODE_MINT_UNAUTHORIZED, nil
+}
+
+func (ugc *DigitalUgcContact) _readMinter(ctx contractapi.TransactionContextInterface, prefix string, subject string) (*DigitalUgcMinterData, error) {
+	minterKey, err := ctx.GetStub().CreateCompositeKey(prefix, []string{subject})
+	if err != nil {
+		return nil, fmt.Errorf("[_readMinter] CreateCompositeKey[ minterKey: %s%s ] error, throw-err: %v", prefix, subject, err)
+	}
+	minterBytes, err := ctx.GetStub().GetState(minterKey)
+	if err != nil {
+		return nil, fmt.Errorf("[_readMinter] GetState[ minterKey: %s ] error, throw-err: %v", minterKey, err)
+	}
+	if len(minterBytes) <= 0 {
+		return nil, nil
+	}
+
+	minter := new(DigitalUgcMinterData)
+	err = json.Unmarshal(minterBytes, minter)
+	if err != nil {
+		return nil, fmt.Errorf("[_readMinter] Json Unmarshal[ minter ] error, throw-err: %v", err)
+	}
+
+	return minter, nil
+}
+
+func (ugc *DigitalUgcContact) _setMinter(ctx contractapi.TransactionContextInterface, prefix string, subject string, allowed bool) error {
+	if err := ugc._authorizeAdmin(ctx); err != nil {
+		return fmt.Errorf("[_setMinter] _authorizeAdmin error, throw-err: %v", err)
+	}
+
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("[_setMinter] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	minterKey, err := ctx.GetStub().CreateCompositeKey(prefix, []string{subject})
+	if err != nil {
+		return fmt.Errorf("[_setMinter] CreateCompositeKey[ minterKey: %s%s ] error, throw-err: %v", prefix, subject, err)
+	}
+	minterBytes, err := json.Marshal(DigitalUgcMinterData{Subject: subject, Allowed: allowed, UpdatedBy: sender})
+	if err != nil {
+		return fmt.Errorf("[_setMinter] Json Marshal[ minterBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().PutState(minterKey, minterBytes)
+	if err != nil {
+		return fmt.Errorf("[_setMinter] PutState[ minterKey, minterBytes ] error, throw-err: %v", err)
+	}
+
+	// Emit the MinterUpdated event
+	newEventBytes, err := json.Marshal(EventMinterUpdated{
+		Subject:  subject,
+		IsMsp:    prefix == config.MinterMspPrefix,
+		Allowed:  allowed,
+		Operator: sender,
+	})
+	if err != nil {
+		return fmt.Errorf("[_setMinter] Json Marshal[ newEventBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("MinterUpdated", newEventBytes)
+	if err != nil {
+		return fmt.Errorf("[_setMinter] SetEvent[ newEventBytes ] error, throw-err: %v", err)
+	}
+
+	return nil
+}
+
+func (ugc *DigitalUgcContact) _setAdmin(ctx contractapi.TransactionContextInterface, account string, enabled bool, operator string) error {
+	adminKey, err := ctx.GetStub().CreateCompositeKey(config.AdminPrefix, []string{account})
+	if err != nil {
+		return fmt.Errorf("[_setAdmin] CreateCompositeKey[ adminKey: %s%s ] error, throw-err: %v", config.AdminPrefix, account, err)
+	}
+	if enabled {
+		err = ctx.GetStub().PutState(adminKey, []byte{'0'})
+	} else {
+		err = ctx.GetStub().DelState(adminKey)
+	}
+	if err != nil {
+		return fmt.Errorf("[_setAdmin] update adminKey[ %s ] error, throw-err: %v", adminKey, err)
+	}
+
+	// Emit the AdminUpdated event
+	newEventBytes, err := json.Marshal(EventAdminUpdated{Account: account, Enabled: enabled, Operator: operator})
+	if err != nil {
+		return fmt.Errorf("[_setAdmin] Json Marshal[ newEventBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("AdminUpdated", newEventBytes)
+	if err != nil {
+		return fmt.Errorf("[_setAdmin] SetEvent[ newEventBytes ] error, throw-err: %v", err)
+	}
+
+	return nil
+}
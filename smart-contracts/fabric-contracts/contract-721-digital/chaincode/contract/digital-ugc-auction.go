@@ -0,0 +1,706 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define auction struct
+*/
+
+type DigitalUgcAuctionConfigData struct {
+	CoinsChaincode  string
+	CoinsChannel    string
+	NotaryChaincode string
+	FeeCollector    string
+	FeeRate         int
+}
+
+type DigitalUgcAuctionData struct {
+	TokenId       string
+	Seller        string
+	AuctionType   string
+	ReservePrice  int
+	StartPrice    int
+	StartTime     int64
+	EndTime       int64
+	HighestBidder string
+	HighestBid    int
+	HoldId        string
+	Status        string
+}
+
+// ============== Auction extension ===============
+
+// SetAuctionConfig
+// @title       SetAuctionConfig
+// @description "SetAuctionConfig sets the stablecoin chaincode used for bids and the platform fee, admin only"
+// @param       ctx              TransactionContextInterface  "ctx the transaction context"
+// @param       coinsChaincode   string                       "The name of the stablecoin chaincode"
+// @param       coinsChannel     string                       "The channel of the stablecoin chaincode, empty for the current channel"
+// @param       notaryChaincode  string                       "The name of this chaincode, registered as a hold notary in the stablecoin chaincode"
+// @param       feeCollector     string                       "The account receiving the platform fee"
+// @param       feeRate          int                          "Platform fee in basis points of the sale price"
+// @return                       bool                         "Return whether the update was successful or not"
+func (ugc *DigitalUgcContact) SetAuctionConfig(ctx contractapi.TransactionContextInterface, coinsChaincode string, coinsChannel string, notaryChaincode string, feeCollector string, feeRate int) (bool, error) {
+	if utils.StringStrip(coinsChaincode) == "" || utils.StringStrip(notaryChaincode) == "" {
+		return false, fmt.Errorf("[SetAuctionConfig] coinsChaincode and notaryChaincode must not be empty")
+	}
+	if feeRate < 0 || feeRate > config.MaxRoyalty {
+		return false, fmt.Errorf("[SetAuctionConfig] feeRate[ %d ] must be between 0 and %d", feeRate, config.MaxRoyalty)
+	}
+	if feeRate > 0 && (utils.StringStrip(feeCollector) == "" || feeCollector == config.EmptyAccount) {
+		return false, fmt.Errorf("[SetAuctionConfig] feeCollector was empty or the zero address")
+	}
+
+	if err := ugc._authorizeAdmin(ctx); err != nil {
+		return false, fmt.Errorf("[SetAuctionConfig] _authorizeAdmin error, throw-err: %v", err)
+	}
+
+	configBytes, err := json.Marshal(DigitalUgcAuctionConfigData{
+		CoinsChaincode:  coinsChaincode,
+		CoinsChannel:    coinsChannel,
+		NotaryChaincode: notaryChaincode,
+		FeeCollector:    feeCollector,
+		FeeRate:         feeRate,
+	})
+	if err != nil {
+		return false, fmt.Errorf("[SetAuctionConfig] Json Marshal[ configBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().PutState(config.AuctionConfigKey, configBytes)
+	if err != nil {
+		return false, fmt.Errorf("[SetAuctionConfig] PutState[ %s ] error, throw-err: %v", config.AuctionConfigKey, err)
+	}
+
+	return true, nil
+}
+
+// GetAuctionConfig
+// @title       GetAuctionConfig
+// @description "GetAuctionConfig returns the stablecoin chaincode and platform fee used by auctions"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @return                DigitalUgcAuctionConfigData  "Return the auction config"
+func (ugc *DigitalUgcContact) GetAuctionConfig(ctx contractapi.TransactionContextInterface) (*DigitalUgcAuctionConfigData, error) {
+	auctionConfig, err := ugc._readAuctionConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[GetAuctionConfig] _readAuctionConfig error, throw-err: %v", err)
+	}
+
+	return auctionConfig, nil
+}
+
+// CreateAuction
+// @title       CreateAuction
+// @description "CreateAuction moves a token into the auction escrow and opens an English or Dutch auction for it"
+// @param       ctx           TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId       string                       "Unique ID of a non-fungible token owned by the caller"
+// @param       auctionType   string                       "english or dutch"
+// @param       reservePrice  int                          "The lowest acceptable bid, the floor price of a Dutch auction"
+// @param       start         int64                        "The Unix time bidding opens"
+// @param       end           int64                        "The Unix time bidding closes"
+// @param       startPrice    int                          "The opening price of a Dutch auction, must be 0 for an English auction"
+// @return                    bool                         "Return whether the auction was created or not"
+func (ugc *DigitalUgcContact) CreateAuction(ctx contractapi.TransactionContextInterface, tokenId string, auctionType string, reservePrice int, start int64, end int64, startPrice int) (bool, error) {
+	if tokenId = utils.StringStrip(tokenId); tokenId == "" {
+		return false, fmt.Errorf("[CreateAuction] tokenId was empty")
+	}
+	if reservePrice <= 0 {
+		return false, fmt.Errorf("[CreateAuction] reservePrice[ %d ] must be positive", reservePrice)
+	}
+	switch auctionType {
+	case config.AuctionTypeEnglish:
+		if startPrice != 0 {
+			return false, fmt.Errorf("[CreateAuction] startPrice must be 0 for an English auction")
+		}
+	case config.AuctionTypeDutch:
+		if startPrice <= reservePrice {
+			return false, fmt.Errorf("[CreateAuction] startPrice[ %d ] must be higher than reservePrice[ %d ]", startPrice, reservePrice)
+		}
+	default:
+		return false, fmt.Errorf("[CreateAuction] Unknown auction type[ %s ]", auctionType)
+	}
+
+	now, err := ugc._txTime(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[CreateAuction] _txTime error, throw-err: %v", err)
+	}
+	if end <= start || end <= now {
+		return false, fmt.Errorf("[CreateAuction] end[ %d ] must be later than start[ %d ] and the transaction time[ %d ]", end, start, now)
+	}
+
+	auctionConfig, err := ugc._readAuctionConfig(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[CreateAuction] _readAuctionConfig error, throw-err: %v", err)
+	}
+
+	seller, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[CreateAuction] _clientAccount for sender error, throw-err: %v", err)
+	}
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[CreateAuction] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+	if nft.Owner != seller {
+		return false, fmt.Errorf("[CreateAuction] Non-fungible token %s is not owned by %s", tokenId, seller)
+	}
+
+	// Royalties and the platform fee must leave something for the seller
+	royalty, _, err := ugc._royaltyOf(ctx, nft)
+	if err != nil {
+		return false, fmt.Errorf("[CreateAuction] _royaltyOf error, throw-err: %v", err)
+	}
+	if royalty+auctionConfig.FeeRate > config.MaxRoyalty {
+		return false, fmt.Errorf("[CreateAuction] The royalty[ %d ] and fee[ %d ] exceed %d", royalty, auctionConfig.FeeRate, config.MaxRoyalty)
+	}
+
+	// A locked token stays in place
+	lock, err := ugc._readTokenLock(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[CreateAuction] _readTokenLock error, throw-err: %v", err)
+	}
+	if lock != nil {
+		return false, fmt.Errorf("[CreateAuction] The tokenId[ %v ] is locked by[ %s ]", tokenId, lock.Locker)
+	}
+
+	// Moving into the escrow follows the soulbound, timelock and allowlist rules
+	reason, err := ugc._checkTransferRule(ctx, seller, config.AuctionEscrowAccount, nft)
+	if err != nil {
+		return false, fmt.Errorf("[CreateAuction] _checkTransferRule error, throw-err: %v", err)
+	}
+	if reason != "" {
+		return false, fmt.Errorf("[CreateAuction] %s", reason)
+	}
+
+	err = ugc._moveNFT(ctx, nft, seller, config.AuctionEscrowAccount, "")
+	if err != nil {
+		return false, fmt.Errorf("[CreateAuction] _moveNFT error, throw-err: %v", err)
+	}
+
+	err = ugc._putAuction(ctx, &DigitalUgcAuctionData{
+		TokenId:      tokenId,
+		Seller:       seller,
+		AuctionType:  auctionType,
+		ReservePrice: reservePrice,
+		StartPrice:   startPrice,
+		StartTime:    start,
+		EndTime:      end,
+		Status:       config.AuctionStatusActive,
+	}, "AuctionCreated")
+	if err != nil {
+		return false, fmt.Errorf("[CreateAuction] _putAuction error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// Bid
+// @title       Bid
+// @description "Bid places a bid, an English bid is held in the stablecoin chaincode and the previous high bid is released, a Dutch bid at or above the current price buys the token at that price"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a token in auction"
+// @param       amount    int                          "The bid, the most the caller will pay for a Dutch auction"
+// @return                bool                         "Return whether the bid was accepted or not"
+func (ugc *DigitalUgcContact) Bid(ctx contractapi.TransactionContextInterface, tokenId string, amount int) (bool, error) {
+	auction, err := ugc._readAuction(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[Bid] _readAuction error, throw-err: %v", err)
+	}
+	if auction.Status != config.AuctionStatusActive {
+		return false, fmt.Errorf("[Bid] The auction of tokenId[ %v ] is %s", tokenId, auction.Status)
+	}
+
+	now, err := ugc._txTime(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[Bid] _txTime error, throw-err: %v", err)
+	}
+	if now < auction.StartTime || now >= auction.EndTime {
+		return false, fmt.Errorf("[Bid] The auction of tokenId[ %v ] is open from %d to %d", tokenId, auction.StartTime, auction.EndTime)
+	}
+
+	bidder, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[Bid] _clientAccount for sender error, throw-err: %v", err)
+	}
+	if bidder == auction.Seller {
+		return false, fmt.Errorf("[Bid] The seller cannot bid")
+	}
+
+	// The winner must be able to receive the token from the seller, the escrow only holds it
+	// and the token leaves the escrow without another rule check
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[Bid] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+	reason, err := ugc._checkTransferRule(ctx, auction.Seller, bidder, nft)
+	if err != nil {
+		return false, fmt.Errorf("[Bid] _checkTransferRule error, throw-err: %v", err)
+	}
+	if reason != "" {
+		return false, fmt.Errorf("[Bid] %s", reason)
+	}
+
+	auctionConfig, err := ugc._readAuctionConfig(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[Bid] _readAuctionConfig error, throw-err: %v", err)
+	}
+
+	if auction.AuctionType == config.AuctionTypeDutch {
+		price := ugc._dutchPrice(auction, now)
+		if amount < price {
+			return false, fmt.Errorf("[Bid] The bid[ %d ] is below the current price[ %d ]", amount, price)
+		}
+
+		// Pay the seller, the creator and the platform directly from the bidder
+		recipients, amounts, err := ugc._auctionPayments(ctx, auctionConfig, auction.Seller, bidder, nft, price)
+		if err != nil {
+			return false, fmt.Errorf("[Bid] _auctionPayments error, throw-err: %v", err)
+		}
+		if len(recipients) > 0 {
+			recipientsBytes, err := json.Marshal(recipients)
+			if err != nil {
+				return false, fmt.Errorf("[Bid] Json Marshal[ recipientsBytes ] error, throw-err: %v", err)
+			}
+			amountsBytes, err := json.Marshal(amounts)
+			if err != nil {
+				return false, fmt.Errorf("[Bid] Json Marshal[ amountsBytes ] error, throw-err: %v", err)
+			}
+			_, err = ugc._invokeCoins(ctx, auctionConfig, config.CoinsTransferBatchFcn, string(recipientsBytes), string(amountsBytes))
+			if err != nil {
+				return false, fmt.Errorf("[Bid] _invokeCoins error, throw-err: %v", err)
+			}
+		}
+
+		err = ugc._moveNFT(ctx, nft, config.AuctionEscrowAccount, bidder, "")
+		if err != nil {
+			return false, fmt.Errorf("[Bid] _moveNFT error, throw-err: %v", err)
+		}
+
+		auction.HighestBidder = bidder
+		auction.HighestBid = price
+		auction.Status = config.AuctionStatusSettled
+		err = ugc._putAuction(ctx, auction, "AuctionSettled")
+		if err != nil {
+			return false, fmt.Errorf("[Bid] _putAuction error, throw-err: %v", err)
+		}
+
+		return true, nil
+	}
+
+	// A high bid whose hold is gone no longer counts
+	err = ugc._dropInactiveBid(ctx, auctionConfig, auction, now)
+	if err != nil {
+		return false, fmt.Errorf("[Bid] _dropInactiveBid error, throw-err: %v", err)
+	}
+	if amount < auction.ReservePrice || amount <= auction.HighestBid {
+		return false, fmt.Errorf("[Bid] The bid[ %d ] must reach the reserve price[ %d ] and beat the highest bid[ %d ]", amount, auction.ReservePrice, auction.HighestBid)
+	}
+
+	// Refund the previous high bidder, then hold the new bid with this chaincode as notary
+	if auction.HoldId != "" {
+		_, err = ugc._invokeCoins(ctx, auctionConfig, config.CoinsReleaseHoldFcn, auction.HoldId)
+		if err != nil {
+			return false, fmt.Errorf("[Bid] _invokeCoins error, throw-err: %v", err)
+		}
+	}
+
+	// The escrow account is the payee so nobody but the notary can release the hold before it expires,
+	// the hold outlives the auction by a grace period for the settlement
+	holdId := fmt.Sprintf("%s-%s-%s", config.AuctionPrefix, tokenId, ctx.GetStub().GetTxID())
+	expiry := strconv.FormatInt(auction.EndTime+config.AuctionHoldGracePeriod, 10)
+	_, err = ugc._invokeCoins(ctx, auctionConfig, config.CoinsHoldFcn, holdId, bidder, config.AuctionEscrowAccount, strconv.Itoa(amount), expiry, auctionConfig.NotaryChaincode)
+	if err != nil {
+		return false, fmt.Errorf("[Bid] _invokeCoins error, throw-err: %v", err)
+	}
+
+	auction.HighestBidder = bidder
+	auction.HighestBid = amount
+	auction.HoldId = holdId
+	err = ugc._putAuction(ctx, auction, "AuctionBid")
+	if err != nil {
+		return false, fmt.Errorf("[Bid] _putAuction error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// Settle
+// @title       Settle
+// @description "Settle closes an auction after it ends, the winner gets the token and the seller is paid minus royalties and fees, an unsold token or one whose winning hold has expired goes back to the seller"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a token in auction"
+// @return                bool                         "Return whether the settlement was successful or not"
+func (ugc *DigitalUgcContact) Settle(ctx contractapi.TransactionContextInterface, tokenId string) (bool, error) {
+	auction, err := ugc._readAuction(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[Settle] _readAuction error, throw-err: %v", err)
+	}
+	if auction.Status != config.AuctionStatusActive {
+		return false, fmt.Errorf("[Settle] The auction of tokenId[ %v ] is %s", tokenId, auction.Status)
+	}
+
+	now, err := ugc._txTime(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[Settle] _txTime error, throw-err: %v", err)
+	}
+	if now < auction.EndTime {
+		return false, fmt.Errorf("[Settle] The auction of tokenId[ %v ] ends at %d", tokenId, auction.EndTime)
+	}
+
+	auctionConfig, err := ugc._readAuctionConfig(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[Settle] _readAuctionConfig error, throw-err: %v", err)
+	}
+
+	// A high bid whose hold is gone no longer counts
+	err = ugc._dropInactiveBid(ctx, auctionConfig, auction, now)
+	if err != nil {
+		return false, fmt.Errorf("[Settle] _dropInactiveBid error, throw-err: %v", err)
+	}
+
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[Settle] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+
+	// Nobody bid, the token goes back to the seller
+	if auction.HighestBidder == "" {
+		err = ugc._moveNFT(ctx, nft, config.AuctionEscrowAccount, auction.Seller, "")
+		if err != nil {
+			return false, fmt.Errorf("[Settle] _moveNFT error, throw-err: %v", err)
+		}
+
+		auction.Status = config.AuctionStatusUnsold
+		err = ugc._putAuction(ctx, auction, "AuctionSettled")
+		if err != nil {
+			return false, fmt.Errorf("[Settle] _putAuction error, throw-err: %v", err)
+		}
+
+		return true, nil
+	}
+
+	// Split the held bid, a share owed to the winner itself stays with the winner
+	recipients, amounts, err := ugc._auctionPayments(ctx, auctionConfig, auction.Seller, auction.HighestBidder, nft, auction.HighestBid)
+	if err != nil {
+		return false, fmt.Errorf("[Settle] _auctionPayments error, throw-err: %v", err)
+	}
+	if len(recipients) > 0 {
+		var recipientsBytes, amountsBytes []byte
+		recipientsBytes, err = json.Marshal(recipients)
+		if err != nil {
+			return false, fmt.Errorf("[Settle] Json Marshal[ recipientsBytes ] error, throw-err: %v", err)
+		}
+		amountsBytes, err = json.Marshal(amounts)
+		if err != nil {
+			return false, fmt.Errorf("[Settle] Json Marshal[ amountsBytes ] error, throw-err: %v", err)
+		}
+		_, err = ugc._invokeCoins(ctx, auctionConfig, config.CoinsExecuteHoldSplitFcn, auction.HoldId, string(recipientsBytes), string(amountsBytes))
+	} else {
+		_, err = ugc._invokeCoins(ctx, auctionConfig, config.CoinsReleaseHoldFcn, auction.HoldId)
+	}
+	if err != nil {
+		return false, fmt.Errorf("[Settle] _invokeCoins error, throw-err: %v", err)
+	}
+
+	// The rule was checked against the winner when the bid was placed
+	err = ugc._moveNFT(ctx, nft, config.AuctionEscrowAccount, auction.HighestBidder, "")
+	if err != nil {
+		return false, fmt.Errorf("[Settle] _moveNFT error, throw-err: %v", err)
+	}
+
+	auction.Status = config.AuctionStatusSettled
+	err = ugc._putAuction(ctx, auction, "AuctionSettled")
+	if err != nil {
+		return false, fmt.Errorf("[Settle] _putAuction error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// CancelAuction
+// @title       CancelAuction
+// @description "CancelAuction returns the token to the seller, allowed for the seller while no bid is held"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a token in auction"
+// @return                bool                         "Return whether the cancellation was successful or not"
+func (ugc *DigitalUgcContact) CancelAuction(ctx contractapi.TransactionContextInterface, tokenId string) (bool, error) {
+	auction, err := ugc._readAuction(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[CancelAuction] _readAuction error, throw-err: %v", err)
+	}
+	if auction.Status != config.AuctionStatusActive {
+		return false, fmt.Errorf("[CancelAuction] The auction of tokenId[ %v ] is %s", tokenId, auction.Status)
+	}
+
+	// A high bid whose hold is gone no longer counts
+	if auction.HoldId != "" {
+		auctionConfig, err := ugc._readAuctionConfig(ctx)
+		if err != nil {
+			return false, fmt.Errorf("[CancelAuction] _readAuctionConfig error, throw-err: %v", err)
+		}
+		now, err := ugc._txTime(ctx)
+		if err != nil {
+			return false, fmt.Errorf("[CancelAuction] _txTime error, throw-err: %v", err)
+		}
+		err = ugc._dropInactiveBid(ctx, auctionConfig, auction, now)
+		if err != nil {
+			return false, fmt.Errorf("[CancelAuction] _dropInactiveBid error, throw-err: %v", err)
+		}
+	}
+	if auction.HighestBidder != "" {
+		return false, fmt.Errorf("[CancelAuction] The auction of tokenId[ %v ] already has a bid", tokenId)
+	}
+
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[CancelAuction] _clientAccount for sender error, throw-err: %v", err)
+	}
+	if sender != auction.Seller {
+		return false, fmt.Errorf("[CancelAuction] Only the seller can cancel the auction")
+	}
+
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[CancelAuction] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+	err = ugc._moveNFT(ctx, nft, config.AuctionEscrowAccount, auction.Seller, "")
+	if err != nil {
+		return false, fmt.Errorf("[CancelAuction] _moveNFT error, throw-err: %v", err)
+	}
+
+	auction.Status = config.AuctionStatusCancelled
+	err = ugc._putAuction(ctx, auction, "AuctionCancelled")
+	if err != nil {
+		return false, fmt.Errorf("[CancelAuction] _putAuction error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// GetAuction
+// @title       GetAuction
+// @description "GetAuction returns the latest auction of a token"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a non-fungible token"
+// @return                DigitalUgcAuctionData        "Return the auction object"
+func (ugc *DigitalUgcContact) GetAuction(ctx contractapi.TransactionContextInterface, tokenId string) (*DigitalUgcAuctionData, error) {
+	auction, err := ugc._readAuction(ctx, tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[GetAuction] _readAuction error, throw-err: %v", err)
+	}
+
+	return auction, nil
+}
+
+// AuctionPrice
+// @title       AuctionPrice
+// @description "AuctionPrice returns the current price of a Dutch auction, or the lowest acceptable next bid of an English auction"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a token in auction"
+// @return                int                          "Return the price"
+func (ugc *DigitalUgcContact) AuctionPrice(ctx contractapi.TransactionContextInterface, tokenId string) (int, error) {
+	auction, err := ugc._readAuction(ctx, tokenId)
+	if err != nil {
+		return 0, fmt.Errorf("[AuctionPrice] _readAuction error, throw-err: %v", err)
+	}
+
+	if auction.AuctionType == config.AuctionTypeDutch {
+		now, err := ugc._txTime(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("[AuctionPrice] _txTime error, throw-err: %v", err)
+		}
+		return ugc._dutchPrice(auction, now), nil
+	}
+	if auction.HighestBid >= auction.ReservePrice {
+		return auction.HighestBid + 1, nil
+	}
+
+	return auction.ReservePrice, nil
+}
+
+// 荷兰式拍卖的价格从 StartPrice 线性下降到 ReservePrice
+func (ugc *DigitalUgcContact) _dutchPrice(auction *DigitalUgcAuctionData, now int64) int {
+	if now <= auction.StartTime {
+		return auction.StartPrice
+	}
+	if now >= auction.EndTime {
+		return auction.ReservePrice
+	}
+
+	drop := int64(auction.StartPrice-auction.ReservePrice) * (now - auction.StartTime) / (auction.EndTime - auction.StartTime)
+
+	return auction.StartPrice - int(drop)
+}
+
+// 返回作品所属系列的版税(万分比)和创作者, 不属于系列时为 0
+func (ugc *DigitalUgcContact) _royaltyOf(ctx contractapi.TransactionContextInterface, nft DigitalUgcBaseData) (int, string, error) {
+	if nft.CollectionId == "" {
+		return 0, "", nil
+	}
+	collection, err := ugc._readCollection(ctx, nft.CollectionId)
+	if err != nil {
+		return 0, "", fmt.Errorf("[_royaltyOf] _readCollection collectionId[ %v ] error, throw-err: %v", nft.CollectionId, err)
+	}
+
+	return collection.Royalty, collection.Creator, nil
+}
+
+// 计算成交价的分配: 创作者版税、平台手续费, 其余归卖方
+// 相同账户合并, 付款人自己的份额和 0 金额不转账
+func (ugc *DigitalUgcContact) _auctionPayments(ctx contractapi.TransactionContextInterface, auctionConfig *DigitalUgcAuctionConfigData, seller string, payer string, nft DigitalUgcBaseData, price int) ([]string, []int, error) {
+	royalty, creator, err := ugc._royaltyOf(ctx, nft)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[_auctionPayments] _royaltyOf error, throw-err: %v", err)
+	}
+
+	royaltyAmount := price * royalty / config.MaxRoyalty
+	feeAmount := price * auctionConfig.FeeRate / config.MaxRoyalty
+	shares := []struct {
+		account string
+		amount  int
+	}{
+		{seller, price - royaltyAmount - feeAmount},
+		{creator, royaltyAmount},
+		{auctionConfig.FeeCollector, feeAmount},
+	}
+
+	recipients := make([]string, 0, len(shares))
+	amounts := make([]int, 0, len(shares))
+	index := make(map[string]int, len(shares))
+	for _, share := range shares {
+		if share.amount <= 0 || share.account == payer {
+			continue
+		}
+		if i, ok := index[share.account]; ok {
+			amounts[i] += share.amount
+			continue
+		}
+		index[share.account] = len(recipients)
+		recipients = append(recipients, share.account)
+		amounts = append(amounts, share.amount)
+	}
+
+	return recipients, amounts, nil
+}
+
+// 通过 InvokeChaincode 调用稳定币合约, 提交交易的账户身份保持不变
+func (ugc *DigitalUgcContact) _invokeCoins(ctx contractapi.TransactionContextInterface, auctionConfig *DigitalUgcAuctionConfigData, fcn string, params ...string) ([]byte, error) {
+	args := [][]byte{[]byte(fcn)}
+	for _, param := range params {
+		args = append(args, []byte(param))
+	}
+
+	response := ctx.GetStub().InvokeChaincode(auctionConfig.CoinsChaincode, args, auctionConfig.CoinsChannel)
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("[_invokeCoins] InvokeChaincode[ %s.%s ] error, throw-err: %v", auctionConfig.CoinsChaincode, fcn, response.Message)
+	}
+
+	return response.Payload, nil
+}
+
+// 查询出价的冻结是否仍可执行, 冻结被释放、执行或已过期时出价不再有效
+func (ugc *DigitalUgcContact) _holdActive(ctx contractapi.TransactionContextInterface, auctionConfig *DigitalUgcAuctionConfigData, holdId string, now int64) (bool, error) {
+	holdBytes, err := ugc._invokeCoins(ctx, auctionConfig, config.CoinsRetrieveHoldDataFcn, holdId)
+	if err != nil {
+		return false, fmt.Errorf("[_holdActive] _invokeCoins error, throw-err: %v", err)
+	}
+
+	hold := struct {
+		Expiry int64  `json:"expiry"`
+		Status string `json:"status"`
+	}{}
+	err = json.Unmarshal(holdBytes, &hold)
+	if err != nil {
+		return false, fmt.Errorf("[_holdActive] Json Unmarshal[ hold ] error, throw-err: %v", err)
+	}
+
+	return hold.Status == config.CoinsHoldStatusOrdered && (hold.Expiry == 0 || now < hold.Expiry), nil
+}
+
+// 出价的冻结失效时清除最高出价, 拍卖可以重新出价、流拍或取消
+func (ugc *DigitalUgcContact) _dropInactiveBid(ctx contractapi.TransactionContextInterface, auctionConfig *DigitalUgcAuctionConfigData, auction *DigitalUgcAuctionData, now int64) error {
+	if auction.HoldId == "" {
+		return nil
+	}
+	active, err := ugc._holdActive(ctx, auctionConfig, auction.HoldId, now)
+	if err != nil {
+		return fmt.Errorf("[_dropInactiveBid] _holdActive error, throw-err: %v", err)
+	}
+	if !active {
+		auction.HighestBidder = ""
+		auction.HighestBid = 0
+		auction.HoldId = ""
+	}
+
+	return nil
+}
+
+func (ugc *DigitalUgcContact) _readAuctionConfig(ctx contractapi.TransactionContextInterface) (*DigitalUgcAuctionConfigData, error) {
+	configBytes, err := ctx.GetStub().GetState(config.AuctionConfigKey)
+	if err != nil {
+		return nil, fmt.Errorf("[_readAuctionConfig] GetState[ %s ] error, throw-err: %v", config.AuctionConfigKey, err)
+	}
+	if len(configBytes) <= 0 {
+		return nil, fmt.Errorf("[_readAuctionConfig] Auctions are not configured")
+	}
+
+	auctionConfig := new(DigitalUgcAuctionConfigData)
+	err = json.Unmarshal(configBytes, auctionConfig)
+	if err != nil {
+		return nil, fmt.Errorf("[_readAuctionConfig] Json Unmarshal[ auctionConfig ] error, throw-err: %v", err)
+	}
+
+	return auctionConfig, nil
+}
+
+func (ugc *DigitalUgcContact) _readAuction(ctx contractapi.TransactionContextInterface, tokenId string) (*DigitalUgcAuctionData, error) {
+	auctionKey, err := ctx.GetStub().CreateCompositeKey(config.AuctionPrefix, []string{tokenId})
+	if err != nil {
+		return nil, fmt.Errorf("[_readAuction] CreateCompositeKey[ auctionKey: %s%s ] error, throw-err: %v", config.AuctionPrefix, tokenId, err)
+	}
+	auctionBytes, err := ctx.GetStub().GetState(auctionKey)
+	if err != nil {
+		return nil, fmt.Errorf("[_readAuction] GetState[ auctionKey: %s ] error, throw-err: %v", auctionKey, err)
+	}
+	if len(auctionBytes) <= 0 {
+		return nil, fmt.Errorf("[_readAuction] The tokenId[ %v ] has no auction", tokenId)
+	}
+
+	auction := new(DigitalUgcAuctionData)
+	err = json.Unmarshal(auctionBytes, auction)
+	if err != nil {
+		return nil, fmt.Errorf("[_readAuction] Json Unmarshal[ auction ] error, throw-err: %v", err)
+	}
+
+	return auction, nil
+}
+
+// 保存拍卖并触发事件, 该事件替换本交易中的 Transfer 事件
+func (ugc *DigitalUgcContact) _putAuction(ctx contractapi.TransactionContextInterface, auction *DigitalUgcAuctionData, eventName string) error {
+	auctionBytes, err := json.Marshal(auction)
+	if err != nil {
+		return fmt.Errorf("[_putAuction] Json Marshal[ auctionBytes ] error, throw-err: %v", err)
+	}
+	auctionKey, err := ctx.GetStub().CreateCompositeKey(config.AuctionPrefix, []string{auction.TokenId})
+	if err != nil {
+		return fmt.Errorf("[_putAuction] CreateCompositeKey[ auctionKey: %s%s ] error, throw-err: %v", config.AuctionPrefix, auction.TokenId, err)
+	}
+	err = ctx.GetStub().PutState(auctionKey, auctionBytes)
+	if err != nil {
+		return fmt.Errorf("[_putAuction] PutState[ auctionKey: %s ] error, throw-err: %v", auctionKey, err)
+	}
+
+	err = ctx.GetStub().SetEvent(eventName, auctionBytes)
+	if err != nil {
+		return fmt.Errorf("[_putAuction] SetEvent[ %s ] error, throw-err: %v", eventName, err)
+	}
+
+	return nil
+}
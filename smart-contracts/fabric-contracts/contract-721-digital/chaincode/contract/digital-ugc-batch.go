@@ -0,0 +1,193 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define batch report struct
+*/
+
+type DigitalUgcTransferIssue struct {
+	TokenId string
+	Reason  string
+}
+
+type DigitalUgcTransferBatchReport struct {
+	Valid  bool
+	Issues []DigitalUgcTransferIssue
+}
+
+type EventTransferMultiRecipient struct {
+	Operator string
+	From     string
+	Tos      []string
+	TokenIds []string
+}
+
+// ============== Batch transfer extension ===============
+
+// GetEvaluateTransactions tags the dry run transactions as evaluate-only in the contract metadata
+func (ugc *DigitalUgcContact) GetEvaluateTransactions() []string {
+	return []string{"DryRunTransferBatch"}
+}
+
+// DryRunTransferBatch
+// @title       DryRunTransferBatch
+// @description "DryRunTransferBatch validates a TransferFromBatch call for the caller without writing anything"
+// @param       ctx       TransactionContextInterface    "ctx the transaction context"
+// @param       from      string                         "The current owner of the non-fungible tokens"
+// @param       to        string                         "The new owner"
+// @param       tokenIds  []string                       "the non-fungible tokens to transfer"
+// @return                DigitalUgcTransferBatchReport  "Return every tokenId that cannot be transferred and the reason"
+func (ugc *DigitalUgcContact) DryRunTransferBatch(ctx contractapi.TransactionContextInterface, from string, to string, tokenIds []string) (*DigitalUgcTransferBatchReport, error) {
+
+	// 检查from
+	if content := utils.StringStrip(from); content == "" {
+		return nil, fmt.Errorf("[DryRunTransferBatch] from was empty")
+	}
+
+	// 检查to
+	if content := utils.StringStrip(to); content == "" {
+		return nil, fmt.Errorf("[DryRunTransferBatch] to was empty")
+	}
+
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[DryRunTransferBatch] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	issues, err := ugc._validateTransferBatch(ctx, sender, from, []string{to}, tokenIds)
+	if err != nil {
+		return nil, fmt.Errorf("[DryRunTransferBatch] _validateTransferBatch error, throw-err: %v", err)
+	}
+
+	return &DigitalUgcTransferBatchReport{Valid: len(issues) == 0, Issues: issues}, nil
+}
+
+// TransferFromMultiRecipient
+// @title       TransferFromMultiRecipient
+// @description "TransferFromMultiRecipient transfers some non-fungible tokens to different owners, either all of them or none"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       from      string                       "The current owner of the non-fungible tokens"
+// @param       tos       []string                     "The new owner of each token, parallel to tokenIds"
+// @param       tokenIds  []string                     "the non-fungible tokens to transfer"
+// @return                bool                         "Return whether the transfer was successful or not"
+func (ugc *DigitalUgcContact) TransferFromMultiRecipient(ctx contractapi.TransactionContextInterface, from string, tos []string, tokenIds []string) (bool, error) {
+
+	// 检查from
+	if content := utils.StringStrip(from); content == "" {
+		return false, fmt.Errorf("[TransferFromMultiRecipient] from was empty")
+	}
+
+	// 判断参数是否一致
+	if len(tokenIds) == 0 || len(tos) != len(tokenIds) {
+		return false, fmt.Errorf("[TransferFromMultiRecipient] tos length must equal tokenIds length and not be 0")
+	}
+
+	// 检查to, 不能转给空账户
+	for i := range tos {
+		if tos[i] = utils.StringStrip(tos[i]); tos[i] == "" || tos[i] == config.EmptyAccount {
+			return false, fmt.Errorf("[TransferFromMultiRecipient] tos[ %d ] was empty or the zero address", i)
+		}
+	}
+
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[TransferFromMultiRecipient] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	// 转账前校验整个批次, 列出所有不能转移的 tokenId
+	issues, err := ugc._validateTransferBatch(ctx, sender, from, tos, tokenIds)
+	if err != nil {
+		return false, fmt.Errorf("[TransferFromMultiRecipient] _validateTransferBatch error, throw-err: %v", err)
+	}
+	if len(issues) > 0 {
+		return false, fmt.Errorf("[TransferFromMultiRecipient] %d of %d tokenIds cannot be transferred: %s", len(issues), len(tokenIds), ugc._formatTransferIssues(issues))
+	}
+
+	// 转账
+	for i, tokenId := range tokenIds {
+		_, err = ugc._transform(ctx, sender, from, tos[i], tokenId, "")
+		if err != nil {
+			return false, fmt.Errorf("[TransferFromMultiRecipient] _transform error, throw-err: %v", err)
+		}
+	}
+
+	// Emit one aggregated event, it replaces the per-token Transfer events of this transaction
+	newEventBytes, err := json.Marshal(EventTransferMultiRecipient{
+		Operator: sender,
+		From:     from,
+		Tos:      tos,
+		TokenIds: tokenIds,
+	})
+	if err != nil {
+		return false, fmt.Errorf("[TransferFromMultiRecipient] Json Marshal[ newEventBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("TransferMultiRecipient", newEventBytes)
+	if err != nil {
+		return false, fmt.Errorf("[TransferFromMultiRecipient] SetEvent[ newEventBytes ] error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// 校验整批转账, 返回每个不能转移的 tokenId 和原因
+// tos 为每个 tokenId 对应的接收者, 只有一个元素时所有 tokenId 都转给它
+func (ugc *DigitalUgcContact) _validateTransferBatch(ctx contractapi.TransactionContextInterface, sender string, from string, tos []string, tokenIds []string) ([]DigitalUgcTransferIssue, error) {
+	issues := make([]DigitalUgcTransferIssue, 0)
+	if len(tokenIds) == 0 {
+		return append(issues, DigitalUgcTransferIssue{Reason: "The tokenIds list was empty"}), nil
+	}
+
+	// State written in this transaction is not readable yet, a duplicated tokenId would be checked against stale ownership
+	seen := make(map[string]bool, len(tokenIds))
+	for i, tokenId := range tokenIds {
+		to := tos[0]
+		if len(tos) > 1 {
+			to = tos[i]
+		}
+
+		if utils.StringStrip(tokenId) == "" {
+			issues = append(issues, DigitalUgcTransferIssue{TokenId: tokenId, Reason: "The tokenId was empty"})
+			continue
+		}
+		if seen[tokenId] {
+			issues = append(issues, DigitalUgcTransferIssue{TokenId: tokenId, Reason: "The tokenId is duplicated in the batch"})
+			continue
+		}
+		seen[tokenId] = true
+
+		nft, err := ugc._readNFT(ctx, tokenId)
+		if err != nil {
+			issues = append(issues, DigitalUgcTransferIssue{TokenId: tokenId, Reason: "The tokenId does not exist"})
+			continue
+		}
+
+		reason, err := ugc._checkTransfer(ctx, sender, from, to, nft)
+		if err != nil {
+			return nil, fmt.Errorf("[_validateTransferBatch] _checkTransfer for tokenId[ %v ] error, throw-err: %v", tokenId, err)
+		}
+		if reason != "" {
+			issues = append(issues, DigitalUgcTransferIssue{TokenId: tokenId, Reason: reason})
+		}
+	}
+
+	return issues, nil
+}
+
+// 将校验结果拼成错误信息
+func (ugc *DigitalUgcContact) _formatTransferIssues(issues []DigitalUgcTransferIssue) string {
+	items := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		items = append(items, fmt.Sprintf("[ %s: %s ]", issue.TokenId, issue.Reason))
+	}
+
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,231 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define burn struct
+*/
+
+type DigitalUgcTombstoneData struct {
+	TokenId      string
+	Owner        string
+	TokenURI     string
+	CollectionId string
+	BurnedBy     string
+	Reason       string
+	BurnedTime   int64
+	UriReserved  bool
+}
+
+// ============== Operator burn extension ===============
+
+// BurnFrom
+// @title       BurnFrom
+// @description "BurnFrom burns a non-fungible token on behalf of its owner, allowed for approved operators and platform moderators"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a non-fungible token"
+// @param       reason    string                       "Why the token is burned, kept in the tombstone"
+// @return                bool                         "Return whether the burn was successful or not"
+func (ugc *DigitalUgcContact) BurnFrom(ctx contractapi.TransactionContextInterface, tokenId string, reason string) (bool, error) {
+	if utils.StringStrip(reason) == "" {
+		return false, fmt.Errorf("[BurnFrom] reason was empty")
+	}
+
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[BurnFrom] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[BurnFrom] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+
+	// Check if the sender is the owner, an approved client, an authorized operator or a moderator
+	allowed := nft.Owner == sender || nft.Approved == sender
+	if !allowed {
+		if allowed, err = ugc.IsApprovedForAll(ctx, nft.Owner, sender); err != nil {
+			return false, fmt.Errorf("[BurnFrom] IsApprovedForAll[ owner:%v, sender:%v ] error, throw-err: %v", nft.Owner, sender, err)
+		}
+	}
+	if !allowed {
+		if allowed, err = ugc.IsModerator(ctx, sender); err != nil {
+			return false, fmt.Errorf("[BurnFrom] IsModerator[ sender:%v ] error, throw-err: %v", sender, err)
+		}
+	}
+	if !allowed {
+		return false, fmt.Errorf("[BurnFrom] The sender is not allowed to burn the tokenId[ %v ]", tokenId)
+	}
+
+	// Burn the token
+	err = ugc._burnNFT(ctx, nft, sender, reason)
+	if err != nil {
+		return false, fmt.Errorf("[BurnFrom] _burnNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+
+	return true, nil
+}
+
+// GetTombstone
+// @title       GetTombstone
+// @description "GetTombstone returns the record kept for a burned token"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a burned non-fungible token"
+// @return                DigitalUgcTombstoneData      "Return the tombstone, or null if the token was never burned"
+func (ugc *DigitalUgcContact) GetTombstone(ctx contractapi.TransactionContextInterface, tokenId string) (*DigitalUgcTombstoneData, error) {
+	tombstoneKey, err := ctx.GetStub().CreateCompositeKey(config.TombstonePrefix, []string{tokenId})
+	if err != nil {
+		return nil, fmt.Errorf("[GetTombstone] CreateCompositeKey[ tombstoneKey: %s%s ] error, throw-err: %v", config.TombstonePrefix, tokenId, err)
+	}
+	tombstoneBytes, err := ctx.GetStub().GetState(tombstoneKey)
+	if err != nil {
+		return nil, fmt.Errorf("[GetTombstone] GetState[ tombstoneKey: %s ] error, throw-err: %v", tombstoneKey, err)
+	}
+	if len(tombstoneBytes) <= 0 {
+		return nil, nil
+	}
+
+	tombstone := new(DigitalUgcTombstoneData)
+	err = json.Unmarshal(tombstoneBytes, tombstone)
+	if err != nil {
+		return nil, fmt.Errorf("[GetTombstone] Json Unmarshal[ tombstone ] error, throw-err: %v", err)
+	}
+
+	return tombstone, nil
+}
+
+// SetModerator
+// @title       SetModerator
+// @description "SetModerator grants or revokes the platform moderator role, admin only"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       account   string                       "The client to update"
+// @param       enabled   bool                         "True to grant the moderator role, false to revoke it"
+// @return                bool                         "Return whether the update was successful or not"
+func (ugc *DigitalUgcContact) SetModerator(ctx contractapi.TransactionContextInterface, account string, enabled bool) (bool, error) {
+	if account = utils.StringStrip(account); account == "" {
+		return false, fmt.Errorf("[SetModerator] account was empty")
+	}
+
+	if err := ugc._authorizeAdmin(ctx); err != nil {
+		return false, fmt.Errorf("[SetModerator] _authorizeAdmin error, throw-err: %v", err)
+	}
+
+	moderatorKey, err := ctx.GetStub().CreateCompositeKey(config.ModeratorPrefix, []string{account})
+	if err != nil {
+		return false, fmt.Errorf("[SetModerator] CreateCompositeKey[ moderatorKey: %s%s ] error, throw-err: %v", config.ModeratorPrefix, account, err)
+	}
+	if enabled {
+		err = ctx.GetStub().PutState(moderatorKey, []byte{'0'})
+	} else {
+		err = ctx.GetStub().DelState(moderatorKey)
+	}
+	if err != nil {
+		return false, fmt.Errorf("[SetModerator] update moderatorKey[ %s ] error, throw-err: %v", moderatorKey, err)
+	}
+
+	return true, nil
+}
+
+// IsModerator
+// @title       IsModerator
+// @description "IsModerator returns if a client holds the platform moderator role"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       account   string                       "The client to check"
+// @return                bool                         "Return true if the client is a moderator"
+func (ugc *DigitalUgcContact) IsModerator(ctx contractapi.TransactionContextInterface, account string) (bool, error) {
+	moderatorKey, err := ctx.GetStub().CreateCompositeKey(config.ModeratorPrefix, []string{account})
+	if err != nil {
+		return false, fmt.Errorf("[IsModerator] CreateCompositeKey[ moderatorKey: %s%s ] error, throw-err: %v", config.ModeratorPrefix, account, err)
+	}
+	moderatorBytes, err := ctx.GetStub().GetState(moderatorKey)
+	if err != nil {
+		return false, fmt.Errorf("[IsModerator] GetState[ moderatorKey: %s ] error, throw-err: %v", moderatorKey, err)
+	}
+
+	return len(moderatorBytes) > 0, nil
+}
+
+// SetReserveBurnedUri
+// @title       SetReserveBurnedUri
+// @description "SetReserveBurnedUri chooses whether burning keeps the tokenURI and content hash reserved, admin only"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       reserved  bool                         "True to keep burned URIs reserved, false to free them"
+// @return                bool                         "Return whether the update was successful or not"
+func (ugc *DigitalUgcContact) SetReserveBurnedUri(ctx contractapi.TransactionContextInterface, reserved bool) (bool, error) {
+	if err := ugc._authorizeAdmin(ctx); err != nil {
+		return false, fmt.Errorf("[SetReserveBurnedUri] _authorizeAdmin error, throw-err: %v", err)
+	}
+
+	reservedBytes, err := json.Marshal(reserved)
+	if err != nil {
+		return false, fmt.Errorf("[SetReserveBurnedUri] Json Marshal[ reservedBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().PutState(config.ReserveUriKey, reservedBytes)
+	if err != nil {
+		return false, fmt.Errorf("[SetReserveBurnedUri] PutState[ %s ] error, throw-err: %v", config.ReserveUriKey, err)
+	}
+
+	return true, nil
+}
+
+// IsBurnedUriReserved
+// @title       IsBurnedUriReserved
+// @description "IsBurnedUriReserved returns whether burning keeps the tokenURI and content hash reserved"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @return                bool                         "Return true if burned URIs stay reserved"
+func (ugc *DigitalUgcContact) IsBurnedUriReserved(ctx contractapi.TransactionContextInterface) (bool, error) {
+	reservedBytes, err := ctx.GetStub().GetState(config.ReserveUriKey)
+	if err != nil {
+		return false, fmt.Errorf("[IsBurnedUriReserved] GetState[ %s ] error, throw-err: %v", config.ReserveUriKey, err)
+	}
+	if len(reservedBytes) <= 0 {
+		return false, nil
+	}
+
+	reserved := false
+	err = json.Unmarshal(reservedBytes, &reserved)
+	if err != nil {
+		return false, fmt.Errorf("[IsBurnedUriReserved] Json Unmarshal[ reservedBytes ] error, throw-err: %v", err)
+	}
+
+	return reserved, nil
+}
+
+// 存储销毁记录
+func (ugc *DigitalUgcContact) _saveTombstone(ctx contractapi.TransactionContextInterface, nft DigitalUgcBaseData, burnedBy string, reason string, uriReserved bool) error {
+	burnedTime, err := ugc._txTime(ctx)
+	if err != nil {
+		return fmt.Errorf("[_saveTombstone] _txTime error, throw-err: %v", err)
+	}
+
+	tombstoneKey, err := ctx.GetStub().CreateCompositeKey(config.TombstonePrefix, []string{nft.TokenId})
+	if err != nil {
+		return fmt.Errorf("[_saveTombstone] CreateCompositeKey[ tombstoneKey: %s%s ] error, throw-err: %v", config.TombstonePrefix, nft.TokenId, err)
+	}
+	tombstoneBytes, err := json.Marshal(DigitalUgcTombstoneData{
+		TokenId:      nft.TokenId,
+		Owner:        nft.Owner,
+		TokenURI:     nft.TokenURI,
+		CollectionId: nft.CollectionId,
+		BurnedBy:     burnedBy,
+		Reason:       reason,
+		BurnedTime:   burnedTime,
+		UriReserved:  uriReserved,
+	})
+	if err != nil {
+		return fmt.Errorf("[_saveTombstone] Json Marshal[ tombstoneBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().PutState(tombstoneKey, tombstoneBytes)
+	if err != nil {
+		return fmt.Errorf("[_saveTombstone] PutState[ tombstoneKey, tombstoneBytes ] error, throw-err: %v", err)
+	}
+
+	return nil
+}
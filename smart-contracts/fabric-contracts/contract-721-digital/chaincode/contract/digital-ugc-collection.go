@@ -0,0 +1,360 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define collection struct
+*/
+
+type DigitalUgcCollectionData struct {
+	CollectionId string
+	Name         string
+	Symbol       string
+	Creator      string
+	MaxSize      int
+	BaseURI      string
+	Royalty      int
+}
+
+type EventCollectionCreated struct {
+	CollectionId string
+	Creator      string
+}
+
+// ============== Collection (series) extension ===============
+
+// CreateCollection
+// @title       CreateCollection
+// @description "CreateCollection registers a new series owned by the calling creator, allowlisted minters only"
+// @param       ctx           TransactionContextInterface  "ctx the transaction context"
+// @param       collectionId  string                       "Unique ID of the collection"
+// @param       name          string                       "Descriptive name of the collection"
+// @param       symbol        string                       "Abbreviated name of the collection"
+// @param       maxSize       int                          "The maximum number of tokens in the collection, 0 for unlimited"
+// @param       baseURI       string                       "Base URI shared by the tokens of the collection"
+// @param       royalty       int                          "Creator royalty in basis points of the sale price"
+// @return                    bool                         "Return whether the collection was created or not"
+func (ugc *DigitalUgcContact) CreateCollection(ctx contractapi.TransactionContextInterface, collectionId string, name string, symbol string, maxSize int, baseURI string, royalty int) (bool, error) {
+
+	// 检查collectionId
+	if collectionId = utils.StringStrip(collectionId); collectionId == "" {
+		return false, fmt.Errorf("[CreateCollection] collectionId was empty")
+	}
+
+	// 检查name
+	if utils.StringStrip(name) == "" {
+		return false, fmt.Errorf("[CreateCollection] name was empty")
+	}
+
+	// 检查maxSize和royalty
+	if maxSize < 0 {
+		return false, fmt.Errorf("[CreateCollection] maxSize[ %d ] must not be negative", maxSize)
+	}
+	if royalty < 0 || royalty > config.MaxRoyalty {
+		return false, fmt.Errorf("[CreateCollection] royalty[ %d ] must be between 0 and %d", royalty, config.MaxRoyalty)
+	}
+
+	// Only allowlisted minters may register a collection and set its royalty
+	if _, err := ugc._authorizeMinter(ctx); err != nil {
+		return false, fmt.Errorf("[CreateCollection] _authorizeMinter error, throw-err: %v", err)
+	}
+
+	creator, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[CreateCollection] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	collectionKey, err := ctx.GetStub().CreateCompositeKey(config.CollectionPrefix, []string{collectionId})
+	if err != nil {
+		return false, fmt.Errorf("[CreateCollection] CreateCompositeKey[ collectionKey: %s%s ] error, throw-err: %v", config.CollectionPrefix, collectionId, err)
+	}
+	collectionBytes, err := ctx.GetStub().GetState(collectionKey)
+	if err != nil {
+		return false, fmt.Errorf("[CreateCollection] GetState[ collectionKey: %s ] error, throw-err: %v", collectionKey, err)
+	}
+	if len(collectionBytes) > 0 {
+		return false, fmt.Errorf("[CreateCollection] The collectionId[ %v ] already exists", collectionId)
+	}
+
+	newCollection := DigitalUgcCollectionData{
+		CollectionId: collectionId,
+		Name:         name,
+		Symbol:       symbol,
+		Creator:      creator,
+		MaxSize:      maxSize,
+		BaseURI:      baseURI,
+		Royalty:      royalty,
+	}
+	collectionBytes, err = json.Marshal(newCollection)
+	if err != nil {
+		return false, fmt.Errorf("[CreateCollection] Json Marshal[ newCollection ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().PutState(collectionKey, collectionBytes)
+	if err != nil {
+		return false, fmt.Errorf("[CreateCollection] PutState[ collectionKey, collectionBytes ] error, throw-err: %v", err)
+	}
+
+	// Emit the CollectionCreated event
+	newEventBytes, err := json.Marshal(EventCollectionCreated{CollectionId: collectionId, Creator: creator})
+	if err != nil {
+		return false, fmt.Errorf("[CreateCollection] Json Marshal[ newEventBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("CollectionCreated", newEventBytes)
+	if err != nil {
+		return false, fmt.Errorf("[CreateCollection] SetEvent[ newEventBytes ] error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// GetCollection
+// @title       GetCollection
+// @description "GetCollection returns a collection"
+// @param       ctx           TransactionContextInterface  "ctx the transaction context"
+// @param       collectionId  string                       "The identifier for a collection"
+// @return                    DigitalUgcCollectionData     "Return the collection object"
+func (ugc *DigitalUgcContact) GetCollection(ctx contractapi.TransactionContextInterface, collectionId string) (*DigitalUgcCollectionData, error) {
+	collection, err := ugc._readCollection(ctx, collectionId)
+	if err != nil {
+		return nil, fmt.Errorf("[GetCollection] _readCollection collectionId[ %v ] error, throw-err: %v", collectionId, err)
+	}
+
+	return collection, nil
+}
+
+// TokensOfCollection
+// @title       TokensOfCollection
+// @description "TokensOfCollection pages through the non-fungible tokens of a collection"
+// @param       ctx           TransactionContextInterface  "ctx the transaction context"
+// @param       collectionId  string                       "The identifier for a collection"
+// @param       pageSize      int32                        "The maximum number of tokenIds to return"
+// @param       bookmark      string                       "The bookmark returned by the previous page, empty for the first page"
+// @return                    DigitalUgcTokenPage          "Returns the tokenIds of this page and the bookmark of the next page"
+func (ugc *DigitalUgcContact) TokensOfCollection(ctx contractapi.TransactionContextInterface, collectionId string, pageSize int32, bookmark string) (*DigitalUgcTokenPage, error) {
+	if collectionId = utils.StringStrip(collectionId); collectionId == "" {
+		return nil, fmt.Errorf("[TokensOfCollection] collectionId was empty")
+	}
+
+	page, err := ugc._readTokenPage(ctx, config.CollectionTokenPrefix, []string{collectionId}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("[TokensOfCollection] _readTokenPage[ collectionId: %s ] error, throw-err: %v", collectionId, err)
+	}
+
+	return page, nil
+}
+
+// TotalSupplyOfCollection
+// @title       TotalSupplyOfCollection
+// @description "TotalSupplyOfCollection returns the number of non-fungible tokens in a collection"
+// @param       ctx           TransactionContextInterface  "ctx the transaction context"
+// @param       collectionId  string                       "The identifier for a collection"
+// @return                    int                          "Returns the number of tokens in the collection"
+func (ugc *DigitalUgcContact) TotalSupplyOfCollection(ctx contractapi.TransactionContextInterface, collectionId string) (int, error) {
+	supply, err := ugc._collectionSupply(ctx, collectionId)
+	if err != nil {
+		return 0, fmt.Errorf("[TotalSupplyOfCollection] _collectionSupply collectionId[ %v ] error, throw-err: %v", collectionId, err)
+	}
+
+	return supply, nil
+}
+
+// MintWithTokenURIInCollection
+// @title       MintWithTokenURIInCollection
+// @description "Mint a new non-fungible token into a collection, creator only"
+// @param       ctx           TransactionContextInterface  "ctx the transaction context"
+// @param       collectionId  string  "The collection the token belongs to"
+// @param       tokenId       string  "Unique ID of the non-fungible token to be minted"
+// @param       tokenURI      string  "URI containing metadata of the minted non-fungible token"
+// @return                    int     "Return the mint code"
+func (ugc *DigitalUgcContact) MintWithTokenURIInCollection(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, tokenURI string) (int, error) {
+	return ugc.MintBatchWithTokenURIInCollection(ctx, collectionId, []string{tokenId}, []string{tokenURI})
+}
+
+// MintBatchWithTokenURIInCollection
+// @title       MintBatchWithTokenURIInCollection
+// @description "Mint some non-fungible tokens into a collection, creator only"
+// @param       ctx           TransactionContextInterface  "ctx the transaction context"
+// @param       collectionId  string    "The collection the tokens belong to"
+// @param       tokenIds      []string  "Unique IDs of the non-fungible tokens to be minted"
+// @param       tokenURIs     []string  "URIs containing metadata of the minted non-fungible tokens"
+// @return                    int       "Return the mint code"
+func (ugc *DigitalUgcContact) MintBatchWithTokenURIInCollection(ctx contractapi.TransactionContextInterface, collectionId string, tokenIds []string, tokenURIs []string) (int, error) {
+
+	// 判断参数是否一致
+	if len(tokenIds) == 0 || len(tokenIds) != len(tokenURIs) {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURIInCollection] tokenIds length must equal tokenURIs length and not be 0")
+	}
+
+	// 检查tokenId和tokenUri是否存在空的值或重复的值, 重复的tokenId会被重复计入总量
+	seenIds := make(map[string]bool, len(tokenIds))
+	seenURIs := make(map[string]bool, len(tokenURIs))
+	for i := range tokenIds {
+		if utils.StringStrip(tokenIds[i]) == "" {
+			return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURIInCollection] tokenIds list is found empty tokenId")
+		}
+		if utils.StringStrip(tokenURIs[i]) == "" {
+			return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURIInCollection] tokenURIs list is found empty uri")
+		}
+		if seenIds[tokenIds[i]] {
+			return config.CODE_MINT_TOKENID_MINTED, fmt.Errorf("[MintBatchWithTokenURIInCollection] tokenId[ %s ] is duplicated in the batch", tokenIds[i])
+		}
+		if seenURIs[tokenURIs[i]] {
+			return config.CODE_MINT_TOKENURI_MINTED, fmt.Errorf("[MintBatchWithTokenURIInCollection] tokenURI[ %s ] is duplicated in the batch", tokenURIs[i])
+		}
+		seenIds[tokenIds[i]] = true
+		seenURIs[tokenURIs[i]] = true
+	}
+
+	// Get ID of submitting client identity
+	minter, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURIInCollection] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	// 检查系列的归属和容量
+	collection, code, err := ugc._checkCollectionMint(ctx, collectionId, minter, len(tokenIds))
+	if err != nil {
+		return code, fmt.Errorf("[MintBatchWithTokenURIInCollection] _checkCollectionMint error, throw-err: %v", err)
+	}
+
+	// 执行批量铸造
+	for i := range tokenIds {
+		_, code, err := ugc._mintNFT(ctx, minter, collection.CollectionId, tokenIds[i], tokenURIs[i], nil, false)
+		if err != nil {
+			return code, fmt.Errorf("[MintBatchWithTokenURIInCollection] _mintNFT error, throw-err: %v", err)
+		}
+	}
+
+	// Update supply counter once, state written in this transaction is not readable yet
+	err = ugc._updateSupply(ctx, collection.CollectionId, len(tokenIds))
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURIInCollection] _updateSupply error, throw-err: %v", err)
+	}
+
+	return config.CODE_MINT_SUCCESS, nil
+}
+
+// 检查 minter 是否可以向系列铸造 count 个 token, 返回系列和铸造错误码
+func (ugc *DigitalUgcContact) _checkCollectionMint(ctx contractapi.TransactionContextInterface, collectionId string, minter string, count int) (*DigitalUgcCollectionData, int, error) {
+	collection, err := ugc._readCollection(ctx, collectionId)
+	if err != nil {
+		return nil, config.CODE_MINT_COLLECTION_NOT_FOUND, fmt.Errorf("[_checkCollectionMint] _readCollection collectionId[ %v ] error, throw-err: %v", collectionId, err)
+	}
+	if collection.Creator != minter {
+		return nil, config.CODE_MINT_COLLECTION_FORBIDDEN, fmt.Errorf("[_checkCollectionMint] Only the creator may mint into the collection[ %v ]", collectionId)
+	}
+
+	// 容量以系列的 token 索引为准, 不依赖可能偏离的发行量计数, 有容量的系列最多扫描 MaxSize 条索引
+	if collection.MaxSize > 0 {
+		supply, err := ugc._countCollectionTokens(ctx, collectionId)
+		if err != nil {
+			return nil, config.CODE_MINT_FAILED, fmt.Errorf("[_checkCollectionMint] _countCollectionTokens collectionId[ %v ] error, throw-err: %v", collectionId, err)
+		}
+		if supply+count > collection.MaxSize {
+			return nil, config.CODE_MINT_COLLECTION_FULL, fmt.Errorf("[_checkCollectionMint] The collection[ %v ] holds %d of %d tokens, cannot mint %d more", collectionId, supply, collection.MaxSize, count)
+		}
+	}
+
+	return collection, config.CODE_MINT_SUCCESS, nil
+}
+
+func (ugc *DigitalUgcContact) _readCollection(ctx contractapi.TransactionContextInterface, collectionId string) (*DigitalUgcCollectionData, error) {
+	collectionKey, err := ctx.GetStub().CreateCompositeKey(config.CollectionPrefix, []string{collectionId})
+	if err != nil {
+		return nil, fmt.Errorf("[_readCollection] CreateCompositeKey[ collectionKey: %s%s ] error, throw-err: %v", config.CollectionPrefix, collectionId, err)
+	}
+
+	collectionBytes, err := ctx.GetStub().GetState(collectionKey)
+	if err != nil {
+		return nil, fmt.Errorf("[_readCollection] GetState[ collectionKey: %s ] error, throw-err: %v", collectionKey, err)
+	}
+	if len(collectionBytes) <= 0 {
+		return nil, fmt.Errorf("[_readCollection] The collectionId[ %v ] is invalid. It does not exist", collectionId)
+	}
+
+	collection := new(DigitalUgcCollectionData)
+	err = json.Unmarshal(collectionBytes, collection)
+	if err != nil {
+		return nil, fmt.Errorf("[_readCollection] Json Unmarshal[ collection ] error, throw-err: %v", err)
+	}
+
+	return collection, nil
+}
+
+// 读取系列的发行量计数
+func (ugc *DigitalUgcContact) _collectionSupply(ctx contractapi.TransactionContextInterface, collectionId string) (int, error) {
+	collectionSupplyKey, err := ctx.GetStub().CreateCompositeKey(config.SupplyPrefix, []string{collectionId})
+	if err != nil {
+		return 0, fmt.Errorf("[_collectionSupply] CreateCompositeKey[ supplyKey: %s%s ] error, throw-err: %v", config.SupplyPrefix, collectionId, err)
+	}
+
+	supply, _, err := ugc._readCounter(ctx, collectionSupplyKey)
+	if err != nil {
+		return 0, fmt.Errorf("[_collectionSupply] _readCounter[ %s ] error, throw-err: %v", collectionSupplyKey, err)
+	}
+
+	return supply, nil
+}
+
+// 根据系列的 token 索引统计系列当前的 token 数量
+func (ugc *DigitalUgcContact) _countCollectionTokens(ctx contractapi.TransactionContextInterface, collectionId string) (int, error) {
+	tokenIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(config.CollectionTokenPrefix, []string{collectionId})
+	if err != nil {
+		return 0, fmt.Errorf("[_countCollectionTokens] GetStateByPartialCompositeKey[ %s%s ] error, throw-err: %v", config.CollectionTokenPrefix, collectionId, err)
+	}
+	defer tokenIterator.Close()
+
+	count := 0
+	for tokenIterator.HasNext() {
+		if _, err = tokenIterator.Next(); err != nil {
+			return 0, fmt.Errorf("[_countCollectionTokens] Failed to get the next state for prefix[ %s ], throw-err: %v", config.CollectionTokenPrefix, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// 根据系列的 token 索引重新统计每个系列的发行量
+func (ugc *DigitalUgcContact) _recountCollectionSupply(ctx contractapi.TransactionContextInterface) error {
+	collectionIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(config.CollectionPrefix, []string{})
+	if err != nil {
+		return fmt.Errorf("[_recountCollectionSupply] GetStateByPartialCompositeKey[ %s ] error, throw-err: %v", config.CollectionPrefix, err)
+	}
+	defer collectionIterator.Close()
+
+	for collectionIterator.HasNext() {
+		queryResponse, err := collectionIterator.Next()
+		if err != nil {
+			return fmt.Errorf("[_recountCollectionSupply] Failed to get the next state for prefix[ %s ], throw-err: %v", config.CollectionPrefix, err)
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return fmt.Errorf("[_recountCollectionSupply] SplitCompositeKey[ %s ] error, throw-err: %v", queryResponse.Key, err)
+		}
+		collectionId := keyParts[0]
+
+		supply, err := ugc._countCollectionTokens(ctx, collectionId)
+		if err != nil {
+			return fmt.Errorf("[_recountCollectionSupply] _countCollectionTokens collectionId[ %v ] error, throw-err: %v", collectionId, err)
+		}
+
+		collectionSupplyKey, err := ctx.GetStub().CreateCompositeKey(config.SupplyPrefix, []string{collectionId})
+		if err != nil {
+			return fmt.Errorf("[_recountCollectionSupply] CreateCompositeKey[ supplyKey: %s%s ] error, throw-err: %v", config.SupplyPrefix, collectionId, err)
+		}
+		err = ugc._writeCounter(ctx, collectionSupplyKey, supply)
+		if err != nil {
+			return fmt.Errorf("[_recountCollectionSupply] _writeCounter[ %s ] error, throw-err: %v", collectionSupplyKey, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,352 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define fractional ownership struct
+*/
+
+type DigitalUgcVaultData struct {
+	TokenId        string
+	Curator        string
+	TotalShares    int
+	FractionalTime int64
+}
+
+type EventFractionalized struct {
+	TokenId     string
+	Curator     string
+	TotalShares int
+}
+
+type EventSharesTransfer struct {
+	TokenId string
+	From    string
+	To      string
+	Amount  int
+}
+
+type EventRedeemed struct {
+	TokenId string
+	Holder  string
+}
+
+// ============== Fractional ownership extension ===============
+
+// Fractionalize
+// @title       Fractionalize
+// @description "Fractionalize locks a token in the fractional vault and issues all of its fungible shares to the owner"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a non-fungible token"
+// @param       shares    int                          "The number of shares to issue"
+// @return                bool                         "Return whether the token was fractionalized or not"
+func (ugc *DigitalUgcContact) Fractionalize(ctx contractapi.TransactionContextInterface, tokenId string, shares int) (bool, error) {
+	if tokenId = utils.StringStrip(tokenId); tokenId == "" {
+		return false, fmt.Errorf("[Fractionalize] tokenId was empty")
+	}
+	if shares <= 0 {
+		return false, fmt.Errorf("[Fractionalize] shares[ %d ] must be positive", shares)
+	}
+
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+	if nft.Owner != sender {
+		return false, fmt.Errorf("[Fractionalize] Non-fungible token %s is not owned by %s", tokenId, sender)
+	}
+
+	// A locked token stays in place
+	lock, err := ugc._readTokenLock(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] _readTokenLock error, throw-err: %v", err)
+	}
+	if lock != nil {
+		return false, fmt.Errorf("[Fractionalize] The tokenId[ %v ] is locked by[ %s ]", tokenId, lock.Locker)
+	}
+
+	// Moving into the vault follows the soulbound, timelock and allowlist rules
+	reason, err := ugc._checkTransferRule(ctx, sender, config.FractionVaultAccount, nft)
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] _checkTransferRule error, throw-err: %v", err)
+	}
+	if reason != "" {
+		return false, fmt.Errorf("[Fractionalize] %s", reason)
+	}
+
+	now, err := ugc._txTime(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] _txTime error, throw-err: %v", err)
+	}
+
+	vaultBytes, err := json.Marshal(DigitalUgcVaultData{
+		TokenId:        tokenId,
+		Curator:        sender,
+		TotalShares:    shares,
+		FractionalTime: now,
+	})
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] Json Marshal[ vaultBytes ] error, throw-err: %v", err)
+	}
+	vaultKey, err := ctx.GetStub().CreateCompositeKey(config.FractionVaultPrefix, []string{tokenId})
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] CreateCompositeKey[ vaultKey: %s%s ] error, throw-err: %v", config.FractionVaultPrefix, tokenId, err)
+	}
+	err = ctx.GetStub().PutState(vaultKey, vaultBytes)
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] PutState[ vaultKey: %s ] error, throw-err: %v", vaultKey, err)
+	}
+
+	err = ugc._moveNFT(ctx, nft, sender, config.FractionVaultAccount, "")
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] _moveNFT error, throw-err: %v", err)
+	}
+	err = ugc._writeShares(ctx, tokenId, sender, shares)
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] _writeShares error, throw-err: %v", err)
+	}
+
+	// Emit the Fractionalized event, it replaces the Transfer event of the vault deposit
+	newEventBytes, err := json.Marshal(EventFractionalized{TokenId: tokenId, Curator: sender, TotalShares: shares})
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] Json Marshal[ newEventBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("Fractionalized", newEventBytes)
+	if err != nil {
+		return false, fmt.Errorf("[Fractionalize] SetEvent[ newEventBytes ] error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// TransferShares
+// @title       TransferShares
+// @description "TransferShares moves shares of a fractionalized token from the caller to another account"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a fractionalized token"
+// @param       to        string                       "The receiver of the shares"
+// @param       amount    int                          "The number of shares to transfer"
+// @return                bool                         "Return whether the transfer was successful or not"
+func (ugc *DigitalUgcContact) TransferShares(ctx contractapi.TransactionContextInterface, tokenId string, to string, amount int) (bool, error) {
+	if to = utils.StringStrip(to); to == "" || to == config.EmptyAccount || to == config.FractionVaultAccount {
+		return false, fmt.Errorf("[TransferShares] to was empty, the zero address or the vault")
+	}
+	if amount <= 0 {
+		return false, fmt.Errorf("[TransferShares] amount[ %d ] must be positive", amount)
+	}
+
+	if _, err := ugc._readVault(ctx, tokenId); err != nil {
+		return false, fmt.Errorf("[TransferShares] _readVault error, throw-err: %v", err)
+	}
+
+	// Shares carry the economic ownership, so a soulbound token cannot be sold through them
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[TransferShares] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+	rule, source, err := ugc._effectiveTransferRule(ctx, nft)
+	if err != nil {
+		return false, fmt.Errorf("[TransferShares] _effectiveTransferRule error, throw-err: %v", err)
+	}
+	if rule != nil && rule.Mode == config.TransferModeSoulbound {
+		return false, fmt.Errorf("[TransferShares] The %s is soulbound and its shares cannot be transferred", source)
+	}
+
+	from, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[TransferShares] _clientAccount for sender error, throw-err: %v", err)
+	}
+	if from == to {
+		return false, fmt.Errorf("[TransferShares] The to is the sender")
+	}
+
+	fromShares, err := ugc.SharesOf(ctx, tokenId, from)
+	if err != nil {
+		return false, fmt.Errorf("[TransferShares] SharesOf[ from ] error, throw-err: %v", err)
+	}
+	if fromShares < amount {
+		return false, fmt.Errorf("[TransferShares] The sender holds %d shares of tokenId[ %v ], want %d", fromShares, tokenId, amount)
+	}
+	toShares, err := ugc.SharesOf(ctx, tokenId, to)
+	if err != nil {
+		return false, fmt.Errorf("[TransferShares] SharesOf[ to ] error, throw-err: %v", err)
+	}
+
+	err = ugc._writeShares(ctx, tokenId, from, fromShares-amount)
+	if err != nil {
+		return false, fmt.Errorf("[TransferShares] _writeShares[ from ] error, throw-err: %v", err)
+	}
+	err = ugc._writeShares(ctx, tokenId, to, toShares+amount)
+	if err != nil {
+		return false, fmt.Errorf("[TransferShares] _writeShares[ to ] error, throw-err: %v", err)
+	}
+
+	// Emit the SharesTransfer event
+	newEventBytes, err := json.Marshal(EventSharesTransfer{TokenId: tokenId, From: from, To: to, Amount: amount})
+	if err != nil {
+		return false, fmt.Errorf("[TransferShares] Json Marshal[ newEventBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("SharesTransfer", newEventBytes)
+	if err != nil {
+		return false, fmt.Errorf("[TransferShares] SetEvent[ newEventBytes ] error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// SharesOf
+// @title       SharesOf
+// @description "SharesOf returns the shares of a fractionalized token held by an account"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a fractionalized token"
+// @param       account   string                       "The account to query"
+// @return                int                          "Return the number of shares, possibly zero"
+func (ugc *DigitalUgcContact) SharesOf(ctx contractapi.TransactionContextInterface, tokenId string, account string) (int, error) {
+	sharesKey, err := ctx.GetStub().CreateCompositeKey(config.FractionSharesPrefix, []string{tokenId, account})
+	if err != nil {
+		return 0, fmt.Errorf("[SharesOf] CreateCompositeKey[ sharesKey: %s%s%s ] error, throw-err: %v", config.FractionSharesPrefix, tokenId, account, err)
+	}
+	shares, _, err := ugc._readCounter(ctx, sharesKey)
+	if err != nil {
+		return 0, fmt.Errorf("[SharesOf] _readCounter error, throw-err: %v", err)
+	}
+
+	return shares, nil
+}
+
+// GetVault
+// @title       GetVault
+// @description "GetVault returns the vault record of a fractionalized token"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a fractionalized token"
+// @return                DigitalUgcVaultData          "Return the vault record"
+func (ugc *DigitalUgcContact) GetVault(ctx contractapi.TransactionContextInterface, tokenId string) (*DigitalUgcVaultData, error) {
+	vault, err := ugc._readVault(ctx, tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[GetVault] _readVault error, throw-err: %v", err)
+	}
+
+	return vault, nil
+}
+
+// Redeem
+// @title       Redeem
+// @description "Redeem burns all shares of a fractionalized token and gives the token back to the caller, who must hold 100% of the shares"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a fractionalized token"
+// @return                bool                         "Return whether the redemption was successful or not"
+func (ugc *DigitalUgcContact) Redeem(ctx contractapi.TransactionContextInterface, tokenId string) (bool, error) {
+	vault, err := ugc._readVault(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[Redeem] _readVault error, throw-err: %v", err)
+	}
+
+	holder, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[Redeem] _clientAccount for sender error, throw-err: %v", err)
+	}
+	shares, err := ugc.SharesOf(ctx, tokenId, holder)
+	if err != nil {
+		return false, fmt.Errorf("[Redeem] SharesOf error, throw-err: %v", err)
+	}
+	if shares != vault.TotalShares {
+		return false, fmt.Errorf("[Redeem] The sender holds %d of %d shares of tokenId[ %v ]", shares, vault.TotalShares, tokenId)
+	}
+
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[Redeem] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+
+	// Leaving the vault is a transfer from the curator who deposited the token, so the
+	// issuer exemption applies to its own deposits and the curator can always take it back
+	if holder != vault.Curator {
+		reason, err := ugc._checkTransferRule(ctx, vault.Curator, holder, nft)
+		if err != nil {
+			return false, fmt.Errorf("[Redeem] _checkTransferRule error, throw-err: %v", err)
+		}
+		if reason != "" {
+			return false, fmt.Errorf("[Redeem] %s", reason)
+		}
+	}
+
+	err = ugc._writeShares(ctx, tokenId, holder, 0)
+	if err != nil {
+		return false, fmt.Errorf("[Redeem] _writeShares error, throw-err: %v", err)
+	}
+	vaultKey, err := ctx.GetStub().CreateCompositeKey(config.FractionVaultPrefix, []string{tokenId})
+	if err != nil {
+		return false, fmt.Errorf("[Redeem] CreateCompositeKey[ vaultKey: %s%s ] error, throw-err: %v", config.FractionVaultPrefix, tokenId, err)
+	}
+	err = ctx.GetStub().DelState(vaultKey)
+	if err != nil {
+		return false, fmt.Errorf("[Redeem] DelState[ vaultKey: %s ] error, throw-err: %v", vaultKey, err)
+	}
+	err = ugc._moveNFT(ctx, nft, config.FractionVaultAccount, holder, "")
+	if err != nil {
+		return false, fmt.Errorf("[Redeem] _moveNFT error, throw-err: %v", err)
+	}
+
+	// Emit the Redeemed event, it replaces the Transfer event of the vault withdrawal
+	newEventBytes, err := json.Marshal(EventRedeemed{TokenId: tokenId, Holder: holder})
+	if err != nil {
+		return false, fmt.Errorf("[Redeem] Json Marshal[ newEventBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("Redeemed", newEventBytes)
+	if err != nil {
+		return false, fmt.Errorf("[Redeem] SetEvent[ newEventBytes ] error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+func (ugc *DigitalUgcContact) _readVault(ctx contractapi.TransactionContextInterface, tokenId string) (*DigitalUgcVaultData, error) {
+	vaultKey, err := ctx.GetStub().CreateCompositeKey(config.FractionVaultPrefix, []string{tokenId})
+	if err != nil {
+		return nil, fmt.Errorf("[_readVault] CreateCompositeKey[ vaultKey: %s%s ] error, throw-err: %v", config.FractionVaultPrefix, tokenId, err)
+	}
+	vaultBytes, err := ctx.GetStub().GetState(vaultKey)
+	if err != nil {
+		return nil, fmt.Errorf("[_readVault] GetState[ vaultKey: %s ] error, throw-err: %v", vaultKey, err)
+	}
+	if len(vaultBytes) <= 0 {
+		return nil, fmt.Errorf("[_readVault] The tokenId[ %v ] is not fractionalized", tokenId)
+	}
+
+	vault := new(DigitalUgcVaultData)
+	err = json.Unmarshal(vaultBytes, vault)
+	if err != nil {
+		return nil, fmt.Errorf("[_readVault] Json Unmarshal[ vault ] error, throw-err: %v", err)
+	}
+
+	return vault, nil
+}
+
+// 写入账户持有的份额, 为 0 时删除
+func (ugc *DigitalUgcContact) _writeShares(ctx contractapi.TransactionContextInterface, tokenId string, account string, shares int) error {
+	sharesKey, err := ctx.GetStub().CreateCompositeKey(config.FractionSharesPrefix, []string{tokenId, account})
+	if err != nil {
+		return fmt.Errorf("[_writeShares] CreateCompositeKey[ sharesKey: %s%s%s ] error, throw-err: %v", config.FractionSharesPrefix, tokenId, account, err)
+	}
+	if shares == 0 {
+		err = ctx.GetStub().DelState(sharesKey)
+		if err != nil {
+			return fmt.Errorf("[_writeShares] DelState[ sharesKey: %s ] error, throw-err: %v", sharesKey, err)
+		}
+		return nil
+	}
+
+	return ugc._writeCounter(ctx, sharesKey, shares)
+}
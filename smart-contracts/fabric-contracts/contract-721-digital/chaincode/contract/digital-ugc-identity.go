@@ -0,0 +1,135 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define identity struct
+*/
+
+type DigitalUgcIdentityChaincodeData struct {
+	Chaincode string
+	Channel   string
+	UpdatedBy string
+}
+
+// ============== Identity registry extension ===============
+
+// SetIdentityChaincode
+// @title       SetIdentityChaincode
+// @description "SetIdentityChaincode sets the stablecoin chaincode holding the identity registry and signing keys, callers are resolved and signatures verified through it, admin only"
+// @param       ctx        TransactionContextInterface  "ctx the transaction context"
+// @param       chaincode  string                       "The name of the stablecoin chaincode"
+// @param       channel    string                       "The channel of the stablecoin chaincode, empty for the current channel"
+// @return                 bool                         "Return whether the update was successful or not"
+func (ugc *DigitalUgcContact) SetIdentityChaincode(ctx contractapi.TransactionContextInterface, chaincode string, channel string) (bool, error) {
+	if chaincode = utils.StringStrip(chaincode); chaincode == "" {
+		return false, fmt.Errorf("[SetIdentityChaincode] chaincode was empty")
+	}
+
+	if err := ugc._authorizeAdmin(ctx); err != nil {
+		return false, fmt.Errorf("[SetIdentityChaincode] _authorizeAdmin error, throw-err: %v", err)
+	}
+
+	operator, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[SetIdentityChaincode] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	identityBytes, err := json.Marshal(DigitalUgcIdentityChaincodeData{Chaincode: chaincode, Channel: channel, UpdatedBy: operator})
+	if err != nil {
+		return false, fmt.Errorf("[SetIdentityChaincode] Json Marshal[ identityBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().PutState(config.IdentityChaincodeKey, identityBytes)
+	if err != nil {
+		return false, fmt.Errorf("[SetIdentityChaincode] PutState[ %s ] error, throw-err: %v", config.IdentityChaincodeKey, err)
+	}
+
+	return true, nil
+}
+
+// GetIdentityChaincode
+// @title       GetIdentityChaincode
+// @description "GetIdentityChaincode returns the chaincode holding the identity registry and signing keys, nil if it was never set"
+// @param       ctx       TransactionContextInterface      "ctx the transaction context"
+// @return                DigitalUgcIdentityChaincodeData  "Return the identity chaincode"
+func (ugc *DigitalUgcContact) GetIdentityChaincode(ctx contractapi.TransactionContextInterface) (*DigitalUgcIdentityChaincodeData, error) {
+	identityChaincode, err := ugc._readIdentityChaincode(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[GetIdentityChaincode] _readIdentityChaincode error, throw-err: %v", err)
+	}
+
+	return identityChaincode, nil
+}
+
+// 获取调用者的稳定账户地址
+func (ugc *DigitalUgcContact) _clientAccount(ctx contractapi.TransactionContextInterface) (string, error) {
+	clientId, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", fmt.Errorf("[_clientAccount] GetClientIdentity.GetID error, throw-err: %v", err)
+	}
+
+	return ugc._resolveAccount(ctx, clientId)
+}
+
+// 身份映射只登记在稳定币合约, 未设置身份合约时直接以 clientId 作为账户地址
+func (ugc *DigitalUgcContact) _resolveAccount(ctx contractapi.TransactionContextInterface, clientId string) (string, error) {
+	identityChaincode, err := ugc._readIdentityChaincode(ctx)
+	if err != nil {
+		return "", fmt.Errorf("[_resolveAccount] _readIdentityChaincode error, throw-err: %v", err)
+	}
+	if identityChaincode == nil {
+		return clientId, nil
+	}
+
+	args := [][]byte{[]byte(config.CoinsResolveAccountFcn), []byte(clientId)}
+	payload, err := ugc._invokeIdentityChaincode(ctx, args)
+	if err != nil {
+		return "", fmt.Errorf("[_resolveAccount] _invokeIdentityChaincode error, throw-err: %v", err)
+	}
+
+	return string(payload), nil
+}
+
+// 调用身份合约, 未设置身份合约时返回错误
+func (ugc *DigitalUgcContact) _invokeIdentityChaincode(ctx contractapi.TransactionContextInterface, args [][]byte) ([]byte, error) {
+	identityChaincode, err := ugc._readIdentityChaincode(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[_invokeIdentityChaincode] _readIdentityChaincode error, throw-err: %v", err)
+	}
+	if identityChaincode == nil {
+		return nil, fmt.Errorf("[_invokeIdentityChaincode] The identity chaincode was not set")
+	}
+
+	response := ctx.GetStub().InvokeChaincode(identityChaincode.Chaincode, args, identityChaincode.Channel)
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("[_invokeIdentityChaincode] InvokeChaincode[ %s ] error, throw-err: %v", identityChaincode.Chaincode, response.Message)
+	}
+
+	return response.Payload, nil
+}
+
+func (ugc *DigitalUgcContact) _readIdentityChaincode(ctx contractapi.TransactionContextInterface) (*DigitalUgcIdentityChaincodeData, error) {
+	identityBytes, err := ctx.GetStub().GetState(config.IdentityChaincodeKey)
+	if err != nil {
+		return nil, fmt.Errorf("[_readIdentityChaincode] GetState[ %s ] error, throw-err: %v", config.IdentityChaincodeKey, err)
+	}
+	if len(identityBytes) <= 0 {
+		return nil, nil
+	}
+
+	identityChaincode := new(DigitalUgcIdentityChaincodeData)
+	err = json.Unmarshal(identityBytes, identityChaincode)
+	if err != nil {
+		return nil, fmt.Errorf("[_readIdentityChaincode] Json Unmarshal[ identityChaincode ] error, throw-err: %v", err)
+	}
+
+	return identityChaincode, nil
+}
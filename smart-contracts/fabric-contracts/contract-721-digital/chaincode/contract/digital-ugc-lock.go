@@ -0,0 +1,286 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+/*
+	Define lock struct
+*/
+
+type DigitalUgcTokenLockData struct {
+	TokenId  string
+	Locked   bool
+	Owner    string
+	Locker   string
+	Reason   string
+	LockedAt int64
+}
+
+// ============== Collateral lock extension ===============
+
+// SetLocker
+// @title       SetLocker
+// @description "SetLocker registers or removes a chaincode allowed to lock tokens, admin only"
+// @param       ctx              TransactionContextInterface  "ctx the transaction context"
+// @param       lockerChaincode  string                       "The name of the locker chaincode"
+// @param       enabled          bool                         "True to register the locker, false to remove it, its existing locks can still be released"
+// @return                       bool                         "Return whether the update was successful or not"
+func (ugc *DigitalUgcContact) SetLocker(ctx contractapi.TransactionContextInterface, lockerChaincode string, enabled bool) (bool, error) {
+	if lockerChaincode = utils.StringStrip(lockerChaincode); lockerChaincode == "" {
+		return false, fmt.Errorf("[SetLocker] lockerChaincode was empty")
+	}
+
+	if err := ugc._authorizeAdmin(ctx); err != nil {
+		return false, fmt.Errorf("[SetLocker] _authorizeAdmin error, throw-err: %v", err)
+	}
+
+	lockerKey, err := ctx.GetStub().CreateCompositeKey(config.LockerPrefix, []string{lockerChaincode})
+	if err != nil {
+		return false, fmt.Errorf("[SetLocker] CreateCompositeKey[ lockerKey: %s%s ] error, throw-err: %v", config.LockerPrefix, lockerChaincode, err)
+	}
+	if enabled {
+		err = ctx.GetStub().PutState(lockerKey, []byte{'0'})
+	} else {
+		err = ctx.GetStub().DelState(lockerKey)
+	}
+	if err != nil {
+		return false, fmt.Errorf("[SetLocker] update lockerKey[ %s ] error, throw-err: %v", lockerKey, err)
+	}
+
+	return true, nil
+}
+
+// IsLocker
+// @title       IsLocker
+// @description "IsLocker returns if a chaincode is a registered locker"
+// @param       ctx              TransactionContextInterface  "ctx the transaction context"
+// @param       lockerChaincode  string                       "The name of the chaincode to check"
+// @return                       bool                         "Return true if the chaincode is a registered locker"
+func (ugc *DigitalUgcContact) IsLocker(ctx contractapi.TransactionContextInterface, lockerChaincode string) (bool, error) {
+	lockerKey, err := ctx.GetStub().CreateCompositeKey(config.LockerPrefix, []string{lockerChaincode})
+	if err != nil {
+		return false, fmt.Errorf("[IsLocker] CreateCompositeKey[ lockerKey: %s%s ] error, throw-err: %v", config.LockerPrefix, lockerChaincode, err)
+	}
+	lockerBytes, err := ctx.GetStub().GetState(lockerKey)
+	if err != nil {
+		return false, fmt.Errorf("[IsLocker] GetState[ lockerKey: %s ] error, throw-err: %v", lockerKey, err)
+	}
+
+	return len(lockerBytes) > 0, nil
+}
+
+// LockToken
+// @title       LockToken
+// @description "LockToken freezes a token in place as collateral, it must be invoked through the registered locker chaincode by the owner or an authorized operator"
+// @param       ctx              TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId          string                       "Unique ID of a non-fungible token"
+// @param       lockerChaincode  string                       "The name of the locker chaincode, the only one that can release the lock"
+// @param       reason           string                       "Why the token is locked"
+// @return                       bool                         "Return whether the lock was successful or not"
+func (ugc *DigitalUgcContact) LockToken(ctx contractapi.TransactionContextInterface, tokenId string, lockerChaincode string, reason string) (bool, error) {
+	isLocker, err := ugc.IsLocker(ctx, lockerChaincode)
+	if err != nil {
+		return false, fmt.Errorf("[LockToken] IsLocker error, throw-err: %v", err)
+	}
+	if !isLocker {
+		return false, fmt.Errorf("[LockToken] The chaincode[ %s ] is not a registered locker", lockerChaincode)
+	}
+	if err = ugc._checkCallerChaincode(ctx, lockerChaincode); err != nil {
+		return false, fmt.Errorf("[LockToken] _checkCallerChaincode error, throw-err: %v", err)
+	}
+
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[LockToken] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+
+	// Check if the sender is the owner, the approved client or an authorized operator
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[LockToken] _clientAccount for sender error, throw-err: %v", err)
+	}
+	allowed := nft.Owner == sender || nft.Approved == sender
+	if !allowed {
+		if allowed, err = ugc.IsApprovedForAll(ctx, nft.Owner, sender); err != nil {
+			return false, fmt.Errorf("[LockToken] IsApprovedForAll[ owner:%v, sender:%v ] error, throw-err: %v", nft.Owner, sender, err)
+		}
+	}
+	if !allowed {
+		return false, fmt.Errorf("[LockToken] The sender is not allowed to lock the tokenId[ %v ]", tokenId)
+	}
+
+	lock, err := ugc._readTokenLock(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[LockToken] _readTokenLock error, throw-err: %v", err)
+	}
+	if lock != nil {
+		return false, fmt.Errorf("[LockToken] The tokenId[ %v ] is already locked by[ %s ]", tokenId, lock.Locker)
+	}
+
+	now, err := ugc._txTime(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[LockToken] _txTime error, throw-err: %v", err)
+	}
+
+	lockBytes, err := json.Marshal(DigitalUgcTokenLockData{
+		TokenId:  tokenId,
+		Locked:   true,
+		Owner:    nft.Owner,
+		Locker:   lockerChaincode,
+		Reason:   reason,
+		LockedAt: now,
+	})
+	if err != nil {
+		return false, fmt.Errorf("[LockToken] Json Marshal[ lockBytes ] error, throw-err: %v", err)
+	}
+	lockKey, err := ctx.GetStub().CreateCompositeKey(config.TokenLockPrefix, []string{tokenId})
+	if err != nil {
+		return false, fmt.Errorf("[LockToken] CreateCompositeKey[ lockKey: %s%s ] error, throw-err: %v", config.TokenLockPrefix, tokenId, err)
+	}
+	err = ctx.GetStub().PutState(lockKey, lockBytes)
+	if err != nil {
+		return false, fmt.Errorf("[LockToken] PutState[ lockKey: %s ] error, throw-err: %v", lockKey, err)
+	}
+
+	// Emit the TokenLocked event
+	err = ctx.GetStub().SetEvent("TokenLocked", lockBytes)
+	if err != nil {
+		return false, fmt.Errorf("[LockToken] SetEvent[ lockBytes ] error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// UnlockToken
+// @title       UnlockToken
+// @description "UnlockToken releases a locked token, it must be invoked through the chaincode that locked it"
+// @param       ctx              TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId          string                       "Unique ID of a non-fungible token"
+// @param       lockerChaincode  string                       "The name of the locker chaincode"
+// @return                       bool                         "Return whether the release was successful or not"
+func (ugc *DigitalUgcContact) UnlockToken(ctx contractapi.TransactionContextInterface, tokenId string, lockerChaincode string) (bool, error) {
+	lock, err := ugc._readTokenLock(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[UnlockToken] _readTokenLock error, throw-err: %v", err)
+	}
+	if lock == nil {
+		return false, fmt.Errorf("[UnlockToken] The tokenId[ %v ] is not locked", tokenId)
+	}
+	if lock.Locker != lockerChaincode {
+		return false, fmt.Errorf("[UnlockToken] The tokenId[ %v ] is locked by[ %s ], not[ %s ]", tokenId, lock.Locker, lockerChaincode)
+	}
+
+	// A removed locker can still release its locks
+	if err = ugc._checkCallerChaincode(ctx, lock.Locker); err != nil {
+		return false, fmt.Errorf("[UnlockToken] _checkCallerChaincode error, throw-err: %v", err)
+	}
+
+	lockKey, err := ctx.GetStub().CreateCompositeKey(config.TokenLockPrefix, []string{tokenId})
+	if err != nil {
+		return false, fmt.Errorf("[UnlockToken] CreateCompositeKey[ lockKey: %s%s ] error, throw-err: %v", config.TokenLockPrefix, tokenId, err)
+	}
+	err = ctx.GetStub().DelState(lockKey)
+	if err != nil {
+		return false, fmt.Errorf("[UnlockToken] DelState[ lockKey: %s ] error, throw-err: %v", lockKey, err)
+	}
+
+	// Emit the TokenUnlocked event
+	lock.Locked = false
+	lockBytes, err := json.Marshal(lock)
+	if err != nil {
+		return false, fmt.Errorf("[UnlockToken] Json Marshal[ lockBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("TokenUnlocked", lockBytes)
+	if err != nil {
+		return false, fmt.Errorf("[UnlockToken] SetEvent[ lockBytes ] error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// GetLock
+// @title       GetLock
+// @description "GetLock returns the lock state of a token"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "Unique ID of a non-fungible token"
+// @return                DigitalUgcTokenLockData      "Return the lock, Locked is false if the token is not locked"
+func (ugc *DigitalUgcContact) GetLock(ctx contractapi.TransactionContextInterface, tokenId string) (*DigitalUgcTokenLockData, error) {
+	lock, err := ugc._readTokenLock(ctx, tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[GetLock] _readTokenLock error, throw-err: %v", err)
+	}
+	if lock == nil {
+		lock = &DigitalUgcTokenLockData{TokenId: tokenId}
+	}
+
+	return lock, nil
+}
+
+func (ugc *DigitalUgcContact) _readTokenLock(ctx contractapi.TransactionContextInterface, tokenId string) (*DigitalUgcTokenLockData, error) {
+	lockKey, err := ctx.GetStub().CreateCompositeKey(config.TokenLockPrefix, []string{tokenId})
+	if err != nil {
+		return nil, fmt.Errorf("[_readTokenLock] CreateCompositeKey[ lockKey: %s%s ] error, throw-err: %v", config.TokenLockPrefix, tokenId, err)
+	}
+	lockBytes, err := ctx.GetStub().GetState(lockKey)
+	if err != nil {
+		return nil, fmt.Errorf("[_readTokenLock] GetState[ lockKey: %s ] error, throw-err: %v", lockKey, err)
+	}
+	if len(lockBytes) <= 0 {
+		return nil, nil
+	}
+
+	lock := new(DigitalUgcTokenLockData)
+	err = json.Unmarshal(lockBytes, lock)
+	if err != nil {
+		return nil, fmt.Errorf("[_readTokenLock] Json Unmarshal[ lock ] error, throw-err: %v", err)
+	}
+
+	return lock, nil
+}
+
+// 校验交易提案调用的是 chaincode, 其他链码通过 InvokeChaincode 调用时提案中是该链码的名称
+func (ugc *DigitalUgcContact) _checkCallerChaincode(ctx contractapi.TransactionContextInterface, chaincode string) error {
+	caller, err := ugc._callerChaincode(ctx)
+	if err != nil {
+		return fmt.Errorf("[_checkCallerChaincode] _callerChaincode error, throw-err: %v", err)
+	}
+	if caller != chaincode {
+		return fmt.Errorf("[_checkCallerChaincode] The transaction was proposed to chaincode[ %s ], not[ %s ]", caller, chaincode)
+	}
+
+	return nil
+}
+
+// 获取交易提案调用的链码名称
+func (ugc *DigitalUgcContact) _callerChaincode(ctx contractapi.TransactionContextInterface) (string, error) {
+	signedProposal, err := ctx.GetStub().GetSignedProposal()
+	if err != nil {
+		return "", fmt.Errorf("[_callerChaincode] GetSignedProposal error, throw-err: %v", err)
+	}
+	if signedProposal == nil {
+		return "", fmt.Errorf("[_callerChaincode] The signed proposal was empty")
+	}
+
+	proposal := new(peer.Proposal)
+	if err = proto.Unmarshal(signedProposal.ProposalBytes, proposal); err != nil {
+		return "", fmt.Errorf("[_callerChaincode] Unmarshal[ proposal ] error, throw-err: %v", err)
+	}
+	payload := new(peer.ChaincodeProposalPayload)
+	if err = proto.Unmarshal(proposal.Payload, payload); err != nil {
+		return "", fmt.Errorf("[_callerChaincode] Unmarshal[ payload ] error, throw-err: %v", err)
+	}
+	invocationSpec := new(peer.ChaincodeInvocationSpec)
+	if err = proto.Unmarshal(payload.Input, invocationSpec); err != nil {
+		return "", fmt.Errorf("[_callerChaincode] Unmarshal[ invocationSpec ] error, throw-err: %v", err)
+	}
+
+	return invocationSpec.GetChaincodeSpec().GetChaincodeId().GetName(), nil
+}
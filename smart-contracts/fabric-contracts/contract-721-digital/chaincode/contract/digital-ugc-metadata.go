@@ -0,0 +1,197 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define metadata struct
+*/
+
+type DigitalUgcMetadata struct {
+	Title       string
+	Creator     string
+	ContentHash string
+	MimeType    string
+	CreatedTime int64
+}
+
+// ============== On-chain metadata extension ===============
+
+// MintWithMetadata
+// @title       MintWithMetadata
+// @description "Mint a new non-fungible token with on-chain metadata, the content hash must be unique"
+// @param       ctx           TransactionContextInterface  "ctx the transaction context"
+// @param       collectionId  string  "The collection the token belongs to, empty for none"
+// @param       tokenId       string  "Unique ID of the non-fungible token to be minted"
+// @param       tokenURI      string  "URI containing metadata of the minted non-fungible token"
+// @param       title         string  "Title of the work"
+// @param       creator       string  "Creator of the work"
+// @param       contentHash   string  "Hex encoded SHA-256 of the work content"
+// @param       mimeType      string  "Mime type of the work content"
+// @return                    int     "Return the mint code"
+func (ugc *DigitalUgcContact) MintWithMetadata(ctx contractapi.TransactionContextInterface, collectionId string, tokenId string, tokenURI string, title string, creator string, contentHash string, mimeType string) (int, error) {
+
+	// 判断tokenId
+	if tokenId = utils.StringStrip(tokenId); tokenId == "" {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintWithMetadata] tokenId is empty")
+	}
+
+	// 判断tokenUri
+	if tokenURI = utils.StringStrip(tokenURI); tokenURI == "" {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintWithMetadata] tokenURI is empty")
+	}
+
+	// 判断contentHash
+	contentHash, err := utils.NormalizeSha256Hex(contentHash)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintWithMetadata] contentHash is invalid, throw-err: %v", err)
+	}
+
+	// Get ID of submitting client identity
+	minter, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintWithMetadata] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	// 检查系列的归属和容量
+	if collectionId = utils.StringStrip(collectionId); collectionId != "" {
+		if _, code, err := ugc._checkCollectionMint(ctx, collectionId, minter, 1); err != nil {
+			return code, fmt.Errorf("[MintWithMetadata] _checkCollectionMint error, throw-err: %v", err)
+		}
+	}
+
+	createdTime, err := ugc._txTime(ctx)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintWithMetadata] _txTime error, throw-err: %v", err)
+	}
+	metadata := &DigitalUgcMetadata{
+		Title:       title,
+		Creator:     creator,
+		ContentHash: contentHash,
+		MimeType:    mimeType,
+		CreatedTime: createdTime,
+	}
+
+	_, code, err := ugc._mintNFT(ctx, minter, collectionId, tokenId, tokenURI, metadata, false)
+	if err != nil {
+		return code, fmt.Errorf("[MintWithMetadata] _mintNFT error, throw-err: %v", err)
+	}
+
+	// Update supply counter
+	err = ugc._updateSupply(ctx, collectionId, 1)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintWithMetadata] _updateSupply error, throw-err: %v", err)
+	}
+
+	return config.CODE_MINT_SUCCESS, nil
+}
+
+// GetTokenMetadata
+// @title       GetTokenMetadata
+// @description "GetTokenMetadata returns the on-chain metadata of a token"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId   string                       "The identifier for a non-fungible token"
+// @return                DigitalUgcMetadata           "Return the metadata, or null if the token was minted without it"
+func (ugc *DigitalUgcContact) GetTokenMetadata(ctx contractapi.TransactionContextInterface, tokenId string) (*DigitalUgcMetadata, error) {
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[GetTokenMetadata] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+
+	return nft.Metadata, nil
+}
+
+// VerifyContentHash
+// @title       VerifyContentHash
+// @description "VerifyContentHash proves that a token was minted for the given content"
+// @param       ctx          TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId      string                       "The identifier for a non-fungible token"
+// @param       contentHash  string                       "Hex encoded SHA-256 of the content"
+// @return                   bool                         "Return true if the token carries the content hash"
+func (ugc *DigitalUgcContact) VerifyContentHash(ctx contractapi.TransactionContextInterface, tokenId string, contentHash string) (bool, error) {
+	contentHash, err := utils.NormalizeSha256Hex(contentHash)
+	if err != nil {
+		return false, fmt.Errorf("[VerifyContentHash] contentHash is invalid, throw-err: %v", err)
+	}
+
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return false, fmt.Errorf("[VerifyContentHash] _readNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+	if nft.Metadata == nil {
+		return false, nil
+	}
+
+	return nft.Metadata.ContentHash == contentHash, nil
+}
+
+// TokenOfContentHash
+// @title       TokenOfContentHash
+// @description "TokenOfContentHash finds the token minted for the given content"
+// @param       ctx          TransactionContextInterface  "ctx the transaction context"
+// @param       contentHash  string                       "Hex encoded SHA-256 of the content"
+// @return                   string                       "Return the tokenId, or empty if the content was never minted"
+func (ugc *DigitalUgcContact) TokenOfContentHash(ctx contractapi.TransactionContextInterface, contentHash string) (string, error) {
+	contentHash, err := utils.NormalizeSha256Hex(contentHash)
+	if err != nil {
+		return "", fmt.Errorf("[TokenOfContentHash] contentHash is invalid, throw-err: %v", err)
+	}
+
+	contentHashKey, err := ctx.GetStub().CreateCompositeKey(config.ContentHashPrefix, []string{contentHash})
+	if err != nil {
+		return "", fmt.Errorf("[TokenOfContentHash] CreateCompositeKey[ contentHashKey: %s%s ] error, throw-err: %v", config.ContentHashPrefix, contentHash, err)
+	}
+	tokenIdBytes, err := ctx.GetStub().GetState(contentHashKey)
+	if err != nil {
+		return "", fmt.Errorf("[TokenOfContentHash] GetState[ contentHashKey: %s ] error, throw-err: %v", contentHashKey, err)
+	}
+
+	return string(tokenIdBytes), nil
+}
+
+// 存储 contentHash, 返回铸造错误码
+func (ugc *DigitalUgcContact) _saveContentHash(ctx contractapi.TransactionContextInterface, metadata *DigitalUgcMetadata, tokenId string) (int, error) {
+	contentHash, err := utils.NormalizeSha256Hex(metadata.ContentHash)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[_saveContentHash] contentHash is invalid, throw-err: %v", err)
+	}
+	metadata.ContentHash = contentHash
+
+	contentHashKey, err := ctx.GetStub().CreateCompositeKey(config.ContentHashPrefix, []string{contentHash})
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[_saveContentHash] CreateCompositeKey[ contentHashKey: %s%s ] error, throw-err: %v", config.ContentHashPrefix, contentHash, err)
+	}
+	tokenIdBytes, err := ctx.GetStub().GetState(contentHashKey)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[_saveContentHash] GetState[ contentHashKey: %s ] error, throw-err: %v", contentHashKey, err)
+	}
+	if len(tokenIdBytes) > 0 {
+		return config.CODE_MINT_CONTENT_HASH_MINTED, fmt.Errorf("[_saveContentHash] The contentHash[ %v ] was minted as tokenId[ %s ], TokenExist", contentHash, tokenIdBytes)
+	}
+
+	err = ctx.GetStub().PutState(contentHashKey, []byte(tokenId))
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[_saveContentHash] PutState[ contentHashKey: %s ] error, throw-err: %v", contentHashKey, err)
+	}
+
+	return config.CODE_MINT_SUCCESS, nil
+}
+
+// 销毁 contentHash
+func (ugc *DigitalUgcContact) _burnContentHash(ctx contractapi.TransactionContextInterface, contentHash string) error {
+	contentHashKey, err := ctx.GetStub().CreateCompositeKey(config.ContentHashPrefix, []string{contentHash})
+	if err != nil {
+		return fmt.Errorf("[_burnContentHash] CreateCompositeKey[ contentHashKey: %s%s ] error, throw-err: %v", config.ContentHashPrefix, contentHash, err)
+	}
+	err = ctx.GetStub().DelState(contentHashKey)
+	if err != nil {
+		return fmt.Errorf("[_burnContentHash] DelState[ contentHashKey: %s ] error, throw-err: %v", contentHashKey, err)
+	}
+
+	return nil
+}
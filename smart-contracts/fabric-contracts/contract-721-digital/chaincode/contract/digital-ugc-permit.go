@@ -0,0 +1,102 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ============== Off-chain signed approval extension ===============
+
+// Nonces
+// @title       Nonces
+// @description "Nonces returns the nonce the next signed approval or relayed call of an owner must use, nonces are kept per chaincode by the identity chaincode"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       owner     string                       "The signing account"
+// @return                int                          "Return the next nonce"
+func (ugc *DigitalUgcContact) Nonces(ctx contractapi.TransactionContextInterface, owner string) (int, error) {
+	chaincode, err := ugc._callerChaincode(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("[Nonces] _callerChaincode error, throw-err: %v", err)
+	}
+
+	args := [][]byte{[]byte(config.CoinsNoncesOfFcn), []byte(owner), []byte(chaincode)}
+	payload, err := ugc._invokeIdentityChaincode(ctx, args)
+	if err != nil {
+		return 0, fmt.Errorf("[Nonces] _invokeIdentityChaincode[ owner: %s ] error, throw-err: %v", owner, err)
+	}
+	nonce, err := strconv.Atoi(string(payload))
+	if err != nil {
+		return 0, fmt.Errorf("[Nonces] The nonce[ %s ] is invalid, throw-err: %v", payload, err)
+	}
+
+	return nonce, nil
+}
+
+// PermitForAll
+// @title       PermitForAll
+// @description "PermitForAll sets approval for all of the owner's assets with a signature made off-chain by the owner"
+// @param       ctx        TransactionContextInterface  "ctx the transaction context"
+// @param       owner      string                       "The client that owns the non-fungible tokens"
+// @param       operator   string                       "A client to add to the set of authorized operators"
+// @param       approved   bool                         "True if the operator is approved, false to revoke approval"
+// @param       deadline   int64                        "Unix seconds after which the signature expires"
+// @param       nonce      int                          "The current nonce of the owner"
+// @param       signature  string                       "Base64 ASN.1 ECDSA signature over SHA-256 of [\"PermitForAll\", domain, channel, owner, operator, approved, deadline, nonce]"
+// @return                 bool                         "Return whether the approval was successful or not"
+func (ugc *DigitalUgcContact) PermitForAll(ctx contractapi.TransactionContextInterface, owner string, operator string, approved bool, deadline int64, nonce int, signature string) (bool, error) {
+	if content := utils.StringStrip(owner); content == "" {
+		return false, fmt.Errorf("[PermitForAll] owner was empty")
+	}
+
+	err := ugc._checkDeadline(ctx, deadline)
+	if err != nil {
+		return false, fmt.Errorf("[PermitForAll] _checkDeadline error, throw-err: %v", err)
+	}
+
+	// 签名数据包含方法名、合约域名和通道
+	payload, err := utils.CanonicalPayload("PermitForAll", config.PermitDomain, ctx.GetStub().GetChannelID(), owner, operator, strconv.FormatBool(approved), strconv.FormatInt(deadline, 10), strconv.Itoa(nonce))
+	if err != nil {
+		return false, fmt.Errorf("[PermitForAll] CanonicalPayload error, throw-err: %v", err)
+	}
+
+	// 验证签名并消耗 nonce, 防止重放
+	err = ugc._useNonce(ctx, owner, payload, signature, nonce)
+	if err != nil {
+		return false, fmt.Errorf("[PermitForAll] _useNonce error, throw-err: %v", err)
+	}
+
+	err = ugc._setApprovalForAll(ctx, owner, operator, approved)
+	if err != nil {
+		return false, fmt.Errorf("[PermitForAll] _setApprovalForAll error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// 由身份合约使用签名者登记的公钥验证对 payload 的签名, 并消耗签名者在本合约的 nonce
+func (ugc *DigitalUgcContact) _useNonce(ctx contractapi.TransactionContextInterface, signer string, payload []byte, signature string, nonce int) error {
+	args := [][]byte{[]byte(config.CoinsUseNonceFcn), []byte(signer), payload, []byte(signature), []byte(strconv.Itoa(nonce))}
+	_, err := ugc._invokeIdentityChaincode(ctx, args)
+	if err != nil {
+		return fmt.Errorf("[_useNonce] _invokeIdentityChaincode[ signer: %s ] error, throw-err: %v", signer, err)
+	}
+
+	return nil
+}
+
+// 校验签名是否过期, 以交易时间为准
+func (ugc *DigitalUgcContact) _checkDeadline(ctx contractapi.TransactionContextInterface, deadline int64) error {
+	txTime, err := ugc._txTime(ctx)
+	if err != nil {
+		return fmt.Errorf("[_checkDeadline] _txTime error, throw-err: %v", err)
+	}
+	if txTime > deadline {
+		return fmt.Errorf("[_checkDeadline] The signature expired at[ %d ], transaction time[ %d ]", deadline, txTime)
+	}
+
+	return nil
+}
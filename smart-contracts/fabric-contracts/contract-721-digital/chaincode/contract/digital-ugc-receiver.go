@@ -0,0 +1,143 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define receiver struct
+*/
+
+type DigitalUgcReceiverData struct {
+	Account   string
+	Chaincode string
+	Channel   string
+}
+
+// ============== Safe transfer receiver extension ===============
+
+// RegisterReceiver
+// @title       RegisterReceiver
+// @description "RegisterReceiver registers the chaincode notified by SafeTransferFrom when the caller receives a token"
+// @param       ctx        TransactionContextInterface  "ctx the transaction context"
+// @param       chaincode  string                       "The receiver chaincode implementing OnERC721Received"
+// @param       channel    string                       "The channel of the receiver chaincode, empty for the current channel"
+// @return                 bool                         "Return whether the registration was successful or not"
+func (ugc *DigitalUgcContact) RegisterReceiver(ctx contractapi.TransactionContextInterface, chaincode string, channel string) (bool, error) {
+	if chaincode = utils.StringStrip(chaincode); chaincode == "" {
+		return false, fmt.Errorf("[RegisterReceiver] chaincode was empty")
+	}
+
+	account, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[RegisterReceiver] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	receiverKey, err := ctx.GetStub().CreateCompositeKey(config.ReceiverPrefix, []string{account})
+	if err != nil {
+		return false, fmt.Errorf("[RegisterReceiver] CreateCompositeKey[ receiverKey: %s%s ] error, throw-err: %v", config.ReceiverPrefix, account, err)
+	}
+	receiverBytes, err := json.Marshal(DigitalUgcReceiverData{
+		Account:   account,
+		Chaincode: chaincode,
+		Channel:   utils.StringStrip(channel),
+	})
+	if err != nil {
+		return false, fmt.Errorf("[RegisterReceiver] Json Marshal[ receiverBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().PutState(receiverKey, receiverBytes)
+	if err != nil {
+		return false, fmt.Errorf("[RegisterReceiver] PutState[ receiverKey, receiverBytes ] error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// UnregisterReceiver
+// @title       UnregisterReceiver
+// @description "UnregisterReceiver removes the receiver chaincode of the caller"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @return                bool                         "Return whether the removal was successful or not"
+func (ugc *DigitalUgcContact) UnregisterReceiver(ctx contractapi.TransactionContextInterface) (bool, error) {
+	account, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[UnregisterReceiver] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	receiverKey, err := ctx.GetStub().CreateCompositeKey(config.ReceiverPrefix, []string{account})
+	if err != nil {
+		return false, fmt.Errorf("[UnregisterReceiver] CreateCompositeKey[ receiverKey: %s%s ] error, throw-err: %v", config.ReceiverPrefix, account, err)
+	}
+	err = ctx.GetStub().DelState(receiverKey)
+	if err != nil {
+		return false, fmt.Errorf("[UnregisterReceiver] DelState[ receiverKey: %s ] error, throw-err: %v", receiverKey, err)
+	}
+
+	return true, nil
+}
+
+// GetReceiver
+// @title       GetReceiver
+// @description "GetReceiver returns the receiver chaincode registered by an account"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       account   string                       "The account to query"
+// @return                DigitalUgcReceiverData       "Return the receiver, or null if none is registered"
+func (ugc *DigitalUgcContact) GetReceiver(ctx contractapi.TransactionContextInterface, account string) (*DigitalUgcReceiverData, error) {
+	receiver, err := ugc._readReceiver(ctx, account)
+	if err != nil {
+		return nil, fmt.Errorf("[GetReceiver] _readReceiver[ account: %s ] error, throw-err: %v", account, err)
+	}
+
+	return receiver, nil
+}
+
+func (ugc *DigitalUgcContact) _readReceiver(ctx contractapi.TransactionContextInterface, account string) (*DigitalUgcReceiverData, error) {
+	receiverKey, err := ctx.GetStub().CreateCompositeKey(config.ReceiverPrefix, []string{account})
+	if err != nil {
+		return nil, fmt.Errorf("[_readReceiver] CreateCompositeKey[ receiverKey: %s%s ] error, throw-err: %v", config.ReceiverPrefix, account, err)
+	}
+	receiverBytes, err := ctx.GetStub().GetState(receiverKey)
+	if err != nil {
+		return nil, fmt.Errorf("[_readReceiver] GetState[ receiverKey: %s ] error, throw-err: %v", receiverKey, err)
+	}
+	if len(receiverBytes) <= 0 {
+		return nil, nil
+	}
+
+	receiver := new(DigitalUgcReceiverData)
+	err = json.Unmarshal(receiverBytes, receiver)
+	if err != nil {
+		return nil, fmt.Errorf("[_readReceiver] Json Unmarshal[ receiver ] error, throw-err: %v", err)
+	}
+
+	return receiver, nil
+}
+
+// 若接收方注册了合约, 调用其 OnERC721Received, 返回值必须为 true
+func (ugc *DigitalUgcContact) _checkOnERC721Received(ctx contractapi.TransactionContextInterface, operator string, from string, to string, tokenId string, data string) error {
+	receiver, err := ugc._readReceiver(ctx, to)
+	if err != nil {
+		return fmt.Errorf("[_checkOnERC721Received] _readReceiver[ account: %s ] error, throw-err: %v", to, err)
+	}
+	if receiver == nil {
+		return nil
+	}
+
+	args := [][]byte{[]byte(config.OnERC721ReceivedFcn), []byte(operator), []byte(from), []byte(tokenId), []byte(data)}
+	response := ctx.GetStub().InvokeChaincode(receiver.Chaincode, args, receiver.Channel)
+	if response.Status != shim.OK {
+		return fmt.Errorf("[_checkOnERC721Received] InvokeChaincode[ %s ] error, throw-err: %v", receiver.Chaincode, response.Message)
+	}
+	if strings.TrimSpace(string(response.Payload)) != "true" {
+		return fmt.Errorf("[_checkOnERC721Received] The receiver chaincode[ %s ] rejected the tokenId[ %s ]", receiver.Chaincode, tokenId)
+	}
+
+	return nil
+}
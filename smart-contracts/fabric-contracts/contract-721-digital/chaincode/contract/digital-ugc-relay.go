@@ -0,0 +1,113 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define relay struct
+*/
+
+type DigitalUgcSignedPayload struct {
+	Signer   string
+	Function string
+	Args     []string
+	Nonce    int
+	Deadline int64
+	Domain   string
+	Channel  string
+}
+
+// ============== Meta-transaction relay extension ===============
+
+// ExecuteSigned
+// @title       ExecuteSigned
+// @description "ExecuteSigned runs TransferFrom or SafeTransferFrom as the signer of the payload, so a relayer can submit on behalf of a user"
+// @param       ctx        TransactionContextInterface  "ctx the transaction context"
+// @param       payload    string                       "JSON encoded DigitalUgcSignedPayload"
+// @param       signature  string                       "Base64 ASN.1 ECDSA signature over SHA-256 of the payload bytes"
+// @return                 bool                         "Return whether the call was successful or not"
+func (ugc *DigitalUgcContact) ExecuteSigned(ctx contractapi.TransactionContextInterface, payload string, signature string) (bool, error) {
+	signed, err := ugc._verifySignedPayload(ctx, payload, signature)
+	if err != nil {
+		return false, fmt.Errorf("[ExecuteSigned] _verifySignedPayload error, throw-err: %v", err)
+	}
+
+	switch signed.Function {
+	case "TransferFrom":
+		if len(signed.Args) != 3 {
+			return false, fmt.Errorf("[ExecuteSigned] TransferFrom expects 3 args[ from, to, tokenId ], got %d", len(signed.Args))
+		}
+		from, to, tokenId := signed.Args[0], signed.Args[1], utils.StringStrip(signed.Args[2])
+		if utils.StringStrip(from) == "" || utils.StringStrip(to) == "" || tokenId == "" {
+			return false, fmt.Errorf("[ExecuteSigned] TransferFrom args must not be empty")
+		}
+
+		_, err = ugc._transform(ctx, signed.Signer, from, to, tokenId, "")
+		if err != nil {
+			return false, fmt.Errorf("[ExecuteSigned] _transform error, throw-err: %v", err)
+		}
+	case "SafeTransferFrom":
+		if len(signed.Args) != 4 {
+			return false, fmt.Errorf("[ExecuteSigned] SafeTransferFrom expects 4 args[ from, to, tokenId, data ], got %d", len(signed.Args))
+		}
+		from, to, tokenId, data := signed.Args[0], utils.StringStrip(signed.Args[1]), utils.StringStrip(signed.Args[2]), signed.Args[3]
+		if to == "" || to == config.EmptyAccount {
+			return false, fmt.Errorf("[ExecuteSigned] to was empty or the zero address")
+		}
+		if utils.StringStrip(from) == "" || tokenId == "" {
+			return false, fmt.Errorf("[ExecuteSigned] SafeTransferFrom args must not be empty")
+		}
+
+		_, err = ugc._transform(ctx, signed.Signer, from, to, tokenId, data)
+		if err != nil {
+			return false, fmt.Errorf("[ExecuteSigned] _transform error, throw-err: %v", err)
+		}
+		err = ugc._checkOnERC721Received(ctx, signed.Signer, from, to, tokenId, data)
+		if err != nil {
+			return false, fmt.Errorf("[ExecuteSigned] _checkOnERC721Received error, throw-err: %v", err)
+		}
+	default:
+		return false, fmt.Errorf("[ExecuteSigned] The function[ %s ] cannot be relayed", signed.Function)
+	}
+
+	return true, nil
+}
+
+// 解析并校验签名数据: 合约域名、通道、有效期、签名和 nonce
+func (ugc *DigitalUgcContact) _verifySignedPayload(ctx contractapi.TransactionContextInterface, payload string, signature string) (*DigitalUgcSignedPayload, error) {
+	signed := new(DigitalUgcSignedPayload)
+	err := json.Unmarshal([]byte(payload), signed)
+	if err != nil {
+		return nil, fmt.Errorf("[_verifySignedPayload] Json Unmarshal[ payload ] error, throw-err: %v", err)
+	}
+	if utils.StringStrip(signed.Signer) == "" {
+		return nil, fmt.Errorf("[_verifySignedPayload] signer was empty")
+	}
+
+	// The domain and channel bind the signature to this chaincode, it cannot be replayed elsewhere
+	if signed.Domain != config.PermitDomain {
+		return nil, fmt.Errorf("[_verifySignedPayload] The domain[ %s ] does not match[ %s ]", signed.Domain, config.PermitDomain)
+	}
+	if channel := ctx.GetStub().GetChannelID(); signed.Channel != channel {
+		return nil, fmt.Errorf("[_verifySignedPayload] The channel[ %s ] does not match[ %s ]", signed.Channel, channel)
+	}
+
+	err = ugc._checkDeadline(ctx, signed.Deadline)
+	if err != nil {
+		return nil, fmt.Errorf("[_verifySignedPayload] _checkDeadline error, throw-err: %v", err)
+	}
+
+	// 验证签名并消耗 nonce, 防止重放
+	err = ugc._useNonce(ctx, signed.Signer, []byte(payload), signature, signed.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("[_verifySignedPayload] _useNonce error, throw-err: %v", err)
+	}
+
+	return signed, nil
+}
@@ -0,0 +1,291 @@
+package contract
+
+import (
+	"contract-721-digital/chaincode/config"
+	"contract-721-digital/chaincode/utils"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+/*
+	Define transferability struct
+*/
+
+type DigitalUgcTransferRuleData struct {
+	Subject   string
+	Issuer    string
+	Mode      string
+	Until     int64
+	Allowlist []string
+	UpdatedBy string
+}
+
+type DigitalUgcTransferabilityData struct {
+	TokenId      string
+	CollectionId string
+	Source       string
+	Issuer       string
+	Mode         string
+	Until        int64
+	Allowlist    []string
+	Transferable bool
+}
+
+type EventTransferRuleUpdated struct {
+	Prefix   string
+	Subject  string
+	Mode     string
+	Operator string
+}
+
+// ============== Transferability extension ===============
+
+// SetTokenTransferRule
+// @title       SetTokenTransferRule
+// @description "SetTokenTransferRule restricts the transfer of a single token, it takes precedence over the collection rule, admin only"
+// @param       ctx        TransactionContextInterface  "ctx the transaction context"
+// @param       tokenId    string                       "Unique ID of a non-fungible token"
+// @param       issuer     string                       "Transfers out of this account are not restricted, empty for none"
+// @param       mode       string                       "free, soulbound, timelock or allowlist"
+// @param       until      int64                        "The timelock mode allows transfers from this Unix time"
+// @param       allowlist  []string                     "The allowlist mode only allows transfers to these accounts"
+// @return                 bool                         "Return whether the update was successful or not"
+func (ugc *DigitalUgcContact) SetTokenTransferRule(ctx contractapi.TransactionContextInterface, tokenId string, issuer string, mode string, until int64, allowlist []string) (bool, error) {
+	if tokenId = utils.StringStrip(tokenId); tokenId == "" {
+		return false, fmt.Errorf("[SetTokenTransferRule] tokenId was empty")
+	}
+	if _, err := ugc._readNFT(ctx, tokenId); err != nil {
+		return false, fmt.Errorf("[SetTokenTransferRule] _readNFT for tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+
+	err := ugc._setTransferRule(ctx, config.TransferRulePrefix, tokenId, issuer, mode, until, allowlist)
+	if err != nil {
+		return false, fmt.Errorf("[SetTokenTransferRule] _setTransferRule error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// SetCollectionTransferRule
+// @title       SetCollectionTransferRule
+// @description "SetCollectionTransferRule restricts the transfer of every token in a collection, admin only"
+// @param       ctx           TransactionContextInterface  "ctx the transaction context"
+// @param       collectionId  string                       "The identifier for a collection"
+// @param       issuer        string                       "Transfers out of this account are not restricted, empty for none"
+// @param       mode          string                       "free, soulbound, timelock or allowlist"
+// @param       until         int64                        "The timelock mode allows transfers from this Unix time"
+// @param       allowlist     []string                     "The allowlist mode only allows transfers to these accounts"
+// @return                    bool                         "Return whether the update was successful or not"
+func (ugc *DigitalUgcContact) SetCollectionTransferRule(ctx contractapi.TransactionContextInterface, collectionId string, issuer string, mode string, until int64, allowlist []string) (bool, error) {
+	if collectionId = utils.StringStrip(collectionId); collectionId == "" {
+		return false, fmt.Errorf("[SetCollectionTransferRule] collectionId was empty")
+	}
+	if _, err := ugc._readCollection(ctx, collectionId); err != nil {
+		return false, fmt.Errorf("[SetCollectionTransferRule] _readCollection collectionId[ %v ] error, throw-err: %v", collectionId, err)
+	}
+
+	err := ugc._setTransferRule(ctx, config.CollectionTransferRulePrefix, collectionId, issuer, mode, until, allowlist)
+	if err != nil {
+		return false, fmt.Errorf("[SetCollectionTransferRule] _setTransferRule error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
+// IsTransferable
+// @title       IsTransferable
+// @description "IsTransferable returns the transfer rule in effect for a token and whether it can be transferred now"
+// @param       ctx       TransactionContextInterface    "ctx the transaction context"
+// @param       tokenId   string                         "Unique ID of a non-fungible token"
+// @return                DigitalUgcTransferabilityData  "Return the rule, Transferable is true for the allowlist mode"
+func (ugc *DigitalUgcContact) IsTransferable(ctx contractapi.TransactionContextInterface, tokenId string) (*DigitalUgcTransferabilityData, error) {
+	nft, err := ugc._readNFT(ctx, tokenId)
+	if err != nil {
+		return nil, fmt.Errorf("[IsTransferable] _readNFT for tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+
+	rule, source, err := ugc._effectiveTransferRule(ctx, nft)
+	if err != nil {
+		return nil, fmt.Errorf("[IsTransferable] _effectiveTransferRule error, throw-err: %v", err)
+	}
+	now, err := ugc._txTime(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[IsTransferable] _txTime error, throw-err: %v", err)
+	}
+
+	transferability := &DigitalUgcTransferabilityData{
+		TokenId:      tokenId,
+		CollectionId: nft.CollectionId,
+		Source:       source,
+		Mode:         config.TransferModeFree,
+		Allowlist:    []string{},
+		Transferable: true,
+	}
+	if rule != nil {
+		transferability.Issuer = rule.Issuer
+		transferability.Mode = rule.Mode
+		transferability.Until = rule.Until
+		transferability.Allowlist = rule.Allowlist
+		switch rule.Mode {
+		case config.TransferModeSoulbound:
+			transferability.Transferable = false
+		case config.TransferModeTimelock:
+			transferability.Transferable = now >= rule.Until
+		}
+	}
+
+	return transferability, nil
+}
+
+// 校验 nft 能否从 from 转给 to, 不能转移时返回原因
+func (ugc *DigitalUgcContact) _checkTransferRule(ctx contractapi.TransactionContextInterface, from string, to string, nft DigitalUgcBaseData) (string, error) {
+	rule, source, err := ugc._effectiveTransferRule(ctx, nft)
+	if err != nil {
+		return "", fmt.Errorf("[_checkTransferRule] _effectiveTransferRule error, throw-err: %v", err)
+	}
+	if rule == nil || rule.Issuer == from {
+		return "", nil
+	}
+
+	switch rule.Mode {
+	case config.TransferModeSoulbound:
+		return fmt.Sprintf("The %s is soulbound and cannot be transferred", source), nil
+	case config.TransferModeTimelock:
+		now, err := ugc._txTime(ctx)
+		if err != nil {
+			return "", fmt.Errorf("[_checkTransferRule] _txTime error, throw-err: %v", err)
+		}
+		if now < rule.Until {
+			return fmt.Sprintf("The %s is locked until %d", source, rule.Until), nil
+		}
+	case config.TransferModeAllowlist:
+		for _, account := range rule.Allowlist {
+			if account == to {
+				return "", nil
+			}
+		}
+		return fmt.Sprintf("The %s can only be transferred to allowlisted accounts", source), nil
+	}
+
+	return "", nil
+}
+
+// token 的规则优先于 collection 的规则, 都没有时返回 nil; source 为 token 或 collection
+func (ugc *DigitalUgcContact) _effectiveTransferRule(ctx contractapi.TransactionContextInterface, nft DigitalUgcBaseData) (*DigitalUgcTransferRuleData, string, error) {
+	rule, err := ugc._readTransferRule(ctx, config.TransferRulePrefix, nft.TokenId)
+	if err != nil {
+		return nil, "", err
+	}
+	if rule != nil {
+		return rule, "token", nil
+	}
+	if nft.CollectionId == "" {
+		return nil, "", nil
+	}
+
+	rule, err = ugc._readTransferRule(ctx, config.CollectionTransferRulePrefix, nft.CollectionId)
+	if err != nil {
+		return nil, "", err
+	}
+	if rule != nil {
+		return rule, "collection", nil
+	}
+
+	return nil, "", nil
+}
+
+func (ugc *DigitalUgcContact) _readTransferRule(ctx contractapi.TransactionContextInterface, prefix string, subject string) (*DigitalUgcTransferRuleData, error) {
+	ruleKey, err := ctx.GetStub().CreateCompositeKey(prefix, []string{subject})
+	if err != nil {
+		return nil, fmt.Errorf("[_readTransferRule] CreateCompositeKey[ ruleKey: %s%s ] error, throw-err: %v", prefix, subject, err)
+	}
+	ruleBytes, err := ctx.GetStub().GetState(ruleKey)
+	if err != nil {
+		return nil, fmt.Errorf("[_readTransferRule] GetState[ ruleKey: %s ] error, throw-err: %v", ruleKey, err)
+	}
+	if len(ruleBytes) <= 0 {
+		return nil, nil
+	}
+
+	rule := new(DigitalUgcTransferRuleData)
+	err = json.Unmarshal(ruleBytes, rule)
+	if err != nil {
+		return nil, fmt.Errorf("[_readTransferRule] Json Unmarshal[ rule ] error, throw-err: %v", err)
+	}
+
+	return rule, nil
+}
+
+// 保存转让规则, free 方式删除规则
+func (ugc *DigitalUgcContact) _setTransferRule(ctx contractapi.TransactionContextInterface, prefix string, subject string, issuer string, mode string, until int64, allowlist []string) error {
+	if allowlist == nil {
+		allowlist = []string{}
+	}
+	switch mode {
+	case config.TransferModeFree, config.TransferModeSoulbound:
+	case config.TransferModeTimelock:
+		if until <= 0 {
+			return fmt.Errorf("[_setTransferRule] The timelock mode needs a positive until")
+		}
+	case config.TransferModeAllowlist:
+		if len(allowlist) == 0 {
+			return fmt.Errorf("[_setTransferRule] The allowlist mode needs a non-empty allowlist")
+		}
+	default:
+		return fmt.Errorf("[_setTransferRule] Unknown transfer mode[ %s ]", mode)
+	}
+
+	if err := ugc._authorizeAdmin(ctx); err != nil {
+		return fmt.Errorf("[_setTransferRule] _authorizeAdmin error, throw-err: %v", err)
+	}
+	operator, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return fmt.Errorf("[_setTransferRule] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	ruleKey, err := ctx.GetStub().CreateCompositeKey(prefix, []string{subject})
+	if err != nil {
+		return fmt.Errorf("[_setTransferRule] CreateCompositeKey[ ruleKey: %s%s ] error, throw-err: %v", prefix, subject, err)
+	}
+	if mode == config.TransferModeFree {
+		err = ctx.GetStub().DelState(ruleKey)
+		if err != nil {
+			return fmt.Errorf("[_setTransferRule] DelState[ ruleKey: %s ] error, throw-err: %v", ruleKey, err)
+		}
+	} else {
+		ruleBytes, err := json.Marshal(DigitalUgcTransferRuleData{
+			Subject:   subject,
+			Issuer:    issuer,
+			Mode:      mode,
+			Until:     until,
+			Allowlist: allowlist,
+			UpdatedBy: operator,
+		})
+		if err != nil {
+			return fmt.Errorf("[_setTransferRule] Json Marshal[ ruleBytes ] error, throw-err: %v", err)
+		}
+		err = ctx.GetStub().PutState(ruleKey, ruleBytes)
+		if err != nil {
+			return fmt.Errorf("[_setTransferRule] PutState[ ruleKey: %s ] error, throw-err: %v", ruleKey, err)
+		}
+	}
+
+	// Emit the TransferRuleUpdated event
+	newEventBytes, err := json.Marshal(EventTransferRuleUpdated{
+		Prefix:   prefix,
+		Subject:  subject,
+		Mode:     mode,
+		Operator: operator,
+	})
+	if err != nil {
+		return fmt.Errorf("[_setTransferRule] Json Marshal[ newEventBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("TransferRuleUpdated", newEventBytes)
+	if err != nil {
+		return fmt.Errorf("[_setTransferRule] SetEvent[ newEventBytes ] error, throw-err: %v", err)
+	}
+
+	return nil
+}
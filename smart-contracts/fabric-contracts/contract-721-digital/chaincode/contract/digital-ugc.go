@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
@@ -14,10 +15,12 @@ import (
 */
 
 type DigitalUgcBaseData struct {
-	TokenId  string
-	Owner    string
-	TokenURI string
-	Approved string
+	TokenId      string
+	Owner        string
+	TokenURI     string
+	Approved     string
+	CollectionId string
+	Metadata     *DigitalUgcMetadata `json:",omitempty"`
 }
 
 type DigitalUgcApprovalData struct {
@@ -32,6 +35,12 @@ type DigitalUgcApprovalAllData struct {
 	Approved bool
 }
 
+type DigitalUgcTokenPage struct {
+	TokenIds            []string
+	Bookmark            string
+	FetchedRecordsCount int32
+}
+
 /*
 	Define event struct
 */
@@ -39,6 +48,7 @@ type EventTransfer struct {
 	From    string
 	To      string
 	TokenId string
+	Data    string `json:",omitempty"`
 }
 
 type EventApproval struct {
@@ -132,8 +142,13 @@ func (ugc *DigitalUgcContact) TransferFrom(ctx contractapi.TransactionContextInt
 		return false, fmt.Errorf("[TransferFrom] tokenId was empty")
 	}
 
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[TransferFrom] _clientAccount for sender error, throw-err: %v", err)
+	}
+
 	// 转账
-	_, err := ugc._transform(ctx, from, to, tokenId)
+	_, err = ugc._transform(ctx, sender, from, to, tokenId, "")
 	if err != nil {
 		return false, fmt.Errorf("[TransferFrom] _transform error, throw-err: %v", err)
 	}
@@ -143,11 +158,11 @@ func (ugc *DigitalUgcContact) TransferFrom(ctx contractapi.TransactionContextInt
 
 // TransferFromBatch
 // @title       TransferFromBatch
-// @description "TransferFrom transfers the ownership of a non-fungible token from one owner to another owner"
+// @description "TransferFromBatch transfers the ownership of some non-fungible tokens, either all of them or none"
 // @param       ctx       TransactionContextInterface  "ctx the transaction context"
-// @param       from      string                       "The current owner of the non-fungible token"
+// @param       from      string                       "The current owner of the non-fungible tokens"
 // @param       to        string                       "The new owner"
-// @param       tokenId   string                       "the non-fungible token to transfer"
+// @param       tokenIds  []string                     "the non-fungible tokens to transfer"
 // @return                bool                         "Return whether the transfer was successful or not"
 func (ugc *DigitalUgcContact) TransferFromBatch(ctx contractapi.TransactionContextInterface, from string, to string, tokenIds []string) (bool, error) {
 
@@ -166,16 +181,23 @@ func (ugc *DigitalUgcContact) TransferFromBatch(ctx contractapi.TransactionConte
 		return false, fmt.Errorf("[TransferFromBatch] tokenIds list was empty")
 	}
 
-	// 检查tokenId
-	for _, tokenId := range tokenIds {
-		if tokenId = utils.StringStrip(tokenId); tokenId == "" {
-			return false, fmt.Errorf("[TransferFromBatch] tokenIds list found empty tokenId")
-		}
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[TransferFromBatch] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	// 转账前校验整个批次, 列出所有不能转移的 tokenId
+	issues, err := ugc._validateTransferBatch(ctx, sender, from, []string{to}, tokenIds)
+	if err != nil {
+		return false, fmt.Errorf("[TransferFromBatch] _validateTransferBatch error, throw-err: %v", err)
+	}
+	if len(issues) > 0 {
+		return false, fmt.Errorf("[TransferFromBatch] %d of %d tokenIds cannot be transferred: %s", len(issues), len(tokenIds), ugc._formatTransferIssues(issues))
 	}
 
 	// 转账
 	for _, tokenId := range tokenIds {
-		_, err := ugc._transform(ctx, from, to, tokenId)
+		_, err = ugc._transform(ctx, sender, from, to, tokenId, "")
 		if err != nil {
 			return false, fmt.Errorf("[TransferFromBatch] _transform error, throw-err: %v", err)
 		}
@@ -184,6 +206,52 @@ func (ugc *DigitalUgcContact) TransferFromBatch(ctx contractapi.TransactionConte
 	return true, nil
 }
 
+// SafeTransferFrom
+// @title       SafeTransferFrom
+// @description "SafeTransferFrom transfers the ownership of a non-fungible token and notifies the receiver chaincode registered by the new owner"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       from      string                       "The current owner of the non-fungible token"
+// @param       to        string                       "The new owner"
+// @param       tokenId   string                       "the non-fungible token to transfer"
+// @param       data      string                       "Additional data with no specified format, recorded in the Transfer event"
+// @return                bool                         "Return whether the transfer was successful or not"
+func (ugc *DigitalUgcContact) SafeTransferFrom(ctx contractapi.TransactionContextInterface, from string, to string, tokenId string, data string) (bool, error) {
+
+	// 检查from
+	if content := utils.StringStrip(from); content == "" {
+		return false, fmt.Errorf("[SafeTransferFrom] from was empty")
+	}
+
+	// 检查to, 不能转给空账户
+	if to = utils.StringStrip(to); to == "" || to == config.EmptyAccount {
+		return false, fmt.Errorf("[SafeTransferFrom] to was empty or the zero address")
+	}
+
+	// 检查tokenId
+	if tokenId = utils.StringStrip(tokenId); tokenId == "" {
+		return false, fmt.Errorf("[SafeTransferFrom] tokenId was empty")
+	}
+
+	sender, err := ugc._clientAccount(ctx)
+	if err != nil {
+		return false, fmt.Errorf("[SafeTransferFrom] _clientAccount for sender error, throw-err: %v", err)
+	}
+
+	// 转账
+	_, err = ugc._transform(ctx, sender, from, to, tokenId, data)
+	if err != nil {
+		return false, fmt.Errorf("[SafeTransferFrom] _transform error, throw-err: %v", err)
+	}
+
+	// 通知接收方注册的合约
+	err = ugc._checkOnERC721Received(ctx, sender, from, to, tokenId, data)
+	if err != nil {
+		return false, fmt.Errorf("[SafeTransferFrom] _checkOnERC721Received error, throw-err: %v", err)
+	}
+
+	return true, nil
+}
+
 // Approve
 // @title       Approve
 // @description "Approve changes or reaffirms the approved client for a non-fungible token"
@@ -192,9 +260,9 @@ func (ugc *DigitalUgcContact) TransferFromBatch(ctx contractapi.TransactionConte
 // @param       tokenId   string                       "the non-fungible token to approve"
 // @return                bool                         "Return whether the approval was successful or not"
 func (ugc *DigitalUgcContact) Approve(ctx contractapi.TransactionContextInterface, approved string, tokenId string) (bool, error) {
-	sender, err := ctx.GetClientIdentity().GetID()
+	sender, err := ugc._clientAccount(ctx)
 	if err != nil {
-		return false, fmt.Errorf("[Approve] GetClientIdentity.GetID for sender error, throw-err: %v", err)
+		return false, fmt.Errorf("[Approve] _clientAccount for sender error, throw-err: %v", err)
 	}
 
 	nft, err := ugc._readNFT(ctx, tokenId)
@@ -254,42 +322,14 @@ func (ugc *DigitalUgcContact) Approve(ctx contractapi.TransactionContextInterfac
 // @param       approved  bool                         "True if the operator is approved, false to revoke approval"
 // @return                bool                         "Return whether the approval was successful or not"
 func (ugc *DigitalUgcContact) SetApprovalForAll(ctx contractapi.TransactionContextInterface, operator string, approved bool) (bool, error) {
-	sender, err := ctx.GetClientIdentity().GetID()
+	sender, err := ugc._clientAccount(ctx)
 	if err != nil {
-		return false, fmt.Errorf("[SetApprovalForAll] GetClientIdentity.GetID for sender error, throw-err: %v", err)
+		return false, fmt.Errorf("[SetApprovalForAll] _clientAccount for sender error, throw-err: %v", err)
 	}
 
-	newApprovalAll := DigitalUgcApprovalAllData{
-		Owner:    sender,
-		Operator: operator,
-		Approved: approved,
-	}
-	approvalAllKey, err := ctx.GetStub().CreateCompositeKey(config.ApprovalPrefix, []string{sender, operator})
-	if err != nil {
-		return false, fmt.Errorf("[SetApprovalForAll] CreateCompositeKey[ approvalAllKey: %v%s%s ] error, throw-err: %v", config.ApprovalPrefix, sender, operator, err)
-	}
-	approvalAllBytes, err := json.Marshal(newApprovalAll)
-	if err != nil {
-		return false, fmt.Errorf("[SetApprovalForAll] Json Marshal[ approvalAllBytes ] error, throw-err: %v", err)
-	}
-	err = ctx.GetStub().PutState(approvalAllKey, approvalAllBytes)
+	err = ugc._setApprovalForAll(ctx, sender, operator, approved)
 	if err != nil {
-		return false, fmt.Errorf("[SetApprovalForAll] PutState[ approvalAllKey, approvalAllBytes ] error, throw-err: %v", err)
-	}
-
-	// Emit the ApprovalForAll event
-	approvalForAllEvent := EventApprovalAll{
-		Owner:    sender,
-		Operator: operator,
-		Approved: approved,
-	}
-	approvalForAllEventBytes, err := json.Marshal(approvalForAllEvent)
-	if err != nil {
-		return false, fmt.Errorf("[SetApprovalForAll] Json Marshal[ approvalForAllEvent ] error, throw-err: %v", err)
-	}
-	err = ctx.GetStub().SetEvent("ApprovalForAll", approvalForAllEventBytes)
-	if err != nil {
-		return false, fmt.Errorf("[SetApprovalForAll] SetEvent[ approvalForAllEventBytes ] error, throw-err: %v", err)
+		return false, fmt.Errorf("[SetApprovalForAll] _setApprovalForAll error, throw-err: %v", err)
 	}
 
 	return true, nil
@@ -385,40 +425,139 @@ func (ugc *DigitalUgcContact) TokenURI(ctx contractapi.TransactionContextInterfa
 
 // TotalSupply
 // @title       TotalSupply
-// @description "TokenURI returns a distinct Uniform Resource Identifier (URI) for a given token."
+// @description "TotalSupply returns the number of non-fungible tokens tracked by this contract."
 // @param       ctx       TransactionContextInterface  "ctx the transaction context"
-// @return                string                       "Returns a count of valid non-fungible tokens tracked by this contract, where each one of them has an assigned and queryable owner."
+// @return                int                          "Returns a count of valid non-fungible tokens tracked by this contract, where each one of them has an assigned and queryable owner."
 func (ugc *DigitalUgcContact) TotalSupply(ctx contractapi.TransactionContextInterface) (int, error) {
-	// There is a key record for every non-fungible token in the format of nftPrefix.tokenId.
-	// TotalSupply() queries for and counts all records matching nftPrefix.*
-	totalSupplyIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(config.NftPrefix, []string{})
+	// The counter is maintained by _mintNFT and Burn
+	totalSupply, found, err := ugc._readCounter(ctx, config.TotalSupplyKey)
 	if err != nil {
-		return 0, fmt.Errorf("[TotalSupply] GetStateByPartialCompositeKey[ totalSupplyIterator: %s ] error, throw-err: %v", config.NftPrefix, err)
+		return 0, fmt.Errorf("[TotalSupply] _readCounter[ %s ] error, throw-err: %v", config.TotalSupplyKey, err)
+	}
+	if found {
+		return totalSupply, nil
 	}
 
-	totalSupply := 0
-	for totalSupplyIterator.HasNext() {
-		if _, err = totalSupplyIterator.Next(); err != nil {
-			return 0, fmt.Errorf("[TotalSupply] Failed to get the next state for prefix %v: %v", config.BalancePrefix, err)
-		}
-		totalSupply++
+	// Ledgers written before the counter existed fall back to a full scan until RecountSupply is called
+	totalSupply, err = ugc._countSupply(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("[TotalSupply] _countSupply error, throw-err: %v", err)
 	}
-	defer totalSupplyIterator.Close()
 
 	return totalSupply, nil
 }
 
-// ============== Extended Functions for this contract ===============
+// RecountSupply
+// @title       RecountSupply
+// @description "RecountSupply recomputes the total and per-collection supply counters from the keyspace, admin only"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @return                int                          "Returns the recomputed total supply"
+func (ugc *DigitalUgcContact) RecountSupply(ctx contractapi.TransactionContextInterface) (int, error) {
+	if err := ugc._authorizeAdmin(ctx); err != nil {
+		return 0, fmt.Errorf("[RecountSupply] _authorizeAdmin error, throw-err: %v", err)
+	}
 
-func (ugc *DigitalUgcContact) SetOption(ctx contractapi.TransactionContextInterface, name string, symbol string) (bool, error) {
-	// Check minter authorization - this sample assumes Org1 is the issuer with privilege to set the name and symbol
-	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	totalSupply, err := ugc._countSupply(ctx)
 	if err != nil {
-		return false, fmt.Errorf("[SetOption] GetClientIdentity.GetMSPID error, throw-err: %v", err)
+		return 0, fmt.Errorf("[RecountSupply] _countSupply error, throw-err: %v", err)
 	}
 
-	if clientMSPID == "" {
-		return false, fmt.Errorf("[SetOption] client is not authorized to set the name and symbol of the token")
+	err = ugc._writeCounter(ctx, config.TotalSupplyKey, totalSupply)
+	if err != nil {
+		return 0, fmt.Errorf("[RecountSupply] _writeCounter[ %s ] error, throw-err: %v", config.TotalSupplyKey, err)
+	}
+
+	// Recount every collection from its token index
+	err = ugc._recountCollectionSupply(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("[RecountSupply] _recountCollectionSupply error, throw-err: %v", err)
+	}
+
+	return totalSupply, nil
+}
+
+// TokenByIndex
+// @title       TokenByIndex
+// @description "TokenByIndex enumerates valid non-fungible tokens in composite key order"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       index     int                          "A counter less than `TotalSupply()`"
+// @return                string                       "Returns the tokenId of the `index`th non-fungible token"
+func (ugc *DigitalUgcContact) TokenByIndex(ctx contractapi.TransactionContextInterface, index int) (string, error) {
+	if index < 0 {
+		return "", fmt.Errorf("[TokenByIndex] index[ %d ] must not be negative", index)
+	}
+
+	tokenIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(config.NftPrefix, []string{})
+	if err != nil {
+		return "", fmt.Errorf("[TokenByIndex] GetStateByPartialCompositeKey[ tokenIterator: %s ] error, throw-err: %v", config.NftPrefix, err)
+	}
+	defer tokenIterator.Close()
+
+	tokenId, err := ugc._tokenIdAtIndex(ctx, tokenIterator, index, 0)
+	if err != nil {
+		return "", fmt.Errorf("[TokenByIndex] _tokenIdAtIndex[ index: %d ] error, throw-err: %v", index, err)
+	}
+
+	return tokenId, nil
+}
+
+// TokenOfOwnerByIndex
+// @title       TokenOfOwnerByIndex
+// @description "TokenOfOwnerByIndex enumerates non-fungible tokens assigned to an owner in composite key order"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       owner     string                       "An owner whose tokens to enumerate"
+// @param       index     int                          "A counter less than `BalanceOf(owner)`"
+// @return                string                       "Returns the tokenId of the `index`th non-fungible token owned by the owner"
+func (ugc *DigitalUgcContact) TokenOfOwnerByIndex(ctx contractapi.TransactionContextInterface, owner string, index int) (string, error) {
+	if owner = utils.StringStrip(owner); owner == "" {
+		return "", fmt.Errorf("[TokenOfOwnerByIndex] owner was empty")
+	}
+	if index < 0 {
+		return "", fmt.Errorf("[TokenOfOwnerByIndex] index[ %d ] must not be negative", index)
+	}
+
+	balanceIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(config.BalancePrefix, []string{owner})
+	if err != nil {
+		return "", fmt.Errorf("[TokenOfOwnerByIndex] GetStateByPartialCompositeKey[ balanceIterator: %v%s ] error, throw-err: %v", config.BalancePrefix, owner, err)
+	}
+	defer balanceIterator.Close()
+
+	// balancePrefix.owner.tokenId, the tokenId is the second attribute
+	tokenId, err := ugc._tokenIdAtIndex(ctx, balanceIterator, index, 1)
+	if err != nil {
+		return "", fmt.Errorf("[TokenOfOwnerByIndex] _tokenIdAtIndex[ owner: %s, index: %d ] error, throw-err: %v", owner, index, err)
+	}
+
+	return tokenId, nil
+}
+
+// TokensOfOwner
+// @title       TokensOfOwner
+// @description "TokensOfOwner pages through the non-fungible tokens assigned to an owner"
+// @param       ctx       TransactionContextInterface  "ctx the transaction context"
+// @param       owner     string                       "An owner whose tokens to list"
+// @param       pageSize  int32                        "The maximum number of tokenIds to return"
+// @param       bookmark  string                       "The bookmark returned by the previous page, empty for the first page"
+// @return                DigitalUgcTokenPage          "Returns the tokenIds of this page and the bookmark of the next page"
+func (ugc *DigitalUgcContact) TokensOfOwner(ctx contractapi.TransactionContextInterface, owner string, pageSize int32, bookmark string) (*DigitalUgcTokenPage, error) {
+	if owner = utils.StringStrip(owner); owner == "" {
+		return nil, fmt.Errorf("[TokensOfOwner] owner was empty")
+	}
+
+	page, err := ugc._readTokenPage(ctx, config.BalancePrefix, []string{owner}, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("[TokensOfOwner] _readTokenPage[ owner: %s ] error, throw-err: %v", owner, err)
+	}
+
+	return page, nil
+}
+
+// ============== Extended Functions for this contract ===============
+
+func (ugc *DigitalUgcContact) SetOption(ctx contractapi.TransactionContextInterface, name string, symbol string) (bool, error) {
+	// Check minter authorization - only allowlisted minters may set the name and symbol
+	if _, err := ugc._authorizeMinter(ctx); err != nil {
+		return false, fmt.Errorf("[SetOption] client is not authorized to set the name and symbol of the token, throw-err: %v", err)
 	}
 
 	nameBytes, err := json.Marshal(name)
@@ -464,12 +603,12 @@ func (ugc *DigitalUgcContact) MintWithTokenURI(ctx contractapi.TransactionContex
 	}
 
 	// Get ID of submitting client identity
-	minter, err := ctx.GetClientIdentity().GetID()
+	minter, err := ugc._clientAccount(ctx)
 	if err != nil {
-		return config.CODE_MINT_FAILED, fmt.Errorf("[MintWithTokenURI] GetClientIdentity.GetID for sender error, throw-err: %v", err)
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintWithTokenURI] _clientAccount for sender error, throw-err: %v", err)
 	}
 
-	_, code, err := ugc._mintNFT(ctx, minter, tokenId, tokenURI, false)
+	_, code, err := ugc._mintNFT(ctx, minter, "", tokenId, tokenURI, nil, false)
 	if err != nil {
 		return code, fmt.Errorf("[MintWithTokenURI] _mintNFT error, throw-err: %v", err)
 	}
@@ -477,6 +616,12 @@ func (ugc *DigitalUgcContact) MintWithTokenURI(ctx contractapi.TransactionContex
 		return code, fmt.Errorf("[MintWithTokenURI] _mintNFT error, throw-err: %v", err)
 	}
 
+	// Update supply counter
+	err = ugc._updateSupply(ctx, "", 1)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintWithTokenURI] _updateSupply error, throw-err: %v", err)
+	}
+
 	return config.CODE_MINT_SUCCESS, nil
 }
 
@@ -494,26 +639,36 @@ func (ugc *DigitalUgcContact) MintBatchWithTokenURI(ctx contractapi.TransactionC
 		return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURI] tokenIds length must equal tokenURIs length")
 	}
 
-	// 检查tokenId是否存在空的值
+	// 检查tokenId是否存在空的值或重复的值, 本交易写入的状态不可读, 重复的tokenId会被重复计入总量
+	seenIds := make(map[string]bool, len(tokenIds))
 	for _, content := range tokenIds {
 		tokenId := utils.StringStrip(content)
 		if tokenId == "" {
 			return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURI] tokenIds list is found empty tokenId")
 		}
+		if seenIds[content] {
+			return config.CODE_MINT_TOKENID_MINTED, fmt.Errorf("[MintBatchWithTokenURI] tokenId[ %s ] is duplicated in the batch", content)
+		}
+		seenIds[content] = true
 	}
 
-	// 检查tokenUri是否存在空的值
+	// 检查tokenUri是否存在空的值或重复的值
+	seenURIs := make(map[string]bool, len(tokenURIs))
 	for _, content := range tokenURIs {
 		tokenURI := utils.StringStrip(content)
 		if tokenURI == "" {
 			return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURI] tokenURIs list is found empty uri")
 		}
+		if seenURIs[content] {
+			return config.CODE_MINT_TOKENURI_MINTED, fmt.Errorf("[MintBatchWithTokenURI] tokenURI[ %s ] is duplicated in the batch", content)
+		}
+		seenURIs[content] = true
 	}
 
 	// Get ID of submitting client identity
-	minter, err := ctx.GetClientIdentity().GetID()
+	minter, err := ugc._clientAccount(ctx)
 	if err != nil {
-		return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURI] GetClientIdentity.GetID for sender error, throw-err: %v", err)
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURI] _clientAccount for sender error, throw-err: %v", err)
 	}
 
 	// 执行批量铸造
@@ -522,12 +677,18 @@ func (ugc *DigitalUgcContact) MintBatchWithTokenURI(ctx contractapi.TransactionC
 		tokenId := tokenIds[i]
 		tokenURI := tokenURIs[i]
 
-		_, code, err := ugc._mintNFT(ctx, minter, tokenId, tokenURI, false)
+		_, code, err := ugc._mintNFT(ctx, minter, "", tokenId, tokenURI, nil, false)
 		if err != nil {
 			return code, fmt.Errorf("[MintBatchWithTokenURI] _mintNFT error, throw-err: %v", err)
 		}
 	}
 
+	// Update supply counter once, state written in this transaction is not readable yet
+	err = ugc._updateSupply(ctx, "", assetLength)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintBatchWithTokenURI] _updateSupply error, throw-err: %v", err)
+	}
+
 	return config.CODE_MINT_SUCCESS, nil
 }
 
@@ -550,18 +711,23 @@ func (ugc *DigitalUgcContact) MintFungibleTokenUriWithBatch(ctx contractapi.Tran
 		return config.CODE_MINT_FAILED, fmt.Errorf("[MintFungibleTokenUriWithBatch] tokenURI is empty")
 	}
 
-	// 检查tokenId是否存在空的值
+	// 检查tokenId是否存在空的值或重复的值, 本交易写入的状态不可读, 重复的tokenId会被重复计入总量
+	seenIds := make(map[string]bool, len(tokenIds))
 	for _, content := range tokenIds {
 		tokenId := utils.StringStrip(content)
 		if tokenId == "" {
 			return config.CODE_MINT_FAILED, fmt.Errorf("[MintFungibleTokenUriWithBatch] tokenIds list is found empty tokenId")
 		}
+		if seenIds[content] {
+			return config.CODE_MINT_TOKENID_MINTED, fmt.Errorf("[MintFungibleTokenUriWithBatch] tokenId[ %s ] is duplicated in the batch", content)
+		}
+		seenIds[content] = true
 	}
 
 	// Get ID of submitting client identity
-	minter, err := ctx.GetClientIdentity().GetID()
+	minter, err := ugc._clientAccount(ctx)
 	if err != nil {
-		return config.CODE_MINT_FAILED, fmt.Errorf("[MintFungibleTokenUriWithBatch] GetClientIdentity.GetID for sender error, throw-err: %v", err)
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintFungibleTokenUriWithBatch] _clientAccount for sender error, throw-err: %v", err)
 	}
 
 	// 执行批量铸造
@@ -569,12 +735,18 @@ func (ugc *DigitalUgcContact) MintFungibleTokenUriWithBatch(ctx contractapi.Tran
 	for i := 0; i < assetLength; i++ {
 		tokenId := tokenIds[i]
 
-		_, code, err := ugc._mintNFT(ctx, minter, tokenId, tokenURI, true)
+		_, code, err := ugc._mintNFT(ctx, minter, "", tokenId, tokenURI, nil, true)
 		if err != nil {
 			return code, fmt.Errorf("[MintFungibleTokenUriWithBatch] _mintNFT error, throw-err: %v", err)
 		}
 	}
 
+	// Update supply counter once, state written in this transaction is not readable yet
+	err = ugc._updateSupply(ctx, "", assetLength)
+	if err != nil {
+		return config.CODE_MINT_FAILED, fmt.Errorf("[MintFungibleTokenUriWithBatch] _updateSupply error, throw-err: %v", err)
+	}
+
 	return config.CODE_MINT_SUCCESS, nil
 }
 
@@ -585,9 +757,9 @@ func (ugc *DigitalUgcContact) MintFungibleTokenUriWithBatch(ctx contractapi.Tran
 // @param       tokenId   string  "Unique ID of a non-fungible token"
 // @return                bool    "Return whether the burn was successful or not"
 func (ugc *DigitalUgcContact) Burn(ctx contractapi.TransactionContextInterface, tokenId string) (bool, error) {
-	owner, err := ctx.GetClientIdentity().GetID()
+	owner, err := ugc._clientAccount(ctx)
 	if err != nil {
-		return false, fmt.Errorf("[Burn] GetClientIdentity.GetID for sender error, throw-err: %v", err)
+		return false, fmt.Errorf("[Burn] _clientAccount for sender error, throw-err: %v", err)
 	}
 
 	// Check if a caller is the owner of the non-fungible token
@@ -599,46 +771,117 @@ func (ugc *DigitalUgcContact) Burn(ctx contractapi.TransactionContextInterface,
 		return false, fmt.Errorf("[Burn] Non-fungible token %s is not owned by %s", tokenId, owner)
 	}
 
+	// Burn the token
+	err = ugc._burnNFT(ctx, nft, owner, "")
+	if err != nil {
+		return false, fmt.Errorf("[Burn] _burnNFT tokenId[ %v ] error, throw-err: %v", tokenId, err)
+	}
+
+	return true, nil
+}
+
+// 销毁 nft, 记录销毁人和原因
+func (ugc *DigitalUgcContact) _burnNFT(ctx contractapi.TransactionContextInterface, nft DigitalUgcBaseData, burnedBy string, reason string) error {
+	// A locked token cannot be burned until the locker releases it
+	lock, err := ugc._readTokenLock(ctx, nft.TokenId)
+	if err != nil {
+		return fmt.Errorf("[_burnNFT] _readTokenLock for tokenId[ %v ] error, throw-err: %v", nft.TokenId, err)
+	}
+	if lock != nil {
+		return fmt.Errorf("[_burnNFT] The tokenId[ %v ] is locked by[ %s ]", nft.TokenId, lock.Locker)
+	}
+	if nft.Owner == config.FractionVaultAccount {
+		return fmt.Errorf("[_burnNFT] The tokenId[ %v ] is fractionalized, redeem it first", nft.TokenId)
+	}
+	if nft.Owner == config.AuctionEscrowAccount {
+		return fmt.Errorf("[_burnNFT] The tokenId[ %v ] is in an auction", nft.TokenId)
+	}
+
 	// Delete the token
-	nftKey, err := ctx.GetStub().CreateCompositeKey(config.NftPrefix, []string{tokenId})
+	nftKey, err := ctx.GetStub().CreateCompositeKey(config.NftPrefix, []string{nft.TokenId})
 	if err != nil {
-		return false, fmt.Errorf("[Burn] CreateCompositeKey[ nftKey: %s%s ] error, throw-err: %v", config.NftPrefix, tokenId, err)
+		return fmt.Errorf("[_burnNFT] CreateCompositeKey[ nftKey: %s%s ] error, throw-err: %v", config.NftPrefix, nft.TokenId, err)
 	}
 	err = ctx.GetStub().DelState(nftKey)
 	if err != nil {
-		return false, fmt.Errorf("[Burn] DelState[ nftKey: %s ] error, throw-err: %v", nftKey, err)
+		return fmt.Errorf("[_burnNFT] DelState[ nftKey: %s ] error, throw-err: %v", nftKey, err)
 	}
 
 	// Remove a composite key from the balance of the owner
-	balanceKey, err := ctx.GetStub().CreateCompositeKey(config.BalancePrefix, []string{owner, tokenId})
+	balanceKey, err := ctx.GetStub().CreateCompositeKey(config.BalancePrefix, []string{nft.Owner, nft.TokenId})
 	if err != nil {
-		return false, fmt.Errorf("[Burn] CreateCompositeKey[ nftKey: %s%s%s ] error, throw-err: %v", config.BalancePrefix, owner, tokenId, err)
+		return fmt.Errorf("[_burnNFT] CreateCompositeKey[ nftKey: %s%s%s ] error, throw-err: %v", config.BalancePrefix, nft.Owner, nft.TokenId, err)
 	}
 	err = ctx.GetStub().DelState(balanceKey)
 	if err != nil {
-		return false, fmt.Errorf("[Burn] DelState[ balanceKey: %s ] error, throw-err: %v", balanceKey, err)
+		return fmt.Errorf("[_burnNFT] DelState[ balanceKey: %s ] error, throw-err: %v", balanceKey, err)
 	}
 
-	// Remove UniqueTokenUri
-	err = ugc._burnUniqueTokenUri(ctx, nft.TokenURI)
+	// Keep the tokenURI and content hash reserved when the platform asks for it,
+	// otherwise free them so the work can be minted again
+	reserveUri, err := ugc.IsBurnedUriReserved(ctx)
 	if err != nil {
-		return false, fmt.Errorf("[Burn] _burnUniqueTokenUri[ TokenURI: %s ] error, throw-err: %v", nft.TokenURI, err)
+		return fmt.Errorf("[_burnNFT] IsBurnedUriReserved error, throw-err: %v", err)
+	}
+	if !reserveUri {
+		// Remove UniqueTokenUri
+		err = ugc._burnUniqueTokenUri(ctx, nft.TokenURI)
+		if err != nil {
+			return fmt.Errorf("[_burnNFT] _burnUniqueTokenUri[ TokenURI: %s ] error, throw-err: %v", nft.TokenURI, err)
+		}
+
+		// Remove the content hash
+		if nft.Metadata != nil {
+			err = ugc._burnContentHash(ctx, nft.Metadata.ContentHash)
+			if err != nil {
+				return fmt.Errorf("[_burnNFT] _burnContentHash[ %s ] error, throw-err: %v", nft.Metadata.ContentHash, err)
+			}
+		}
+	}
+
+	// Keep a tombstone of the burned token
+	err = ugc._saveTombstone(ctx, nft, burnedBy, reason, reserveUri)
+	if err != nil {
+		return fmt.Errorf("[_burnNFT] _saveTombstone error, throw-err: %v", err)
+	}
+
+	// Remove the token from its collection
+	if nft.CollectionId != "" {
+		collectionTokenKey, err := ctx.GetStub().CreateCompositeKey(config.CollectionTokenPrefix, []string{nft.CollectionId, nft.TokenId})
+		if err != nil {
+			return fmt.Errorf("[_burnNFT] CreateCompositeKey[ collectionTokenKey: %s%s%s ] error, throw-err: %v", config.CollectionTokenPrefix, nft.CollectionId, nft.TokenId, err)
+		}
+		err = ctx.GetStub().DelState(collectionTokenKey)
+		if err != nil {
+			return fmt.Errorf("[_burnNFT] DelState[ collectionTokenKey: %s ] error, throw-err: %v", collectionTokenKey, err)
+		}
+	}
+
+	// Update supply counter
+	err = ugc._updateSupply(ctx, nft.CollectionId, -1)
+	if err != nil {
+		return fmt.Errorf("[_burnNFT] _updateSupply error, throw-err: %v", err)
 	}
 
 	// Emit the Transfer event
-	newEventTransfer, err := json.Marshal(EventTransfer{From: owner, To: "0x0", TokenId: tokenId})
+	newEventTransfer, err := json.Marshal(EventTransfer{From: nft.Owner, To: config.EmptyAccount, TokenId: nft.TokenId})
 	if err != nil {
-		return false, fmt.Errorf("[Burn] Json Marshal[ newEventTransfer ] error, throw-err: %v", err)
+		return fmt.Errorf("[_burnNFT] Json Marshal[ newEventTransfer ] error, throw-err: %v", err)
 	}
 	err = ctx.GetStub().SetEvent("Transfer", newEventTransfer)
 	if err != nil {
-		return false, fmt.Errorf("[Burn] SetEvent[ newEventTransfer ] error, throw-err: %v", err)
+		return fmt.Errorf("[_burnNFT] SetEvent[ newEventTransfer ] error, throw-err: %v", err)
 	}
 
-	return true, nil
+	return nil
 }
 
-func (ugc *DigitalUgcContact) _mintNFT(ctx contractapi.TransactionContextInterface, minter, tokenId, tokenURI string, isFungible bool) (bool, int, error) {
+func (ugc *DigitalUgcContact) _mintNFT(ctx contractapi.TransactionContextInterface, minter, collectionId, tokenId, tokenURI string, metadata *DigitalUgcMetadata, isFungible bool) (bool, int, error) {
+
+	// Check if the caller is an allowlisted minter
+	if code, err := ugc._authorizeMinter(ctx); err != nil {
+		return false, code, fmt.Errorf("[_mintNFT] _authorizeMinter error, throw-err: %v", err)
+	}
 
 	// Check if the token to be minted does not exist
 	tokenIdExists, tokenUriExists, err := ugc._nftExists(ctx, tokenId, tokenURI)
@@ -656,11 +899,21 @@ func (ugc *DigitalUgcContact) _mintNFT(ctx contractapi.TransactionContextInterfa
 		}
 	}
 
+	// Index the content hash, the same artwork cannot be minted twice under different URIs
+	if metadata != nil {
+		code, err := ugc._saveContentHash(ctx, metadata, tokenId)
+		if err != nil {
+			return false, code, fmt.Errorf("[_mintNFT] _saveContentHash[ tokenId:%s ] error, throw-err: %v", tokenId, err)
+		}
+	}
+
 	// Add a non-fungible token
 	newUgcToken := DigitalUgcBaseData{
-		TokenId:  tokenId,
-		Owner:    minter,
-		TokenURI: tokenURI,
+		TokenId:      tokenId,
+		Owner:        minter,
+		TokenURI:     tokenURI,
+		CollectionId: collectionId,
+		Metadata:     metadata,
 	}
 	newNftBytes, err := json.Marshal(newUgcToken)
 	if err != nil {
@@ -693,6 +946,18 @@ func (ugc *DigitalUgcContact) _mintNFT(ctx contractapi.TransactionContextInterfa
 		return false, config.CODE_MINT_FAILED, fmt.Errorf("[_mintNFT] _saveUniqueTokenUri[ tokenURI:%s ] error, throw-err: %v", tokenURI, err)
 	}
 
+	// Index the token by its collection
+	if collectionId != "" {
+		collectionTokenKey, err := ctx.GetStub().CreateCompositeKey(config.CollectionTokenPrefix, []string{collectionId, tokenId})
+		if err != nil {
+			return false, config.CODE_MINT_FAILED, fmt.Errorf("[_mintNFT] CreateCompositeKey[ collectionTokenKey: %s%s%s ] error, throw-err: %v", config.CollectionTokenPrefix, collectionId, tokenId, err)
+		}
+		err = ctx.GetStub().PutState(collectionTokenKey, []byte{'0'})
+		if err != nil {
+			return false, config.CODE_MINT_FAILED, fmt.Errorf("[_mintNFT] PutState[ collectionTokenKey,[]byte ] error, throw-err: %v", err)
+		}
+	}
+
 	// Emit the Transfer event
 	newTransferEvent, err := json.Marshal(EventTransfer{From: "0x0", To: minter, TokenId: tokenId})
 	if err != nil {
@@ -706,70 +971,68 @@ func (ugc *DigitalUgcContact) _mintNFT(ctx contractapi.TransactionContextInterfa
 	return true, config.CODE_MINT_SUCCESS, nil
 }
 
-func (ugc *DigitalUgcContact) _transform(ctx contractapi.TransactionContextInterface, from string, to string, tokenId string) (bool, error) {
-
-	sender, err := ctx.GetClientIdentity().GetID()
-	if err != nil {
-		return false, fmt.Errorf("[_transform] GetClientIdentity.GetID for sender error, throw-err: %v", err)
-	}
-
+func (ugc *DigitalUgcContact) _transform(ctx contractapi.TransactionContextInterface, sender string, from string, to string, tokenId string, data string) (bool, error) {
 	nft, err := ugc._readNFT(ctx, tokenId)
 	if err != nil {
 		return false, fmt.Errorf("[_transform] _readNFT for tokenId[ %v ] error, throw-err: %v", tokenId, err)
 	}
 
-	// Check if the sender is the current owner, an authorized operator,
-	// or the approved client for this non-fungible token.
-	owner := nft.Owner
-	tokenApproval := nft.Approved
-	operatorApproval, err := ugc.IsApprovedForAll(ctx, owner, sender)
+	// Check if the sender may move the token from `from` to `to`
+	reason, err := ugc._checkTransfer(ctx, sender, from, to, nft)
 	if err != nil {
-		return false, fmt.Errorf("[_transform] IsApprovedForAll[ owner:%v, sender:%v ] error, throw-err: %v", owner, sender, err)
+		return false, fmt.Errorf("[_transform] _checkTransfer for tokenId[ %v ] error, throw-err: %v", tokenId, err)
 	}
-	if owner != sender && tokenApproval != sender && !operatorApproval {
-		return false, fmt.Errorf("[_transform] The sender is not allowed to transfer the non-fungible tokenId")
+	if reason != "" {
+		return false, fmt.Errorf("[_transform] %s", reason)
 	}
 
-	// Check if `from` is the current owner
-	if owner != from {
-		return false, fmt.Errorf("[_transform] The from is not the current owner")
+	err = ugc._moveNFT(ctx, nft, from, to, data)
+	if err != nil {
+		return false, fmt.Errorf("[_transform] _moveNFT for tokenId[ %v ] error, throw-err: %v", tokenId, err)
 	}
 
+	return true, nil
+}
+
+// 将 nft 从 from 转给 to 并触发 Transfer 事件, 调用方负责校验
+func (ugc *DigitalUgcContact) _moveNFT(ctx contractapi.TransactionContextInterface, nft DigitalUgcBaseData, from string, to string, data string) error {
+	tokenId := nft.TokenId
+
 	// Clear the approved client for this non-fungible token
 	nft.Approved = ""
 	// Overwrite a non-fungible token to assign a new owner.
 	nft.Owner = to
 	nftKey, err := ctx.GetStub().CreateCompositeKey(config.NftPrefix, []string{tokenId})
 	if err != nil {
-		return false, fmt.Errorf("[_transform] CreateCompositeKey[ nftKey: %v%s ] error, throw-err: %v", config.NftPrefix, tokenId, err)
+		return fmt.Errorf("[_moveNFT] CreateCompositeKey[ nftKey: %v%s ] error, throw-err: %v", config.NftPrefix, tokenId, err)
 	}
 	nftBytes, err := json.Marshal(nft)
 	if err != nil {
-		return false, fmt.Errorf("[_transform] Json Marshal[ nftBytes ] error, throw-err: %v", err)
+		return fmt.Errorf("[_moveNFT] Json Marshal[ nftBytes ] error, throw-err: %v", err)
 	}
 	err = ctx.GetStub().PutState(nftKey, nftBytes)
 	if err != nil {
-		return false, fmt.Errorf("[_transform] PutState[ nftKey, nftBytes ] error, throw-err: %v", err)
+		return fmt.Errorf("[_moveNFT] PutState[ nftKey, nftBytes ] error, throw-err: %v", err)
 	}
 
 	// Remove a composite key from the balance of the current owner
 	balanceKeyFrom, err := ctx.GetStub().CreateCompositeKey(config.BalancePrefix, []string{from, tokenId})
 	if err != nil {
-		return false, fmt.Errorf("[_transform] CreateCompositeKey[ balanceKeyFrom: %s%s%s ] error, throw-err: %v", config.BalancePrefix, from, tokenId, err)
+		return fmt.Errorf("[_moveNFT] CreateCompositeKey[ balanceKeyFrom: %s%s%s ] error, throw-err: %v", config.BalancePrefix, from, tokenId, err)
 	}
 	err = ctx.GetStub().DelState(balanceKeyFrom)
 	if err != nil {
-		return false, fmt.Errorf("[_transform] DelState[ balanceKeyFrom: %s ] error, throw-err: %v", balanceKeyFrom, err)
+		return fmt.Errorf("[_moveNFT] DelState[ balanceKeyFrom: %s ] error, throw-err: %v", balanceKeyFrom, err)
 	}
 
 	// Save a composite key to count the balance of a new owner
 	balanceKeyTo, err := ctx.GetStub().CreateCompositeKey(config.BalancePrefix, []string{to, tokenId})
 	if err != nil {
-		return false, fmt.Errorf("[_transform] CreateCompositeKey[ balanceKeyTo: %s%s%s ] error, throw-err: %v", config.BalancePrefix, to, tokenId, err)
+		return fmt.Errorf("[_moveNFT] CreateCompositeKey[ balanceKeyTo: %s%s%s ] error, throw-err: %v", config.BalancePrefix, to, tokenId, err)
 	}
 	err = ctx.GetStub().PutState(balanceKeyTo, []byte{'0'})
 	if err != nil {
-		return false, fmt.Errorf("[_transform] PutState[ balanceKeyTo, []bytes ] error, throw-err: %v", err)
+		return fmt.Errorf("[_moveNFT] PutState[ balanceKeyTo, []bytes ] error, throw-err: %v", err)
 	}
 
 	// Emit the Transfer event
@@ -777,17 +1040,64 @@ func (ugc *DigitalUgcContact) _transform(ctx contractapi.TransactionContextInter
 		From:    from,
 		To:      to,
 		TokenId: tokenId,
+		Data:    data,
 	}
 	newEventTransferBytes, err := json.Marshal(newEventTransfer)
 	if err != nil {
-		return false, fmt.Errorf("[_transform] Json Marshal[ newEventTransfer ] error, throw-err: %v", err)
+		return fmt.Errorf("[_moveNFT] Json Marshal[ newEventTransfer ] error, throw-err: %v", err)
 	}
 	err = ctx.GetStub().SetEvent("Transfer", newEventTransferBytes)
 	if err != nil {
-		return false, fmt.Errorf("[_transform] SetEvent[ newEventTransferBytes ] error, throw-err: %v", err)
+		return fmt.Errorf("[_moveNFT] SetEvent[ newEventTransferBytes ] error, throw-err: %v", err)
 	}
 
-	return true, nil
+	return nil
+}
+
+// 检查 sender 能否将 nft 从 from 转给 to, 不能转移时返回原因
+func (ugc *DigitalUgcContact) _checkTransfer(ctx contractapi.TransactionContextInterface, sender string, from string, to string, nft DigitalUgcBaseData) (string, error) {
+	// Check if the sender is the current owner, an authorized operator,
+	// or the approved client for this non-fungible token.
+	owner := nft.Owner
+	tokenApproval := nft.Approved
+	operatorApproval, err := ugc.IsApprovedForAll(ctx, owner, sender)
+	if err != nil {
+		return "", fmt.Errorf("[_checkTransfer] IsApprovedForAll[ owner:%v, sender:%v ] error, throw-err: %v", owner, sender, err)
+	}
+	if owner != sender && tokenApproval != sender && !operatorApproval {
+		return "The sender is not allowed to transfer the non-fungible tokenId", nil
+	}
+
+	// Check if `from` is the current owner
+	if owner != from {
+		return "The from is not the current owner", nil
+	}
+
+	// Tokens only enter the fractional vault through Fractionalize
+	if to == config.FractionVaultAccount {
+		return "The to is the fractional vault, use Fractionalize", nil
+	}
+	// Tokens only enter the auction escrow through CreateAuction
+	if to == config.AuctionEscrowAccount {
+		return "The to is the auction escrow, use CreateAuction", nil
+	}
+
+	// Check if the token is locked as collateral
+	lock, err := ugc._readTokenLock(ctx, nft.TokenId)
+	if err != nil {
+		return "", fmt.Errorf("[_checkTransfer] _readTokenLock for tokenId[ %v ] error, throw-err: %v", nft.TokenId, err)
+	}
+	if lock != nil {
+		return fmt.Sprintf("The tokenId is locked by[ %s ]", lock.Locker), nil
+	}
+
+	// Check the soulbound, timelock and allowlist rules
+	reason, err := ugc._checkTransferRule(ctx, from, to, nft)
+	if err != nil {
+		return "", fmt.Errorf("[_checkTransfer] _checkTransferRule for tokenId[ %v ] error, throw-err: %v", nft.TokenId, err)
+	}
+
+	return reason, nil
 }
 
 func (ugc *DigitalUgcContact) _readNFT(ctx contractapi.TransactionContextInterface, tokenId string) (DigitalUgcBaseData, error) {
@@ -816,6 +1126,62 @@ func (ugc *DigitalUgcContact) _readNFT(ctx contractapi.TransactionContextInterfa
 	return nft, nil
 }
 
+// 遍历复合键, 返回第 index 条记录中的 tokenId
+func (ugc *DigitalUgcContact) _tokenIdAtIndex(ctx contractapi.TransactionContextInterface, iterator shim.StateQueryIteratorInterface, index int, attrIndex int) (string, error) {
+	for i := 0; iterator.HasNext(); i++ {
+		queryResponse, err := iterator.Next()
+		if err != nil {
+			return "", fmt.Errorf("[_tokenIdAtIndex] Failed to get the next state, throw-err: %v", err)
+		}
+		if i < index {
+			continue
+		}
+
+		_, attributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return "", fmt.Errorf("[_tokenIdAtIndex] SplitCompositeKey[ %s ] error, throw-err: %v", queryResponse.Key, err)
+		}
+		if len(attributes) <= attrIndex {
+			return "", fmt.Errorf("[_tokenIdAtIndex] The compositeKey[ %s ] has no attribute at %d", queryResponse.Key, attrIndex)
+		}
+		return attributes[attrIndex], nil
+	}
+
+	return "", fmt.Errorf("[_tokenIdAtIndex] The index[ %d ] is out of bounds", index)
+}
+
+// 分页读取以 tokenId 结尾的复合键, 返回 tokenId 列表和下一页书签
+func (ugc *DigitalUgcContact) _readTokenPage(ctx contractapi.TransactionContextInterface, objectType string, attributes []string, pageSize int32, bookmark string) (*DigitalUgcTokenPage, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("[_readTokenPage] pageSize[ %d ] must be a positive integer", pageSize)
+	}
+
+	pageIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(objectType, attributes, pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("[_readTokenPage] GetStateByPartialCompositeKeyWithPagination[ %s%v ] error, throw-err: %v", objectType, attributes, err)
+	}
+	defer pageIterator.Close()
+
+	tokenIds := make([]string, 0, pageSize)
+	for pageIterator.HasNext() {
+		queryResponse, err := pageIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("[_readTokenPage] Failed to get the next state for prefix[ %s ], throw-err: %v", objectType, err)
+		}
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("[_readTokenPage] SplitCompositeKey[ %s ] error, throw-err: %v", queryResponse.Key, err)
+		}
+		tokenIds = append(tokenIds, keyParts[len(keyParts)-1])
+	}
+
+	return &DigitalUgcTokenPage{
+		TokenIds:            tokenIds,
+		Bookmark:            metadata.GetBookmark(),
+		FetchedRecordsCount: metadata.GetFetchedRecordsCount(),
+	}, nil
+}
+
 func (ugc *DigitalUgcContact) _nftExists(ctx contractapi.TransactionContextInterface, tokenId string, tokenURI string) (bool, bool, error) {
 	nftKey, err := ctx.GetStub().CreateCompositeKey(config.NftPrefix, []string{tokenId})
 	if err != nil {
@@ -880,6 +1246,143 @@ func (ugc *DigitalUgcContact) _burnUniqueTokenUri(ctx contractapi.TransactionCon
 	return nil
 }
 
+// 更新 owner 对 operator 的全部授权
+func (ugc *DigitalUgcContact) _setApprovalForAll(ctx contractapi.TransactionContextInterface, owner string, operator string, approved bool) error {
+	newApprovalAll := DigitalUgcApprovalAllData{
+		Owner:    owner,
+		Operator: operator,
+		Approved: approved,
+	}
+	approvalAllKey, err := ctx.GetStub().CreateCompositeKey(config.ApprovalPrefix, []string{owner, operator})
+	if err != nil {
+		return fmt.Errorf("[_setApprovalForAll] CreateCompositeKey[ approvalAllKey: %v%s%s ] error, throw-err: %v", config.ApprovalPrefix, owner, operator, err)
+	}
+	approvalAllBytes, err := json.Marshal(newApprovalAll)
+	if err != nil {
+		return fmt.Errorf("[_setApprovalForAll] Json Marshal[ approvalAllBytes ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().PutState(approvalAllKey, approvalAllBytes)
+	if err != nil {
+		return fmt.Errorf("[_setApprovalForAll] PutState[ approvalAllKey, approvalAllBytes ] error, throw-err: %v", err)
+	}
+
+	// Emit the ApprovalForAll event
+	approvalForAllEvent := EventApprovalAll{
+		Owner:    owner,
+		Operator: operator,
+		Approved: approved,
+	}
+	approvalForAllEventBytes, err := json.Marshal(approvalForAllEvent)
+	if err != nil {
+		return fmt.Errorf("[_setApprovalForAll] Json Marshal[ approvalForAllEvent ] error, throw-err: %v", err)
+	}
+	err = ctx.GetStub().SetEvent("ApprovalForAll", approvalForAllEventBytes)
+	if err != nil {
+		return fmt.Errorf("[_setApprovalForAll] SetEvent[ approvalForAllEventBytes ] error, throw-err: %v", err)
+	}
+
+	return nil
+}
+
+// 读取交易时间戳(秒)
+func (ugc *DigitalUgcContact) _txTime(ctx contractapi.TransactionContextInterface) (int64, error) {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return 0, fmt.Errorf("[_txTime] GetTxTimestamp error, throw-err: %v", err)
+	}
+
+	return txTimestamp.GetSeconds(), nil
+}
+
+// 读取计数器, 返回计数和是否存在
+func (ugc *DigitalUgcContact) _readCounter(ctx contractapi.TransactionContextInterface, counterKey string) (int, bool, error) {
+	counterBytes, err := ctx.GetStub().GetState(counterKey)
+	if err != nil {
+		return 0, false, fmt.Errorf("[_readCounter] GetState[ %s ] error, throw-err: %v", counterKey, err)
+	}
+	if len(counterBytes) <= 0 {
+		return 0, false, nil
+	}
+
+	counter := 0
+	err = json.Unmarshal(counterBytes, &counter)
+	if err != nil {
+		return 0, false, fmt.Errorf("[_readCounter] Json Unmarshal[ %s ] error, throw-err: %v", counterKey, err)
+	}
+
+	return counter, true, nil
+}
+
+// 写入计数器
+func (ugc *DigitalUgcContact) _writeCounter(ctx contractapi.TransactionContextInterface, counterKey string, counter int) error {
+	counterBytes, err := json.Marshal(counter)
+	if err != nil {
+		return fmt.Errorf("[_writeCounter] Json Marshal[ %s ] error, throw-err: %v", counterKey, err)
+	}
+	err = ctx.GetStub().PutState(counterKey, counterBytes)
+	if err != nil {
+		return fmt.Errorf("[_writeCounter] PutState[ %s ] error, throw-err: %v", counterKey, err)
+	}
+
+	return nil
+}
+
+// 更新发行量计数, collectionId 不为空时同时更新该系列的计数
+func (ugc *DigitalUgcContact) _updateSupply(ctx contractapi.TransactionContextInterface, collectionId string, delta int) error {
+	counterKeys := []string{config.TotalSupplyKey}
+	if collectionId != "" {
+		collectionSupplyKey, err := ctx.GetStub().CreateCompositeKey(config.SupplyPrefix, []string{collectionId})
+		if err != nil {
+			return fmt.Errorf("[_updateSupply] CreateCompositeKey[ supplyKey: %s%s ] error, throw-err: %v", config.SupplyPrefix, collectionId, err)
+		}
+		counterKeys = append(counterKeys, collectionSupplyKey)
+	}
+
+	for _, counterKey := range counterKeys {
+		counter, found, err := ugc._readCounter(ctx, counterKey)
+		if err != nil {
+			return fmt.Errorf("[_updateSupply] _readCounter[ %s ] error, throw-err: %v", counterKey, err)
+		}
+		// The total counter is initialised from a full scan the first time it is touched,
+		// range queries do not see the writes of the current transaction
+		if !found && counterKey == config.TotalSupplyKey {
+			if counter, err = ugc._countSupply(ctx); err != nil {
+				return fmt.Errorf("[_updateSupply] _countSupply error, throw-err: %v", err)
+			}
+		}
+
+		if counter+delta < 0 {
+			return fmt.Errorf("[_updateSupply] The counter[ %s ] would become negative", counterKey)
+		}
+		err = ugc._writeCounter(ctx, counterKey, counter+delta)
+		if err != nil {
+			return fmt.Errorf("[_updateSupply] _writeCounter[ %s ] error, throw-err: %v", counterKey, err)
+		}
+	}
+
+	return nil
+}
+
+// 遍历全部 nft 复合键统计发行量
+func (ugc *DigitalUgcContact) _countSupply(ctx contractapi.TransactionContextInterface) (int, error) {
+	// There is a key record for every non-fungible token in the format of nftPrefix.tokenId.
+	totalSupplyIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(config.NftPrefix, []string{})
+	if err != nil {
+		return 0, fmt.Errorf("[_countSupply] GetStateByPartialCompositeKey[ totalSupplyIterator: %s ] error, throw-err: %v", config.NftPrefix, err)
+	}
+	defer totalSupplyIterator.Close()
+
+	totalSupply := 0
+	for totalSupplyIterator.HasNext() {
+		if _, err = totalSupplyIterator.Next(); err != nil {
+			return 0, fmt.Errorf("[_countSupply] Failed to get the next state for prefix %v: %v", config.NftPrefix, err)
+		}
+		totalSupply++
+	}
+
+	return totalSupply, nil
+}
+
 // ClientAccountBalance
 // @title       ClientAccountBalance
 // @description "ClientAccountBalance returns the balance of the requesting client's account."
@@ -887,9 +1390,9 @@ func (ugc *DigitalUgcContact) _burnUniqueTokenUri(ctx contractapi.TransactionCon
 // @return                int                          "Returns the account balance"
 func (ugc *DigitalUgcContact) ClientAccountBalance(ctx contractapi.TransactionContextInterface) (int, error) {
 	// Get ID of submitting client identity
-	clientAccountId, err := ctx.GetClientIdentity().GetID()
+	clientAccountId, err := ugc._clientAccount(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("[ClientAccountBalance] GetClientIdentity.GetID for sender error, throw-err: %v", err)
+		return 0, fmt.Errorf("[ClientAccountBalance] _clientAccount for sender error, throw-err: %v", err)
 	}
 
 	return ugc.BalanceOf(ctx, clientAccountId)
@@ -903,9 +1406,9 @@ func (ugc *DigitalUgcContact) ClientAccountBalance(ctx contractapi.TransactionCo
 // @return                string  "Return client account id"
 func (ugc *DigitalUgcContact) ClientAccountID(ctx contractapi.TransactionContextInterface) (string, error) {
 	// Get ID of submitting client identity
-	clientAccountId, err := ctx.GetClientIdentity().GetID()
+	clientAccountId, err := ugc._clientAccount(ctx)
 	if err != nil {
-		return "", fmt.Errorf("[ClientAccountID] GetClientIdentity.GetID for sender error, throw-err: %v", err)
+		return "", fmt.Errorf("[ClientAccountID] _clientAccount for sender error, throw-err: %v", err)
 	}
 
 	return clientAccountId, nil
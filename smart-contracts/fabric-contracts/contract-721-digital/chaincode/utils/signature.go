@@ -0,0 +1,10 @@
+package utils
+
+import (
+	"encoding/json"
+)
+
+// CanonicalPayload encodes the signed fields as a JSON array of strings
+func CanonicalPayload(fields ...string) ([]byte, error) {
+	return json.Marshal(fields)
+}
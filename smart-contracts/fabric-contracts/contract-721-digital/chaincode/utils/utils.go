@@ -1,6 +1,10 @@
 package utils
 
-import "strings"
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
 
 func StringStrip(input string) string {
 	if input == "" {
@@ -8,3 +12,16 @@ func StringStrip(input string) string {
 	}
 	return strings.Join(strings.Fields(input), "")
 }
+
+// NormalizeSha256Hex returns the lowercase form of a hex encoded SHA-256 digest
+func NormalizeSha256Hex(input string) (string, error) {
+	digest := strings.ToLower(strings.TrimPrefix(StringStrip(input), "0x"))
+	decoded, err := hex.DecodeString(digest)
+	if err != nil {
+		return "", fmt.Errorf("content hash is not hex encoded: %v", err)
+	}
+	if len(decoded) != 32 {
+		return "", fmt.Errorf("content hash must be 32 bytes, got %d", len(decoded))
+	}
+	return digest, nil
+}